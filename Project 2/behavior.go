@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Applikationslogik der kører på en proces i stedet for at simulationen driver alle
+// events udefra. OnReceive kaldes efter en besked er modtaget (og uret opdateret) med
+// beskedens payload; OnTick kaldes periodisk af Scheduler.Tick. Begge kan lave lokale
+// events og sende beskeder med processens metoder, uanset hvilket ur processen bruger.
+type Behavior interface {
+	OnReceive(p *Process, from int, payload string)
+	OnTick(p *Process)
+}
+
+// Replikeret tæller (G-counter): hver replika tæller sine egne increments, sender hele
+// sin state til én peer per tick round-robin og merger modtaget state med entry-vis max
+type ReplicatedCounter struct {
+	Peers      []*Process // Replikaerne state sendes til
+	Increments int        // Antal increments replikaen mangler at lave (én per tick)
+	counts     []int
+	round      int
+}
+
+// Installerer en ReplicatedCounter på hver proces i sim, der hver laver increments
+func NewReplicatedCounters(sim *Simulation, increments int) []*ReplicatedCounter {
+	counters := make([]*ReplicatedCounter, len(sim.Processes))
+	for i, p := range sim.Processes {
+		counters[i] = &ReplicatedCounter{Peers: sim.Processes, Increments: increments}
+		p.Behavior = counters[i]
+	}
+	return counters
+}
+
+// Retuner tællerens værdi som replikaen ser den
+func (c *ReplicatedCounter) Value() int {
+	sum := 0
+	for _, count := range c.counts {
+		sum += count
+	}
+	return sum
+}
+
+// Tæller op (hvis der er increments tilbage) og sender state til næste peer
+func (c *ReplicatedCounter) OnTick(p *Process) {
+	c.counts = padVector(c.counts, p.ID+1)
+	if c.Increments > 0 {
+		c.Increments--
+		c.counts[p.ID]++
+		p.HandleLocalEvent(fmt.Sprintf("increment (value %d)", c.Value()))
+	}
+	for tries := 0; tries < len(c.Peers); tries++ {
+		peer := c.Peers[c.round%len(c.Peers)]
+		c.round++
+		if peer != p {
+			entries := make([]string, len(c.counts))
+			for i, count := range c.counts {
+				entries[i] = strconv.Itoa(count)
+			}
+			p.SendMessage(peer, "counts="+strings.Join(entries, ","))
+			return
+		}
+	}
+}
+
+// Merger modtaget state
+func (c *ReplicatedCounter) OnReceive(p *Process, from int, payload string) {
+	state, ok := strings.CutPrefix(payload, "counts=")
+	if !ok {
+		return
+	}
+	entries := strings.Split(state, ",")
+	c.counts = padVector(c.counts, len(entries))
+	for i, entry := range entries {
+		if count, err := strconv.Atoi(entry); err == nil && count > c.counts[i] {
+			c.counts[i] = count
+		}
+	}
+}
+
+// DemonstrateProcessBehavior kører den samme replikerede tæller ovenpå hvert ur
+func DemonstrateProcessBehavior(out io.Writer) {
+	fmt.Fprintln(out, "\n=== PROCESS BEHAVIOR: REPLICATED COUNTER ===")
+	fmt.Fprintln(out, "4 replicas increment 5 times each (one per 10ms tick) and gossip their state round-robin for 200ms")
+	fmt.Fprintf(out, "\n%-8s | %-7s | %-14s | %-9s | %s\n", "Clock", "Events", "Values", "Converged", "P0's final clock")
+	fmt.Fprintln(out, "---------|---------|----------------|-----------|--------------------------")
+	for _, clockType := range clockTypes {
+		sim := newSimulationOfType(4, clockType)
+		sim.Out = io.Discard
+		counters := NewReplicatedCounters(sim, 5)
+		s := NewScheduler(sim, 1)
+		s.Tick(10*time.Millisecond, 200*time.Millisecond)
+		s.Run()
+
+		events, values, converged := 0, make([]string, len(counters)), true
+		for i, c := range counters {
+			events += len(sim.Processes[i].EventLog)
+			values[i] = strconv.Itoa(c.Value())
+			converged = converged && c.Value() == 20
+		}
+		fmt.Fprintf(out, "%-8s | %-7d | %-14s | %-9v | %s\n", clockType, events, strings.Join(values, " "),
+			converged, sim.Processes[0].Clock.Now())
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• The counter's logic lives in a Behavior; the simulator only delivers messages and ticks")
+	fmt.Fprintln(out, "• The application result is the same with every clock: the clock only stamps the events")
+	fmt.Fprintln(out, "• The same run gives identical interleavings, so the clocks can be compared event by event")
+}
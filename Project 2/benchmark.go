@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
 	"runtime"
+	"runtime/debug"
 	"time"
 )
 
@@ -25,25 +28,25 @@ type BenchmarkResult struct {
 }
 
 // Kør benchmark for lamport og vector
-func RunBenchmark(numProcesses int, numEvents int) BenchmarkResult {
-	fmt.Printf("\n=== Running Benchmark ===\n")
-	fmt.Printf("Processes: %d, Events per process: %d\n", numProcesses, numEvents)
+func RunBenchmark(out io.Writer, numProcesses int, numEvents int) BenchmarkResult {
+	fmt.Fprintf(out, "\n=== Running Benchmark ===\n")
+	fmt.Fprintf(out, "Processes: %d, Events per process: %d\n", numProcesses, numEvents)
 
 	result := BenchmarkResult{}
 
 	// Test Lamport
-	fmt.Println("\nTesting Lamport Clock...")
-	result.LamportMetrics = benchmarkAlgorithm(numProcesses, numEvents, false)
+	fmt.Fprintln(out, "\nTesting Lamport Clock...")
+	result.LamportMetrics = benchmarkAlgorithm(out, numProcesses, numEvents, false)
 
 	// Test Vector
-	fmt.Println("Testing Vector Clock...")
-	result.VectorMetrics = benchmarkAlgorithm(numProcesses, numEvents, true)
+	fmt.Fprintln(out, "Testing Vector Clock...")
+	result.VectorMetrics = benchmarkAlgorithm(out, numProcesses, numEvents, true)
 
 	return result
 }
 
 // Måler performance for en algoritme
-func benchmarkAlgorithm(numProcesses int, numEvents int, useVectorClock bool) Metrics {
+func benchmarkAlgorithm(out io.Writer, numProcesses int, numEvents int, useVectorClock bool) Metrics {
 	// Start memory measurement
 	var memBefore runtime.MemStats
 	runtime.GC() // Force garbage collection for accurate measurement
@@ -61,13 +64,10 @@ func benchmarkAlgorithm(numProcesses int, numEvents int, useVectorClock bool) Me
 		p.Run(done)
 	}
 
-	time.Sleep(10 * time.Millisecond)
-
 	// Generer random events
-	rand.Seed(time.Now().UnixNano())
 	for i := 0; i < numEvents; i++ {
 		for _, p := range sim.Processes {
-			eventType := rand.Intn(3) // 0=local, 1=send, 2=send
+			eventType := sim.Rand.Intn(3) // 0=local, 1=send, 2=send
 
 			switch eventType {
 			case 0:
@@ -75,7 +75,7 @@ func benchmarkAlgorithm(numProcesses int, numEvents int, useVectorClock bool) Me
 				p.HandleLocalEvent(fmt.Sprintf("Event %d", i))
 			default:
 				// Send event
-				targetID := rand.Intn(numProcesses)
+				targetID := sim.Rand.Intn(numProcesses)
 				if targetID != p.ID {
 					target := sim.Processes[targetID]
 					p.SendMessage(target, fmt.Sprintf("Msg %d", i))
@@ -86,7 +86,9 @@ func benchmarkAlgorithm(numProcesses int, numEvents int, useVectorClock bool) Me
 	}
 
 	// Vent på at alle beskeder er håndteret
-	time.Sleep(100 * time.Millisecond)
+	if err := sim.WaitUntilIdle(); err != nil {
+		fmt.Fprintln(out, "Messages still in flight:", err)
+	}
 	close(done)
 
 	// Stop timing
@@ -139,7 +141,7 @@ func calculateOrderingCorrectness(sim *Simulation) float64 {
 
 	// Saml events fra hver proces
 	for _, p := range sim.Processes {
-		if sim.UseVectorClock {
+		if sim.UsesVectorClock() {
 			// Brug de gemte vector snapshots
 			for i := 0; i < len(p.EventLog); i++ {
 				var vector []int
@@ -147,7 +149,7 @@ func calculateOrderingCorrectness(sim *Simulation) float64 {
 					vector = p.EventVectors[i]
 				} else {
 					// Fallback hvis der mangler data
-					vector = p.VectorClock.GetVector()
+					vector = p.Clock.Now().Vector()
 				}
 				allEvents = append(allEvents, EventRecord{
 					ProcessID: p.ID,
@@ -163,7 +165,7 @@ func calculateOrderingCorrectness(sim *Simulation) float64 {
 					timestamp = p.EventTimestamps[i]
 				} else {
 					// Fallback hvis der mangler data
-					timestamp = p.LamportClock.GetTime()
+					timestamp = p.Clock.Now().Time()
 				}
 				allEvents = append(allEvents, EventRecord{
 					ProcessID: p.ID,
@@ -181,12 +183,13 @@ func calculateOrderingCorrectness(sim *Simulation) float64 {
 	// Sammenlign alle event-par og se hvor mange vi kan ordne
 	totalPairs := 0
 	orderablePairs := 0
+	useVectorClock := sim.UsesVectorClock()
 
 	for i := 0; i < len(allEvents); i++ {
 		for j := i + 1; j < len(allEvents); j++ {
 			totalPairs++
 
-			if sim.UseVectorClock {
+			if useVectorClock {
 				_ = CompareVectors(allEvents[i].Vector, allEvents[j].Vector)
 				orderablePairs++
 			} else {
@@ -210,199 +213,464 @@ func calculateOrderingCorrectness(sim *Simulation) float64 {
 }
 
 // Print funktion
-func PrintMetrics(metrics Metrics) {
-	fmt.Printf("\n--- %s Metrics ---\n", metrics.ClockType)
-	fmt.Printf("Processes:           %d\n", metrics.NumProcesses)
-	fmt.Printf("Total Events:        %d\n", metrics.NumEvents)
-	fmt.Printf("Execution Time:      %v\n", metrics.TotalExecutionTime)
-	fmt.Printf("Memory Used:         %d bytes (%.2f KB)\n",
+func PrintMetrics(out io.Writer, metrics Metrics) {
+	fmt.Fprintf(out, "\n--- %s Metrics ---\n", metrics.ClockType)
+	fmt.Fprintf(out, "Processes:           %d\n", metrics.NumProcesses)
+	fmt.Fprintf(out, "Total Events:        %d\n", metrics.NumEvents)
+	fmt.Fprintf(out, "Execution Time:      %v\n", metrics.TotalExecutionTime)
+	fmt.Fprintf(out, "Memory Used:         %d bytes (%.2f KB)\n",
 		metrics.MemoryUsed, float64(metrics.MemoryUsed)/1024.0)
-	fmt.Printf("Message Overhead:    %d bytes per message\n", metrics.MessageOverhead)
-	fmt.Printf("Ordering Capability: %.1f%%\n", metrics.OrderingCorrectness)
+	fmt.Fprintf(out, "Message Overhead:    %d bytes per message\n", metrics.MessageOverhead)
+	fmt.Fprintf(out, "Ordering Capability: %.1f%%\n", metrics.OrderingCorrectness)
 }
 
 // Sammenligner og printer en comparison af to results
-func CompareResults(result BenchmarkResult) {
-	fmt.Printf("\n\n=== COMPARISON ===\n")
+func CompareResults(out io.Writer, result BenchmarkResult) {
+	fmt.Fprintf(out, "\n\n=== COMPARISON ===\n")
 
-	PrintMetrics(result.LamportMetrics)
-	PrintMetrics(result.VectorMetrics)
+	PrintMetrics(out, result.LamportMetrics)
+	PrintMetrics(out, result.VectorMetrics)
 
-	fmt.Printf("\n--- Analysis ---\n")
+	fmt.Fprintf(out, "\n--- Analysis ---\n")
 
 	// Time comparison
 	timeDiff := result.VectorMetrics.TotalExecutionTime - result.LamportMetrics.TotalExecutionTime
 	timePercent := (float64(timeDiff) / float64(result.LamportMetrics.TotalExecutionTime)) * 100
-	fmt.Printf("Time Overhead (Vector vs Lamport): %+v (%+.1f%%)\n", timeDiff, timePercent)
+	fmt.Fprintf(out, "Time Overhead (Vector vs Lamport): %+v (%+.1f%%)\n", timeDiff, timePercent)
 
 	// Memory comparison
 	memDiff := int64(result.VectorMetrics.MemoryUsed) - int64(result.LamportMetrics.MemoryUsed)
 	memPercent := (float64(memDiff) / float64(result.LamportMetrics.MemoryUsed)) * 100
-	fmt.Printf("Memory Overhead (Vector vs Lamport): %+d bytes (%+.1f%%)\n", memDiff, memPercent)
+	fmt.Fprintf(out, "Memory Overhead (Vector vs Lamport): %+d bytes (%+.1f%%)\n", memDiff, memPercent)
 
 	// Message overhead comparison
 	msgDiff := result.VectorMetrics.MessageOverhead - result.LamportMetrics.MessageOverhead
 	msgPercent := (float64(msgDiff) / float64(result.LamportMetrics.MessageOverhead)) * 100
-	fmt.Printf("Message Size Overhead (Vector vs Lamport): %+d bytes (%+.1f%%)\n", msgDiff, msgPercent)
+	fmt.Fprintf(out, "Message Size Overhead (Vector vs Lamport): %+d bytes (%+.1f%%)\n", msgDiff, msgPercent)
 
 	// Ordering capability comparison
 	orderingDiff := result.VectorMetrics.OrderingCorrectness - result.LamportMetrics.OrderingCorrectness
-	fmt.Printf("Ordering Capability Improvement: %+.1f%%\n", orderingDiff)
-
-	fmt.Printf("\n--- Summary ---\n")
-	fmt.Println("Lamport Clock:")
-	fmt.Println("  + Lower time overhead")
-	fmt.Println("  + Lower memory usage")
-	fmt.Println("  + Smaller message size")
-	fmt.Println("  - Only partial ordering (cannot determine order of concurrent events)")
-
-	fmt.Println("\nVector Clock:")
-	fmt.Println("  + Total ordering capability (can determine all causal relationships)")
-	fmt.Println("  + Can detect concurrent events")
-	fmt.Println("  - Higher overhead (time, space, message size)")
-	fmt.Println("  - Overhead scales with number of processes (O(n) per message)")
+	fmt.Fprintf(out, "Ordering Capability Improvement: %+.1f%%\n", orderingDiff)
+
+	fmt.Fprintf(out, "\n--- Summary ---\n")
+	fmt.Fprintln(out, "Lamport Clock:")
+	fmt.Fprintln(out, "  + Lower time overhead")
+	fmt.Fprintln(out, "  + Lower memory usage")
+	fmt.Fprintln(out, "  + Smaller message size")
+	fmt.Fprintln(out, "  - Only partial ordering (cannot determine order of concurrent events)")
+
+	fmt.Fprintln(out, "\nVector Clock:")
+	fmt.Fprintln(out, "  + Total ordering capability (can determine all causal relationships)")
+	fmt.Fprintln(out, "  + Can detect concurrent events")
+	fmt.Fprintln(out, "  - Higher overhead (time, space, message size)")
+	fmt.Fprintln(out, "  - Overhead grows with the number of processes (fitted per run in the scalability analysis)")
 }
 
-// Måler hvordan scalability med overhead vokser med antal processer
-func BenchmarkScalability(processCounts []int, eventsPerProcess int) {
-	fmt.Println("\n\n=== SCALABILITY ANALYSIS ===")
-	fmt.Printf("Events per process: %d\n", eventsPerProcess)
-	fmt.Printf("Running %d iterations per configuration...\n\n", 100)
+// Indstillinger for benchmark kørsler
+type BenchmarkOptions struct {
+	GOMAXPROCS int           // Antal OS-tråde Go må bruge (0 = runtime default)
+	Isolate    bool          // Tving GC og vent på goroutines mellem hver celle
+	Cooldown   time.Duration // Ekstra pause mellem celler når Isolate er slået til
 
-	fmt.Printf("%-12s | %-15s | %-15s | %-12s | %-15s | %-15s\n",
-		"Processes", "Lamport (µs)", "Vector (µs)", "Ratio", "Lamport Mem", "Vector Mem")
-	fmt.Println("-------------|-----------------|-----------------|--------------|-----------------|------------------")
+	PayloadSize    int // Mindste payload størrelse i bytes (0 = korte strenge som "M3")
+	PayloadPadding int // Op til så mange ekstra tilfældige bytes per besked
 
-	for _, numProc := range processCounts {
-		// Benchmark Lamport
-		var lamportTotal time.Duration
-		var lamportMem uint64
-		iterations := 100
-
-		for i := 0; i < iterations; i++ {
-			var memBefore runtime.MemStats
-			runtime.GC()
-			runtime.ReadMemStats(&memBefore)
-
-			start := time.Now()
-			sim := NewSimulation(numProc, false)
-			done := make(chan bool)
-			for _, p := range sim.Processes {
-				p.Run(done)
-			}
+	DiscardEvents bool // Gem ikke events under målingen, så tid og hukommelse kun er clock overhead
+}
+
+// Laver en payload der starter med label og fyldes op til den konfigurerede størrelse
+func makePayload(label string, opts BenchmarkOptions) string {
+	size := opts.PayloadSize
+	if opts.PayloadPadding > 0 {
+		size += rand.Intn(opts.PayloadPadding + 1)
+	}
+	if size <= len(label) {
+		return label
+	}
+
+	padding := make([]byte, size-len(label))
+	for i := range padding {
+		padding[i] = byte('a' + rand.Intn(26))
+	}
+	return label + string(padding)
+}
+
+// Andel af beskedens bytes der går til clock headeren
+func headerShare(stats MessageStats) float64 {
+	total := stats.HeaderBytes + stats.PayloadBytes
+	if total == 0 {
+		return 0
+	}
+	return float64(stats.HeaderBytes) / float64(total) * 100
+}
+
+// Standard indstillinger: isolation slået til, ingen cooldown
+func DefaultBenchmarkOptions() BenchmarkOptions {
+	return BenchmarkOptions{
+		Isolate: true,
+	}
+}
+
+// Sætter GOMAXPROCS og returnerer en funktion der gendanner den gamle værdi
+func applyGOMAXPROCS(opts BenchmarkOptions) func() {
+	if opts.GOMAXPROCS <= 0 {
+		return func() {}
+	}
+	previous := runtime.GOMAXPROCS(opts.GOMAXPROCS)
+	return func() {
+		runtime.GOMAXPROCS(previous)
+	}
+}
+
+// Rydder op efter forrige celle så dens goroutines og garbage ikke påvirker målingen
+func isolateCell(opts BenchmarkOptions, baselineGoroutines int) {
+	if !opts.Isolate {
+		return
+	}
 
-			// Generer events
-			for e := 0; e < eventsPerProcess; e++ {
-				for _, p := range sim.Processes {
-					if rand.Intn(2) == 0 {
-						p.HandleLocalEvent(fmt.Sprintf("E%d", e))
-					} else {
-						target := rand.Intn(numProc)
-						if target != p.ID {
-							p.SendMessage(sim.Processes[target], fmt.Sprintf("M%d", e))
-						}
+	// Vent på at process goroutines fra forrige celle er stoppet
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baselineGoroutines && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	runtime.GC()
+	debug.FreeOSMemory()
+
+	if opts.Cooldown > 0 {
+		time.Sleep(opts.Cooldown)
+	}
+}
+
+// Kører én celle (én clock type og ét antal processer) og returnerer gennemsnitlig tid,
+// hukommelse og de samlede besked stats
+func runScalabilityCell(numProc int, eventsPerProcess int, iterations int, clockType string, opts BenchmarkOptions) (time.Duration, uint64, MessageStats) {
+	var total time.Duration
+	var mem uint64
+	var stats MessageStats
+
+	for i := 0; i < iterations; i++ {
+		var memBefore runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&memBefore)
+
+		start := time.Now()
+		sim := newSimulationOfType(numProc, clockType)
+		done := make(chan bool)
+		for _, p := range sim.Processes {
+			p.DiscardEvents = opts.DiscardEvents
+			p.Run(done)
+		}
+
+		// Generer events
+		for e := 0; e < eventsPerProcess; e++ {
+			for _, p := range sim.Processes {
+				if sim.Rand.Intn(2) == 0 {
+					p.HandleLocalEvent(fmt.Sprintf("E%d", e))
+				} else {
+					target := sim.Rand.Intn(numProc)
+					if target != p.ID {
+						p.SendMessage(sim.Processes[target], makePayload(fmt.Sprintf("M%d", e), opts))
 					}
 				}
 			}
+		}
 
-			close(done)
-			lamportTotal += time.Since(start)
+		close(done)
+		total += time.Since(start)
+		stats = stats.Add(sim.MessageStats())
 
-			var memAfter runtime.MemStats
-			runtime.ReadMemStats(&memAfter)
-			lamportMem += memAfter.Alloc - memBefore.Alloc
-		}
+		var memAfter runtime.MemStats
+		runtime.ReadMemStats(&memAfter)
+		mem += memAfter.Alloc - memBefore.Alloc
+	}
+
+	return total / time.Duration(iterations), mem / uint64(iterations), stats
+}
 
-		lamportAvg := lamportTotal.Microseconds() / int64(iterations)
-		lamportMemAvg := lamportMem / uint64(iterations)
+// Måler hvordan scalability med overhead vokser med antal processer
+func BenchmarkScalability(out io.Writer, processCounts []int, eventsPerProcess int, opts BenchmarkOptions) {
+	restore := applyGOMAXPROCS(opts)
+	defer restore()
+
+	iterations := 100
+	baselineGoroutines := runtime.NumGoroutine()
+
+	fmt.Fprintln(out, "\n\n=== SCALABILITY ANALYSIS ===")
+	fmt.Fprintf(out, "Events per process: %d\n", eventsPerProcess)
+	fmt.Fprintf(out, "GOMAXPROCS: %d, Isolation: %v, Cooldown: %v\n",
+		runtime.GOMAXPROCS(0), opts.Isolate, opts.Cooldown)
+	fmt.Fprintf(out, "Payload: %d bytes + up to %d bytes random padding\n", opts.PayloadSize, opts.PayloadPadding)
+	fmt.Fprintf(out, "Event retention: %v\n", !opts.DiscardEvents)
+	fmt.Fprintf(out, "Running %d iterations per configuration...\n\n", iterations)
+
+	fmt.Fprintf(out, "%-12s | %-15s | %-15s | %-15s | %-12s | %-15s | %-15s | %-15s\n",
+		"Processes", "Lamport (µs)", "Vector (µs)", "HLC (µs)", "Ratio", "Lamport Mem", "Vector Mem", "HLC Mem")
+	fmt.Fprintln(out, "-------------|-----------------|-----------------|-----------------|--------------|-----------------|-----------------|----------------")
+
+	lamportStats := make([]MessageStats, len(processCounts))
+	vectorStats := make([]MessageStats, len(processCounts))
+	times, stats := make(map[string][]time.Duration), make(map[string][]MessageStats)
+
+	for i, numProc := range processCounts {
+		// Benchmark Lamport
+		isolateCell(opts, baselineGoroutines)
+		lamportTime, lamportMemAvg, lStats := runScalabilityCell(numProc, eventsPerProcess, iterations, "Lamport", opts)
+		lamportAvg := lamportTime.Microseconds()
+		lamportStats[i] = lStats
 
 		// Benchmark Vector
-		var vectorTotal time.Duration
-		var vectorMem uint64
+		isolateCell(opts, baselineGoroutines)
+		vectorTime, vectorMemAvg, vStats := runScalabilityCell(numProc, eventsPerProcess, iterations, "Vector", opts)
+		vectorAvg := vectorTime.Microseconds()
+		vectorStats[i] = vStats
+
+		// Benchmark HLC
+		isolateCell(opts, baselineGoroutines)
+		hlcTime, hlcMemAvg, hStats := runScalabilityCell(numProc, eventsPerProcess, iterations, "HLC", opts)
+		times["Lamport"] = append(times["Lamport"], lamportTime)
+		times["Vector"] = append(times["Vector"], vectorTime)
+		times["HLC"] = append(times["HLC"], hlcTime)
+		stats["Lamport"] = append(stats["Lamport"], lStats)
+		stats["Vector"] = append(stats["Vector"], vStats)
+		stats["HLC"] = append(stats["HLC"], hStats)
 
-		for i := 0; i < iterations; i++ {
-			var memBefore runtime.MemStats
-			runtime.GC()
-			runtime.ReadMemStats(&memBefore)
+		ratio := float64(vectorAvg) / float64(lamportAvg)
 
-			start := time.Now()
-			sim := NewSimulation(numProc, true)
-			done := make(chan bool)
-			for _, p := range sim.Processes {
-				p.Run(done)
-			}
+		fmt.Fprintf(out, "%-12d | %-15d | %-15d | %-15d | %-12.2fx | %-15d | %-15d | %-15d\n",
+			numProc, lamportAvg, vectorAvg, hlcTime.Microseconds(), ratio,
+			lamportMemAvg, vectorMemAvg, hlcMemAvg)
+	}
 
-			// Generer events
-			for e := 0; e < eventsPerProcess; e++ {
-				for _, p := range sim.Processes {
-					if rand.Intn(2) == 0 {
-						p.HandleLocalEvent(fmt.Sprintf("E%d", e))
-					} else {
-						target := rand.Intn(numProc)
-						if target != p.ID {
-							p.SendMessage(sim.Processes[target], fmt.Sprintf("M%d", e))
-						}
-					}
-				}
+	// Clock headerens andel af de faktiske beskeder med den valgte payload
+	fmt.Fprintln(out, "\n--- Header Overhead vs Payload ---")
+	fmt.Fprintf(out, "%-12s | %-15s | %-18s | %-18s\n",
+		"Processes", "Payload B/msg", "Lamport header %", "Vector header %")
+	fmt.Fprintln(out, "-------------|-----------------|--------------------|-------------------")
+	for i, numProc := range processCounts {
+		payloadPerMsg := 0.0
+		if vectorStats[i].Sent > 0 {
+			payloadPerMsg = float64(vectorStats[i].PayloadBytes) / float64(vectorStats[i].Sent)
+		}
+		fmt.Fprintf(out, "%-12d | %-15.1f | %-17.1f%% | %-17.1f%%\n",
+			numProc, payloadPerMsg, headerShare(lamportStats[i]), headerShare(vectorStats[i]))
+	}
+
+	// Målingerne fittet til teoriens O(1) og O(n)
+	fmt.Fprintln(out, "\n--- Theory vs Measurement ---")
+	PrintTheoryReport(out, scalingFits(processCounts, eventsPerProcess, times, stats))
+
+	printRetentionCost(out, processCounts, eventsPerProcess, iterations, opts, baselineGoroutines)
+
+	// Kompleksitet måles i stedet for at blive påstået
+	fmt.Fprintln(out, "\n--- Clock Operation Cost (measured) ---")
+	results := MeasureClockOperations(clockOperationSizes)
+	PrintClockOperationTable(out, results)
+	PrintComplexityEstimate(out, results)
+}
+
+// Kører hver celle med og uden event retention, så clock overhead og logging
+// (EventLog og de gemte snapshots) kan aflæses hver for sig
+func printRetentionCost(out io.Writer, processCounts []int, eventsPerProcess int, iterations int, opts BenchmarkOptions, baselineGoroutines int) {
+	fmt.Fprintln(out, "\n--- Clock Cost vs Event Logging Cost ---")
+	fmt.Fprintf(out, "%-12s | %-8s | %-15s | %-15s | %-15s | %-15s | %-10s\n",
+		"Processes", "Clock", "Clock (µs)", "Logging (µs)", "Clock Mem", "Logging Mem", "Logging %")
+	fmt.Fprintln(out, "-------------|----------|-----------------|-----------------|-----------------|-----------------|-----------")
+
+	retained, discarded := opts, opts
+	retained.DiscardEvents = false
+	discarded.DiscardEvents = true
+	for _, numProc := range processCounts {
+		for _, clockType := range clockTypes {
+			isolateCell(opts, baselineGoroutines)
+			totalTime, totalMem, _ := runScalabilityCell(numProc, eventsPerProcess, iterations, clockType, retained)
+			isolateCell(opts, baselineGoroutines)
+			clockTime, clockMem, _ := runScalabilityCell(numProc, eventsPerProcess, iterations, clockType, discarded)
+
+			// Målingerne er støjfyldte, så logging delen kan ikke blive negativ
+			loggingTime := max(totalTime-clockTime, 0)
+			loggingMem := uint64(0)
+			if totalMem > clockMem {
+				loggingMem = totalMem - clockMem
 			}
+			share := 0.0
+			if totalMem > 0 {
+				share = float64(loggingMem) / float64(totalMem) * 100
+			}
+			fmt.Fprintf(out, "%-12d | %-8s | %-15d | %-15d | %-15d | %-15d | %-9.1f%%\n",
+				numProc, clockType, clockTime.Microseconds(), loggingTime.Microseconds(),
+				clockMem, loggingMem, share)
+		}
+	}
+}
+
+// Vector størrelser der bruges i clock operation micro-benchmarks
+var clockOperationSizes = []int{10, 100, 1000, 10000}
 
-			close(done)
-			vectorTotal += time.Since(start)
+// Resultat af et micro-benchmark for én operation ved én vector størrelse
+type ClockOperationResult struct {
+	Operation   string
+	Size        int // 0 betyder at operationen er uafhængig af størrelsen
+	NsPerOp     int64
+	BytesPerOp  int64
+	AllocsPerOp int64
+}
 
-			var memAfter runtime.MemStats
-			runtime.ReadMemStats(&memAfter)
-			vectorMem += memAfter.Alloc - memBefore.Alloc
+// Hvor længe hver clock operation køres; antallet af kald fordobles til målingen varer
+// mindst så længe
+const clockOperationDuration = 20 * time.Millisecond
+
+// Måler tid, bytes og allokeringer per kald af op, på samme måde som scalability
+// cellerne måler med runtime.MemStats
+func measureClockOperation(operation string, size int, op func()) ClockOperationResult {
+	for n := 1; ; n *= 2 {
+		var memBefore, memAfter runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&memBefore)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			op()
 		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&memAfter)
+		if elapsed >= clockOperationDuration || n >= 1<<30 {
+			return ClockOperationResult{
+				Operation:   operation,
+				Size:        size,
+				NsPerOp:     elapsed.Nanoseconds() / int64(n),
+				BytesPerOp:  int64(memAfter.TotalAlloc-memBefore.TotalAlloc) / int64(n),
+				AllocsPerOp: int64(memAfter.Mallocs-memBefore.Mallocs) / int64(n),
+			}
+		}
+	}
+}
 
-		vectorAvg := vectorTotal.Microseconds() / int64(iterations)
-		vectorMemAvg := vectorMem / uint64(iterations)
+// Måler de samme operationer som micro-benchmarks i clocks_test.go for hver vector størrelse
+func MeasureClockOperations(sizes []int) []ClockOperationResult {
+	lamport := NewLamportClock()
+	results := []ClockOperationResult{
+		measureClockOperation("Lamport LocalEvent", 0, func() { lamport.LocalEvent() }),
+	}
 
-		ratio := float64(vectorAvg) / float64(lamportAvg)
+	for _, size := range sizes {
+		clock := NewVectorClock(size, 0)
+		results = append(results, measureClockOperation("Vector LocalEvent", size, func() { clock.LocalEvent() }))
+	}
+	for _, size := range sizes {
+		clock, received := NewVectorClock(size, 0), make([]int, size)
+		results = append(results, measureClockOperation("Vector ReceiveEvent", size, func() { clock.ReceiveEvent(received) }))
+	}
+
+	return results
+}
+
+// Print funktion for micro-benchmark resultater
+func PrintClockOperationTable(out io.Writer, results []ClockOperationResult) {
+	fmt.Fprintf(out, "%-20s | %-8s | %-12s | %-12s | %-10s\n",
+		"Operation", "Size", "ns/op", "B/op", "allocs/op")
+	fmt.Fprintln(out, "---------------------|----------|--------------|--------------|-----------")
+
+	for _, r := range results {
+		size := "any"
+		if r.Size > 0 {
+			size = fmt.Sprintf("%d", r.Size)
+		}
+		fmt.Fprintf(out, "%-20s | %-8s | %-12d | %-12d | %-10d\n",
+			r.Operation, size, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+	}
+}
+
+// Estimerer vækst-eksponenten k i ns/op ~ n^k ud fra mindste og største størrelse
+func PrintComplexityEstimate(out io.Writer, results []ClockOperationResult) {
+	fmt.Fprintln(out, "\nEstimated growth (ns/op ~ n^k):")
 
-		fmt.Printf("%-12d | %-15d | %-15d | %-12.2fx | %-15d | %-15d\n",
-			numProc, lamportAvg, vectorAvg, ratio,
-			lamportMemAvg, vectorMemAvg)
+	byOperation := make(map[string][]ClockOperationResult)
+	order := make([]string, 0)
+	for _, r := range results {
+		if r.Size == 0 {
+			fmt.Fprintf(out, "  %-20s constant (independent of n)\n", r.Operation)
+			continue
+		}
+		if _, ok := byOperation[r.Operation]; !ok {
+			order = append(order, r.Operation)
+		}
+		byOperation[r.Operation] = append(byOperation[r.Operation], r)
 	}
 
-	fmt.Println("\n--- Analysis ---")
-	fmt.Println("Lamport Clock: Time complexity O(1) - constant regardless of process count")
-	fmt.Println("Vector Clock:  Time complexity O(n) - grows linearly with process count")
-	fmt.Println("               (due to vector copy and merge operations)")
-	fmt.Println()
-	fmt.Println("Space Complexity:")
-	fmt.Printf("  Lamport: O(1) = 8 bytes per process\n")
-	fmt.Printf("  Vector:  O(n) = 8n bytes per process (where n = number of processes)\n")
+	for _, op := range order {
+		rs := byOperation[op]
+		if len(rs) < 2 {
+			continue
+		}
+		first, last := rs[0], rs[len(rs)-1]
+		if first.NsPerOp <= 0 || last.NsPerOp <= 0 {
+			continue
+		}
+		k := math.Log(float64(last.NsPerOp)/float64(first.NsPerOp)) /
+			math.Log(float64(last.Size)/float64(first.Size))
+		fmt.Fprintf(out, "  %-20s k = %.2f (n=%d → n=%d: %dns → %dns)\n",
+			op, k, first.Size, last.Size, first.NsPerOp, last.NsPerOp)
+	}
 }
 
 // BenchmarkMessageComplexity analyserer message overhead i detaljer
-func BenchmarkMessageComplexity(maxProcesses int) {
-	fmt.Println("\n\n=== MESSAGE COMPLEXITY ANALYSIS ===")
-	fmt.Printf("%-12s | %-18s | %-18s | %-15s\n",
-		"Processes", "Lamport Msg Size", "Vector Msg Size", "Overhead Ratio")
-	fmt.Println("-------------|--------------------|--------------------|------------------")
+func BenchmarkMessageComplexity(out io.Writer, maxProcesses int, opts BenchmarkOptions) {
+	fmt.Fprintln(out, "\n\n=== MESSAGE COMPLEXITY ANALYSIS ===")
+	fmt.Fprintf(out, "Payload: %d bytes (header share = header / (header + payload))\n", opts.PayloadSize)
+	fmt.Fprintf(out, "%-12s | %-18s | %-18s | %-15s | %-15s\n",
+		"Processes", "Lamport Msg Size", "Vector Msg Size", "Overhead Ratio", "Vector Share")
+	fmt.Fprintln(out, "-------------|--------------------|--------------------|-----------------|----------------")
 
 	for n := 5; n <= maxProcesses; n += 5 {
 		lamportSize := 8    // 1 int64
 		vectorSize := n * 8 // n int64s
 		ratio := float64(vectorSize) / float64(lamportSize)
+		share := float64(vectorSize) / float64(vectorSize+opts.PayloadSize) * 100
+
+		fmt.Fprintf(out, "%-12d | %-18d | %-18d | %-15.1fx | %-14.1f%%\n",
+			n, lamportSize, vectorSize, ratio, share)
+	}
 
-		fmt.Printf("%-12d | %-18d | %-18d | %-15.1fx\n",
-			n, lamportSize, vectorSize, ratio)
+	// Mål de faktiske headers som simulationen sender (tekst-encoding)
+	fmt.Fprintln(out, "\nMeasured clock headers (text encoding used by the simulation):")
+	fmt.Fprintf(out, "%-12s | %-18s | %-18s\n", "Processes", "Lamport B/msg", "Vector B/msg")
+	fmt.Fprintln(out, "-------------|--------------------|-------------------")
+	for n := 5; n <= maxProcesses; n += 5 {
+		lamportStats := measureMessageHeaders(n, false)
+		vectorStats := measureMessageHeaders(n, true)
+		fmt.Fprintf(out, "%-12d | %-18.1f | %-18.1f\n",
+			n, lamportStats.HeaderBytesPerMessage(), vectorStats.HeaderBytesPerMessage())
 	}
 
-	fmt.Println("\n--- Analysis ---")
-	fmt.Println("Message overhead grows linearly with number of processes for Vector clocks")
-	fmt.Println("Lamport maintains constant message size regardless of system scale")
-	fmt.Println()
-	fmt.Println("For large distributed systems (n > 100), this becomes significant:")
-	fmt.Printf("  At n=100:  Vector messages are 100x larger than Lamport\n")
-	fmt.Printf("  At n=1000: Vector messages are 1000x larger than Lamport\n")
+	fmt.Fprintln(out, "\n--- Analysis ---")
+	fmt.Fprintln(out, "Message overhead grows linearly with number of processes for Vector clocks")
+	fmt.Fprintln(out, "Lamport maintains constant message size regardless of system scale")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "For large distributed systems (n > 100), this becomes significant:")
+	fmt.Fprintf(out, "  At n=100:  Vector messages are 100x larger than Lamport\n")
+	fmt.Fprintf(out, "  At n=1000: Vector messages are 1000x larger than Lamport\n")
+	if opts.PayloadSize > 0 {
+		fmt.Fprintf(out, "  Relative to %d-byte payloads, the n=100 vector header (800 bytes) is %.1f%% of each message\n",
+			opts.PayloadSize, 800/float64(800+opts.PayloadSize)*100)
+	}
+}
+
+// Lader hver proces lave nogle events og sende én besked, og returnerer de målte stats
+func measureMessageHeaders(numProcesses int, useVectorClock bool) MessageStats {
+	sim := NewSimulation(numProcesses, useVectorClock)
+	for _, p := range sim.Processes {
+		for i := 0; i < 10; i++ {
+			p.HandleLocalEvent("work")
+		}
+		p.SendMessage(sim.Processes[(p.ID+1)%numProcesses], "ping")
+	}
+	return sim.MessageStats()
 }
 
 // MeasureOrderingCapability måler faktisk ordering capability med forskellige workloads
-func MeasureOrderingCapability(numProcesses int, concurrencyLevel float64) {
-	fmt.Println("\n\n=== ORDERING CAPABILITY MEASUREMENT ===")
-	fmt.Printf("Processes: %d, Concurrency level: %.0f%%\n", numProcesses, concurrencyLevel*100)
+func MeasureOrderingCapability(out io.Writer, numProcesses int, concurrencyLevel float64) {
+	fmt.Fprintln(out, "\n\n=== ORDERING CAPABILITY MEASUREMENT ===")
+	fmt.Fprintf(out, "Processes: %d, Concurrency level: %.0f%%\n", numProcesses, concurrencyLevel*100)
 
 	// Test Lamport
 	lamportSim := NewSimulation(numProcesses, false)
@@ -415,12 +683,12 @@ func MeasureOrderingCapability(numProcesses int, concurrencyLevel float64) {
 	numEvents := 50
 	for i := 0; i < numEvents; i++ {
 		for _, p := range lamportSim.Processes {
-			if rand.Float64() < concurrencyLevel {
+			if lamportSim.Rand.Float64() < concurrencyLevel {
 				// Concurrent local event
 				p.HandleLocalEvent(fmt.Sprintf("Local %d", i))
 			} else {
 				// Message passing (creates causal relation)
-				target := rand.Intn(numProcesses)
+				target := lamportSim.Rand.Intn(numProcesses)
 				if target != p.ID {
 					p.SendMessage(lamportSim.Processes[target], fmt.Sprintf("Msg %d", i))
 				}
@@ -429,7 +697,9 @@ func MeasureOrderingCapability(numProcesses int, concurrencyLevel float64) {
 		time.Sleep(1 * time.Millisecond)
 	}
 
-	time.Sleep(50 * time.Millisecond)
+	if err := lamportSim.WaitUntilIdle(); err != nil {
+		fmt.Fprintln(out, "Messages still in flight:", err)
+	}
 	close(done)
 
 	lamportCorrectness := calculateOrderingCorrectness(lamportSim)
@@ -443,10 +713,10 @@ func MeasureOrderingCapability(numProcesses int, concurrencyLevel float64) {
 
 	for i := 0; i < numEvents; i++ {
 		for _, p := range vectorSim.Processes {
-			if rand.Float64() < concurrencyLevel {
+			if vectorSim.Rand.Float64() < concurrencyLevel {
 				p.HandleLocalEvent(fmt.Sprintf("Local %d", i))
 			} else {
-				target := rand.Intn(numProcesses)
+				target := vectorSim.Rand.Intn(numProcesses)
 				if target != p.ID {
 					p.SendMessage(vectorSim.Processes[target], fmt.Sprintf("Msg %d", i))
 				}
@@ -455,20 +725,31 @@ func MeasureOrderingCapability(numProcesses int, concurrencyLevel float64) {
 		time.Sleep(1 * time.Millisecond)
 	}
 
-	time.Sleep(50 * time.Millisecond)
+	if err := vectorSim.WaitUntilIdle(); err != nil {
+		fmt.Fprintln(out, "Messages still in flight:", err)
+	}
 	close(done2)
 
 	vectorCorrectness := calculateOrderingCorrectness(vectorSim)
 
-	fmt.Printf("\nResults:\n")
-	fmt.Printf("  Lamport Clock: %.1f%% of event pairs can be ordered\n", lamportCorrectness)
-	fmt.Printf("  Vector Clock:  %.1f%% of event pairs can be ordered\n", vectorCorrectness)
-	fmt.Printf("  Improvement:   +%.1f%%\n", vectorCorrectness-lamportCorrectness)
-
-	fmt.Println("\n--- Interpretation ---")
-	fmt.Println("Vector Clock achieves total ordering: can determine causal relationship")
-	fmt.Println("for ALL event pairs (either happens-before or concurrent)")
-	fmt.Println()
-	fmt.Println("Lamport Clock achieves partial ordering: can only order events with")
-	fmt.Println("direct causal chains, cannot distinguish concurrent events")
+	fmt.Fprintf(out, "\nResults:\n")
+	fmt.Fprintf(out, "  Lamport Clock: %.1f%% of event pairs can be ordered\n", lamportCorrectness)
+	fmt.Fprintf(out, "  Vector Clock:  %.1f%% of event pairs can be ordered\n", vectorCorrectness)
+	fmt.Fprintf(out, "  Improvement:   +%.1f%%\n", vectorCorrectness-lamportCorrectness)
+
+	fmt.Fprintln(out, "\n--- Interpretation ---")
+	fmt.Fprintln(out, "Vector Clock achieves total ordering: can determine causal relationship")
+	fmt.Fprintln(out, "for ALL event pairs (either happens-before or concurrent)")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Lamport Clock achieves partial ordering: can only order events with")
+	fmt.Fprintln(out, "direct causal chains, cannot distinguish concurrent events")
+
+	// Hvor meget præcision mister vi ved kun at gemme K entries?
+	MeasurePruningErrors(out, numProcesses, concurrencyLevel, []int{2, 4, 6, 8, numProcesses})
+
+	// Og med et Bloom clock i konstant plads?
+	MeasureBloomClockAccuracy(out, numProcesses, concurrencyLevel, []float64{0.5, 0.1, 0.01})
+
+	// Eller med færre entries end processer?
+	MeasurePlausibleClocks(out, numProcesses, concurrencyLevel, []int{1, 2, 3, 5, 8, numProcesses})
 }
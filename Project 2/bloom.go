@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// BloomClock opsummerer den causale historie i et counting Bloom filter med et fast
+// antal celler, uanset antal processer. Hvert event lægges i hashes celler; receive
+// tager maximum celle for celle som et vector clock. Sammenligning er den samme som for
+// vectors: a → b medfører altid cells(a) < cells(b), men det omvendte gælder kun med
+// en vis sandsynlighed, så concurrent events kan fejlagtigt se ordnede ud.
+type BloomClock struct {
+	cells     []int
+	hashes    int
+	processID int
+	events    int // Antal egne events, så hvert event får et unikt ID
+	mutex     sync.Mutex
+}
+
+// Opretter et Bloom clock med et givet antal celler og hash funktioner
+func NewBloomClock(cells int, hashes int, processID int) *BloomClock {
+	return &BloomClock{cells: make([]int, cells), hashes: hashes, processID: processID}
+}
+
+// Antal celler og hash funktioner for et Bloom filter der skal rumme expectedEvents
+// events med den ønskede false positive rate (standard Bloom filter dimensionering)
+func BloomClockSize(expectedEvents int, falsePositiveRate float64) (int, int) {
+	cells := int(math.Ceil(-float64(expectedEvents) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := int(math.Round(float64(cells) / float64(expectedEvents) * math.Ln2))
+	return max(cells, 1), max(hashes, 1)
+}
+
+// Lægger et nyt eget event i filteret (kræver at mutex er låst). Cellerne findes med
+// double hashing af (proces ID, event nummer).
+func (bc *BloomClock) add() {
+	bc.events++
+	var id [16]byte
+	binary.LittleEndian.PutUint64(id[:8], uint64(bc.processID))
+	binary.LittleEndian.PutUint64(id[8:], uint64(bc.events))
+	h := fnv.New64a()
+	h.Write(id[:])
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+
+	for i := 0; i < bc.hashes; i++ {
+		bc.cells[(h1+uint64(i)*h2)%uint64(len(bc.cells))]++
+	}
+}
+
+// Lokal operation
+func (bc *BloomClock) LocalEvent() []int {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	bc.add()
+	return copyVector(bc.cells)
+}
+
+// Send event, samme som LocalEvent
+func (bc *BloomClock) SendEvent() []int {
+	return bc.LocalEvent()
+}
+
+// Merge de modtagne celler (maximum) og læg receive eventet i filteret
+func (bc *BloomClock) ReceiveEvent(receivedCells []int) []int {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	for i := range bc.cells {
+		if receivedCells[i] > bc.cells[i] {
+			bc.cells[i] = receivedCells[i]
+		}
+	}
+	bc.add()
+	return copyVector(bc.cells)
+}
+
+// Retuner et snapshot af cellerne
+func (bc *BloomClock) GetVector() ClockSnapshot {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	return ClockSnapshot{vector: copyVector(bc.cells)}
+}
+
+// Kører samme tilfældige workload (fra seed) med præcise vector clocks og et
+// approksimativt ur (newClock) side om side, og returnerer antal event-par hvor det
+// approksimative urs relation er forkert, samt antal par. Det approksimative urs
+// snapshots skal være vectors.
+func measureApproximationErrors(numProcesses int, concurrencyLevel float64, rounds int, seed int64, newClock func(id int) LogicalClock) (int, int) {
+	rng := rand.New(rand.NewSource(seed))
+	exact := make([]*VectorClock, numProcesses)
+	approx := make([]LogicalClock, numProcesses)
+	for i := 0; i < numProcesses; i++ {
+		exact[i] = NewVectorClock(numProcesses, i)
+		approx[i] = newClock(i)
+	}
+
+	var exactStamps, approxStamps [][]int
+	record := func(e []int, a ClockSnapshot) {
+		exactStamps = append(exactStamps, e)
+		approxStamps = append(approxStamps, a.vector)
+	}
+
+	for r := 0; r < rounds; r++ {
+		for i := 0; i < numProcesses; i++ {
+			if rng.Float64() < concurrencyLevel {
+				record(exact[i].LocalEvent(), approx[i].Tick())
+				continue
+			}
+
+			target := rng.Intn(numProcesses)
+			if target == i {
+				continue
+			}
+			sentExact, sentApprox := exact[i].SendEvent(), approx[i].Send()
+			record(sentExact, sentApprox)
+			record(exact[target].ReceiveEvent(sentExact), approx[target].Receive(sentApprox))
+		}
+	}
+
+	errors, pairs := 0, 0
+	for i := 0; i < len(exactStamps); i++ {
+		for j := i + 1; j < len(exactStamps); j++ {
+			pairs++
+			if CompareVectors(exactStamps[i], exactStamps[j]) != CompareVectors(approxStamps[i], approxStamps[j]) {
+				errors++
+			}
+		}
+	}
+	return errors, pairs
+}
+
+// Printer hvor mange relationer Bloom clocks tager fejl af for forskellige false positive rates
+func MeasureBloomClockAccuracy(out io.Writer, numProcesses int, concurrencyLevel float64, falsePositiveRates []float64) {
+	const rounds = 20
+	fmt.Fprintf(out, "\nBloom clocks (sized for %d events, vector clocks use %d entries):\n", numProcesses*rounds, numProcesses)
+	fmt.Fprintf(out, "%-10s | %-8s | %-8s | %-18s | %-10s\n", "Target FP", "Cells", "Hashes", "Wrong relations", "Error rate")
+	fmt.Fprintln(out, "-----------|----------|----------|--------------------|-----------")
+
+	for _, fp := range falsePositiveRates {
+		cells, hashes := BloomClockSize(numProcesses*rounds, fp)
+		errors, pairs := measureApproximationErrors(numProcesses, concurrencyLevel, rounds, 1, func(id int) LogicalClock {
+			return NewBloomClock(cells, hashes, id)
+		})
+		rate := 0.0
+		if pairs > 0 {
+			rate = float64(errors) / float64(pairs) * 100
+		}
+		fmt.Fprintf(out, "%-10g | %-8d | %-8d | %-18d | %9.2f%%\n", fp, cells, hashes, errors, rate)
+	}
+	fmt.Fprintln(out, "(The cell count follows the history length, not n, so Bloom clocks only pay off for large n)")
+}
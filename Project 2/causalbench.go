@@ -0,0 +1,159 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// Et event i den simulerede tid: en proces broadcaster, eller en besked ankommer
+type causalNetEvent struct {
+	at      int // Simuleret tid i ms
+	seq     int // Rækkefølge for events på samme tid
+	send    bool
+	process int // Afsender for send, modtager for ankomst
+	message CausalMessage
+}
+
+// Prioritetskø sorteret efter tid
+type causalNetQueue []causalNetEvent
+
+func (q causalNetQueue) Len() int { return len(q) }
+func (q causalNetQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q causalNetQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *causalNetQueue) Push(x interface{}) { *q = append(*q, x.(causalNetEvent)) }
+func (q *causalNetQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Netværket i causal delivery benchmarken
+type CausalNetwork struct {
+	LossRate     float64 // Sandsynlighed for at en transmission tabes (sendes igen efter RetransmitMs)
+	ReorderRate  float64 // Sandsynlighed for at en besked forsinkes op til ReorderMs ekstra
+	ReorderMs    int
+	RetransmitMs int
+}
+
+// Resultatet af én kørsel
+type CausalDeliveryCost struct {
+	Messages     int // Leverede beskeder (uden egne)
+	Delayed      int // Beskeder der måtte vente i bufferen
+	AddedTotalMs int // Samlet tid beskederne ventede efter ankomst
+	AddedMaxMs   int
+	PeakMessages int // Flest beskeder i én proces' buffer
+	PeakBytes    int // Flest bytes (vector + body) i én proces' buffer
+	Undelivered  int // Beskeder der stadig ventede ved slutningen (bør være 0)
+}
+
+// Gennemsnitlig ekstra leveringsforsinkelse per besked
+func (c CausalDeliveryCost) MeanAddedMs() float64 {
+	if c.Messages == 0 {
+		return 0
+	}
+	return float64(c.AddedTotalMs) / float64(c.Messages)
+}
+
+// Simulerer rounds runder hvor hver proces broadcaster én besked hver intervalMs, over
+// et netværk med tab og omrokering, og måler hvad causal delivery bufferen koster
+func simulateCausalDelivery(numProcesses int, rounds int, intervalMs int, network CausalNetwork, rng *rand.Rand) CausalDeliveryCost {
+	buffers := make([]*CausalBuffer, numProcesses)
+	for i := range buffers {
+		buffers[i] = NewCausalBuffer(numProcesses, i)
+	}
+
+	queue := &causalNetQueue{}
+	seq := 0
+	push := func(e causalNetEvent) {
+		seq++
+		e.seq = seq
+		heap.Push(queue, e)
+	}
+	for r := 0; r < rounds; r++ {
+		for p := 0; p < numProcesses; p++ {
+			push(causalNetEvent{at: r * intervalMs, send: true, process: p})
+		}
+	}
+
+	type arrivalKey struct{ sender, number, receiver int }
+	arrivedAt := make(map[arrivalKey]int)
+	cost := CausalDeliveryCost{}
+
+	for queue.Len() > 0 {
+		e := heap.Pop(queue).(causalNetEvent)
+		if e.send {
+			m := buffers[e.process].Stamp(fmt.Sprintf("P%d message %d", e.process, e.at))
+			for q := 0; q < numProcesses; q++ {
+				if q == e.process {
+					continue
+				}
+				at := e.at + 1 + rng.Intn(2)
+				if rng.Float64() < network.ReorderRate {
+					at += rng.Intn(network.ReorderMs + 1)
+				}
+				for attempts := 0; attempts < 10 && rng.Float64() < network.LossRate; attempts++ {
+					at += network.RetransmitMs
+				}
+				push(causalNetEvent{at: at, process: q, message: m})
+			}
+			continue
+		}
+
+		m := e.message
+		arrivedAt[arrivalKey{m.Sender, m.Vector[m.Sender], e.process}] = e.at
+		buffer := buffers[e.process]
+		for _, ready := range buffer.Receive(m) {
+			waited := e.at - arrivedAt[arrivalKey{ready.Sender, ready.Vector[ready.Sender], e.process}]
+			cost.Messages++
+			cost.AddedTotalMs += waited
+			cost.AddedMaxMs = max(cost.AddedMaxMs, waited)
+			if waited > 0 {
+				cost.Delayed++
+			}
+		}
+		cost.PeakMessages = max(cost.PeakMessages, buffer.Pending())
+		cost.PeakBytes = max(cost.PeakBytes, buffer.PendingBytes())
+	}
+
+	for _, b := range buffers {
+		cost.Undelivered += b.Pending()
+	}
+	return cost
+}
+
+// Benchmark af hvad causal delivery koster i buffer plads og leveringsforsinkelse under
+// forskellige tab- og omrokeringsrater, oven i selve clock overheadet
+func BenchmarkCausalDelivery(out io.Writer, numProcesses int, lossRates []float64, reorderRates []float64) {
+	const rounds, intervalMs = 50, 2
+	fmt.Fprintln(out, "\n=== CAUSAL DELIVERY BUFFERING COST ===")
+	fmt.Fprintf(out, "Processes: %d, %d broadcasts each every %d ms, 1-2 ms base latency, retransmit after 30 ms\n\n",
+		numProcesses, rounds, intervalMs)
+	fmt.Fprintf(out, "%-6s | %-8s | %-9s | %-15s | %-14s | %-14s | %-12s\n",
+		"Loss", "Reorder", "Delayed", "Mean added (ms)", "Max added (ms)", "Peak buffered", "Peak bytes")
+	fmt.Fprintln(out, "-------|----------|-----------|-----------------|----------------|----------------|-------------")
+
+	for _, loss := range lossRates {
+		for _, reorder := range reorderRates {
+			network := CausalNetwork{LossRate: loss, ReorderRate: reorder, ReorderMs: 10, RetransmitMs: 30}
+			cost := simulateCausalDelivery(numProcesses, rounds, intervalMs, network, rand.New(rand.NewSource(1)))
+			delayed := float64(cost.Delayed) / float64(cost.Messages) * 100
+			fmt.Fprintf(out, "%-6s | %-8s | %8.1f%% | %-15.2f | %-14d | %-14d | %-12d\n",
+				fmt.Sprintf("%.0f%%", loss*100), fmt.Sprintf("%.0f%%", reorder*100), delayed,
+				cost.MeanAddedMs(), cost.AddedMaxMs, cost.PeakMessages, cost.PeakBytes)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Without loss or reordering the buffer is almost free: messages arrive in causal order")
+	fmt.Fprintln(out, "• One lost message blocks everything that causally follows it until the retransmission arrives")
+	fmt.Fprintln(out, "• Loss costs far more than reordering, since a retransmit timeout is much longer than jitter")
+	fmt.Fprintln(out, "• Buffer memory grows with the broadcast rate times the retransmit timeout, per process")
+}
@@ -0,0 +1,172 @@
+// Package causality er en lille causality tracker til indlejring i andre Go services:
+// et vector clock snapshot, sammenligning og merge, uden simulatoren og uden andre
+// afhængigheder end standardbiblioteket.
+//
+// API stabilitet: alt der er eksporteret her ændres kun bagudkompatibelt, også når
+// simulatoren i logical-clocks ændrer sig. Nye funktioner kan komme til; eksisterende
+// navne, signaturer og tekstformatet fra String/Parse ændres ikke.
+package causality
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Relationen mellem to snapshots
+type Ordering int
+
+const (
+	Concurrent Ordering = iota // Ingen happens-before i nogen retning
+	Before                     // a → b
+	After                      // b → a
+	Equal                      // Samme snapshot
+)
+
+// Print funktion
+func (o Ordering) String() string {
+	switch o {
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Equal:
+		return "equal"
+	}
+	return "concurrent"
+}
+
+// En uforanderlig vector clock værdi. Entry i er antallet af events set fra proces i.
+// Mangler der entries i den korteste af to vectors, tæller de som 0.
+type Vector []uint64
+
+// Længden på den længste af to vectors
+func width(a, b Vector) int {
+	if len(a) > len(b) {
+		return len(a)
+	}
+	return len(b)
+}
+
+// Entry i, eller 0 hvis vectoren er kortere
+func (v Vector) at(i int) uint64 {
+	if i < len(v) {
+		return v[i]
+	}
+	return 0
+}
+
+// Sammenligner to vectors
+func Compare(a, b Vector) Ordering {
+	less, greater := false, false
+	for i := 0; i < width(a, b); i++ {
+		switch {
+		case a.at(i) < b.at(i):
+			less = true
+		case a.at(i) > b.at(i):
+			greater = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+// Entry-vis maximum af to vectors (en ny vector; ingen af dem ændres)
+func Merge(a, b Vector) Vector {
+	merged := make(Vector, width(a, b))
+	for i := range merged {
+		merged[i] = max(a.at(i), b.at(i))
+	}
+	return merged
+}
+
+// Print funktion: [1,2,0]
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, entry := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatUint(entry, 10))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// Parser en vector skrevet med String, fx fra en request header
+func Parse(s string) (Vector, error) {
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("malformed vector %q", s)
+	}
+	body := s[1 : len(s)-1]
+	if body == "" {
+		return Vector{}, nil
+	}
+	parts := strings.Split(body, ",")
+	v := make(Vector, len(parts))
+	for i, part := range parts {
+		entry, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed vector entry %q", part)
+		}
+		v[i] = entry
+	}
+	return v, nil
+}
+
+// Vector clock for én proces i en service. Sikker at bruge fra flere goroutines.
+type Tracker struct {
+	id     int
+	vector Vector
+	mutex  sync.Mutex
+}
+
+// Opretter en tracker for proces id (0-indekseret); vectoren vokser efter behov
+func NewTracker(id int) *Tracker {
+	return &Tracker{id: id, vector: make(Vector, id+1)}
+}
+
+// Lokalt event
+func (t *Tracker) Tick() Vector {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.vector[t.id]++
+	return t.copy()
+}
+
+// Send event; den returnerede vector sendes med beskeden
+func (t *Tracker) Send() Vector {
+	return t.Tick()
+}
+
+// Receive event: merge afsenderens vector og tæl eventet
+func (t *Tracker) Receive(received Vector) Vector {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.vector = Merge(t.vector, received)
+	t.vector[t.id]++
+	return t.copy()
+}
+
+// Aflæs vectoren uden at tælle et event
+func (t *Tracker) Now() Vector {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.copy()
+}
+
+// Returnerer en kopi (kræver at mutex er låst)
+func (t *Tracker) copy() Vector {
+	v := make(Vector, len(t.vector))
+	copy(v, t.vector)
+	return v
+}
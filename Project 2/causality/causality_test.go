@@ -0,0 +1,37 @@
+package causality
+
+import (
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	a, b := NewTracker(0), NewTracker(1)
+	a.Tick()
+	sent := a.Send()
+	local := b.Tick()
+	received := b.Receive(sent)
+
+	if got := received.String(); got != "[2,2]" {
+		t.Errorf("Forventede [2,2] efter receive, fik %s", got)
+	}
+	if Compare(sent, received) != Before || Compare(received, sent) != After {
+		t.Errorf("Send skulle komme før receive: %s vs %s", sent, received)
+	}
+	if Compare(sent, local) != Concurrent {
+		t.Errorf("Forventede concurrent, fik %s", Compare(sent, local))
+	}
+	if Compare(Vector{1}, Vector{1, 0}) != Equal {
+		t.Error("Manglende entries skulle tælle som 0")
+	}
+
+	parsed, err := Parse(received.String())
+	if err != nil || Compare(parsed, received) != Equal {
+		t.Errorf("Parse gav %v (%v)", parsed, err)
+	}
+	if _, err := Parse("[1,-2]"); err == nil {
+		t.Error("Negative entries skulle give en fejl")
+	}
+	if got := Merge(Vector{3, 0}, Vector{1, 2, 5}).String(); got != "[3,2,5]" {
+		t.Errorf("Forventede [3,2,5], fik %s", got)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// En deltager i chat rummet
+type ChatParticipant struct {
+	ID      int
+	Name    string
+	causal  bool
+	names   []string // Alle deltageres navne, indekseret efter ID
+	buffer  *CausalBuffer
+	display []string        // Beskederne i den rækkefølge deltageren så dem
+	shown   []CausalMessage // De samme beskeder, med deres vectors
+	mutex   sync.Mutex
+}
+
+// Chat rum hvor hver besked broadcastes til alle over et in-process netværk med delay
+type ChatRoom struct {
+	Participants []*ChatParticipant
+	delays       map[[2]int]time.Duration // Delay per link (afsender, modtager)
+	inFlight     sync.WaitGroup
+	said         atomic.Int64    // Antal beskeder skrevet i rummet
+	counters     MessageCounters // Beskeder på netværket (vectoren er headeren)
+}
+
+// Opretter et chat rum. Med causal=false vises beskeder i ankomst-rækkefølge.
+func NewChatRoom(names []string, causal bool) *ChatRoom {
+	room := &ChatRoom{delays: make(map[[2]int]time.Duration)}
+	for i, name := range names {
+		room.Participants = append(room.Participants, &ChatParticipant{
+			ID:     i,
+			Name:   name,
+			causal: causal,
+			names:  names,
+			buffer: NewCausalBuffer(len(names), i),
+		})
+	}
+	return room
+}
+
+// Sætter forsinkelsen på linket fra en deltager til en anden
+func (room *ChatRoom) SetDelay(from, to int, delay time.Duration) {
+	room.delays[[2]int{from, to}] = delay
+}
+
+// Deltageren skriver en besked: den vises lokalt og broadcastes til de andre
+func (room *ChatRoom) Say(from int, text string) {
+	sender := room.Participants[from]
+	sender.mutex.Lock()
+	message := sender.buffer.Stamp(text)
+	sender.show(message)
+	sender.mutex.Unlock()
+	room.said.Add(1)
+
+	for _, p := range room.Participants {
+		if p.ID == from {
+			continue
+		}
+		target := p
+		room.counters.recordSend(len(FormatVector(message.Vector)), len(text))
+		room.inFlight.Add(1)
+		time.AfterFunc(room.delays[[2]int{from, p.ID}], func() {
+			defer room.inFlight.Done()
+			room.counters.recordReceive()
+			target.receive(message)
+		})
+	}
+}
+
+// Modtager en besked og viser den (med causal delivery når det er slået til)
+func (p *ChatParticipant) receive(message CausalMessage) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.causal {
+		// Vises med det samme, men tæller stadig som set, så deltagerens næste besked
+		// afhænger af den
+		sender := message.Sender
+		p.buffer.delivered[sender] = max(p.buffer.delivered[sender], message.Vector[sender])
+		p.show(message)
+		return
+	}
+	for _, ready := range p.buffer.Receive(message) {
+		p.show(ready)
+	}
+}
+
+// Viser en besked på deltagerens skærm (kræver mutex)
+func (p *ChatParticipant) show(message CausalMessage) {
+	p.display = append(p.display, p.names[message.Sender]+": "+message.Body)
+	p.shown = append(p.shown, message)
+}
+
+// Retuner det deltageren har set indtil nu
+func (p *ChatParticipant) Transcript() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.display...)
+}
+
+// Beskederne rummet har sendt over netværket
+func (room *ChatRoom) MessageStats() MessageStats {
+	return room.counters.Snapshot()
+}
+
+// Antal beskeder som alle deltagere viser
+func (room *ChatRoom) DeliveredMulticasts() int {
+	shown := make(map[[2]int]int) // (afsender, afsenderens nummer for beskeden) → skærme
+	for _, p := range room.Participants {
+		p.mutex.Lock()
+		for _, message := range p.shown {
+			shown[[2]int{message.Sender, message.Vector[message.Sender]}]++
+		}
+		p.mutex.Unlock()
+	}
+	delivered := 0
+	for _, screens := range shown {
+		if screens == len(room.Participants) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Venter til alle beskeder er ankommet
+func (room *ChatRoom) Wait() {
+	room.inFlight.Wait()
+}
+
+// Kører samme samtale i et chat rum med eller uden causal delivery.
+// Linket Alice → Carol er langsomt, så Bob's svar når Carol før Alice's spørgsmål.
+func runChatConversation(causal bool) *ChatRoom {
+	room := NewChatRoom([]string{"Alice", "Bob", "Carol"}, causal)
+	for from := 0; from < 3; from++ {
+		for to := 0; to < 3; to++ {
+			room.SetDelay(from, to, time.Millisecond)
+		}
+	}
+	room.SetDelay(0, 2, 40*time.Millisecond)
+
+	room.Say(0, "Anyone up for lunch?")
+	time.Sleep(10 * time.Millisecond) // Bob læser spørgsmålet
+	room.Say(1, "Sure, pizza?")
+	time.Sleep(5 * time.Millisecond)
+	room.Say(2, "Count me in")
+	room.Wait()
+	return room
+}
+
+// Safety: ingen deltager viser en besked før en besked den causally afhænger af.
+// Liveness: alle beskeder vises til sidst hos alle (ingen bliver hængende i bufferen).
+// Kaldes efter Wait.
+func (room *ChatRoom) Verdicts() []Verdict {
+	violation, missing := "", ""
+	for _, p := range room.Participants {
+		p.mutex.Lock()
+		for i := 0; i < len(p.shown) && violation == ""; i++ {
+			for j := i + 1; j < len(p.shown); j++ {
+				if Compare(p.shown[j].Vector, p.shown[i].Vector) == Before {
+					violation = fmt.Sprintf("%s saw %q before %q", p.Name, p.display[i], p.display[j])
+					break
+				}
+			}
+		}
+		if shown := len(p.shown); shown < int(room.said.Load()) && missing == "" {
+			missing = fmt.Sprintf("%s shows %d of %d messages", p.Name, shown, room.said.Load())
+		}
+		p.mutex.Unlock()
+	}
+	return []Verdict{
+		safetyVerdict("causal order on every screen", violation),
+		livenessVerdict("every message eventually shown to everyone", missing),
+	}
+}
+
+// Printer hvad hver deltager så
+func (room *ChatRoom) PrintTranscripts(out io.Writer) {
+	for _, p := range room.Participants {
+		fmt.Fprintf(out, "  %s's screen:\n", p.Name)
+		for _, line := range p.Transcript() {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+// DemonstrateCausalChat viser et chat rum med og uden causal broadcast
+func DemonstrateCausalChat(out io.Writer) {
+	fmt.Fprintln(out, "\nAlice asks a question, Bob answers, but Alice's message to Carol is slow (40ms)")
+
+	fmt.Fprintln(out, "\n"+strings.Repeat("─", 50))
+	fmt.Fprintln(out, "Causal delivery OFF (messages shown on arrival)")
+	fmt.Fprintln(out, strings.Repeat("─", 50))
+	unordered := runChatConversation(false)
+	unordered.PrintTranscripts(out)
+	PrintVerdicts(out, unordered.Verdicts())
+	PrintMessageStats(out, "\nNetwork", unordered.MessageStats(), unordered.DeliveredMulticasts(), "delivered multicast")
+
+	fmt.Fprintln(out, "\n"+strings.Repeat("─", 50))
+	fmt.Fprintln(out, "Causal delivery ON (buffered until dependencies arrive)")
+	fmt.Fprintln(out, strings.Repeat("─", 50))
+	causal := runChatConversation(true)
+	causal.PrintTranscripts(out)
+	PrintVerdicts(out, causal.Verdicts())
+	PrintMessageStats(out, "\nNetwork", causal.MessageStats(), causal.DeliveredMulticasts(), "delivered multicast")
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Without causal delivery Carol sees the answer before the question")
+	fmt.Fprintln(out, "• Bob's message carries [1,1,0]: it depends on Alice's first message, so Carol buffers it")
+	fmt.Fprintln(out, "• Carol's own message is concurrent with Alice's question from Carol's point of view")
+	fmt.Fprintln(out, "• Buffering costs no messages: both rooms send n-1 messages per multicast, each with an n-entry vector")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fælles interface for logiske ure. Process bruger kun dette interface, så Lamport,
+// vector og egne ure kan bruges i den samme simulation uden at koden skal forgrene sig.
+type LogicalClock interface {
+	Tick() ClockSnapshot                          // Lokalt event
+	Send() ClockSnapshot                          // Send event; værdien følger med beskeden
+	Receive(received ClockSnapshot) ClockSnapshot // Receive event med afsenderens værdi
+	Now() ClockSnapshot                           // Aflæs uret uden at tælle et event
+}
+
+// LamportClock som LogicalClock
+func (lc *LamportClock) Tick() ClockSnapshot {
+	return LamportSnapshot(lc.LocalEvent())
+}
+
+func (lc *LamportClock) Send() ClockSnapshot {
+	return LamportSnapshot(lc.SendEvent())
+}
+
+// En vector værdi har ingen Lamport tid (Time() er 0), så den tæller kun som et event
+func (lc *LamportClock) Receive(received ClockSnapshot) ClockSnapshot {
+	return LamportSnapshot(lc.ReceiveEvent(received.Time()))
+}
+
+func (lc *LamportClock) Now() ClockSnapshot {
+	return lc.GetTime()
+}
+
+// VectorClock som LogicalClock
+func (vc *VectorClock) Tick() ClockSnapshot {
+	return ClockSnapshot{vector: vc.LocalEvent()}
+}
+
+func (vc *VectorClock) Send() ClockSnapshot {
+	return ClockSnapshot{vector: vc.SendEvent()}
+}
+
+// En besked uden vector (fx uden clock header) giver ingen viden, så der merges ikke
+func (vc *VectorClock) Receive(received ClockSnapshot) ClockSnapshot {
+	if !received.IsVector() {
+		return ClockSnapshot{vector: vc.LocalEvent()}
+	}
+	return ClockSnapshot{vector: vc.ReceiveEvent(received.vector)}
+}
+
+func (vc *VectorClock) Now() ClockSnapshot {
+	return vc.GetVector()
+}
+
+// PrunedVectorClock som LogicalClock (pruned entries sendes som UnknownEntry)
+func (pc *PrunedVectorClock) Tick() ClockSnapshot {
+	return ClockSnapshot{vector: pc.LocalEvent()}
+}
+
+func (pc *PrunedVectorClock) Send() ClockSnapshot {
+	return ClockSnapshot{vector: pc.SendEvent()}
+}
+
+func (pc *PrunedVectorClock) Receive(received ClockSnapshot) ClockSnapshot {
+	if !received.IsVector() {
+		return ClockSnapshot{vector: pc.LocalEvent()}
+	}
+	return ClockSnapshot{vector: pc.ReceiveEvent(received.vector)}
+}
+
+func (pc *PrunedVectorClock) Now() ClockSnapshot {
+	return pc.GetVector()
+}
+
+// BloomClock som LogicalClock (snapshots er cellerne som en vector)
+func (bc *BloomClock) Tick() ClockSnapshot {
+	return ClockSnapshot{vector: bc.LocalEvent()}
+}
+
+func (bc *BloomClock) Send() ClockSnapshot {
+	return ClockSnapshot{vector: bc.SendEvent()}
+}
+
+func (bc *BloomClock) Receive(received ClockSnapshot) ClockSnapshot {
+	if !received.IsVector() {
+		return ClockSnapshot{vector: bc.LocalEvent()}
+	}
+	return ClockSnapshot{vector: bc.ReceiveEvent(received.vector)}
+}
+
+func (bc *BloomClock) Now() ClockSnapshot {
+	return bc.GetVector()
+}
+
+// PlausibleClock som LogicalClock
+func (pc *PlausibleClock) Tick() ClockSnapshot {
+	return ClockSnapshot{vector: pc.LocalEvent()}
+}
+
+func (pc *PlausibleClock) Send() ClockSnapshot {
+	return ClockSnapshot{vector: pc.SendEvent()}
+}
+
+func (pc *PlausibleClock) Receive(received ClockSnapshot) ClockSnapshot {
+	if !received.IsVector() {
+		return ClockSnapshot{vector: pc.LocalEvent()}
+	}
+	return ClockSnapshot{vector: pc.ReceiveEvent(received.vector)}
+}
+
+func (pc *PlausibleClock) Now() ClockSnapshot {
+	return pc.GetVector()
+}
+
+// Clock headeren i en besked: 5 for Lamport, [1,2,0] for vector og H<pakket tid> for HLC.
+// Med flere ure adskilles værdierne med ';', fx [1,2,0];5
+func encodeClockHeader(clock ClockSnapshot) string {
+	if len(clock.stamps) > 0 {
+		parts := []string{encodeClockHeader(ClockSnapshot{time: clock.time, vector: clock.vector, hybrid: clock.hybrid})}
+		for _, stamp := range clock.stamps {
+			parts = append(parts, encodeClockHeader(stamp))
+		}
+		return strings.Join(parts, ";")
+	}
+	if clock.IsVector() {
+		return FormatVector(clock.vector)
+	}
+	if clock.IsHybrid() {
+		return "H" + strconv.Itoa(clock.time)
+	}
+	return strconv.Itoa(clock.time)
+}
+
+// Parser en clock header fra encodeClockHeader, og afviser headere den ikke kunne have
+// lavet (fx tomme eller negative værdier) i stedet for at læse dem som 0
+func parseClockHeaderChecked(header string) (ClockSnapshot, error) {
+	if primary, others, found := strings.Cut(header, ";"); found {
+		clock, err := parseClockHeaderChecked(primary)
+		if err != nil {
+			return ClockSnapshot{}, err
+		}
+		for _, part := range strings.Split(others, ";") {
+			stamp, err := parseClockHeaderChecked(part)
+			if err != nil {
+				return ClockSnapshot{}, err
+			}
+			clock.stamps = append(clock.stamps, stamp)
+		}
+		return clock, nil
+	}
+	if strings.HasPrefix(header, "[") {
+		vector, err := parseVector(header)
+		if err != nil {
+			return ClockSnapshot{}, fmt.Errorf("%w: %v", ErrMalformedClock, err)
+		}
+		return ClockSnapshot{vector: vector}, nil
+	}
+	hybrid := strings.HasPrefix(header, "H")
+	time, err := strconv.Atoi(strings.TrimPrefix(header, "H"))
+	if err != nil || time < 0 {
+		return ClockSnapshot{}, fmt.Errorf("%w: %q", ErrMalformedClock, header)
+	}
+	return ClockSnapshot{time: time, hybrid: hybrid}, nil
+}
+
+// Fejl hvis en modtaget vector ikke passer til det lokale ur, så Receive ikke indekserer
+// uden for vectoren. Forskellige slags ure er tilladt (urene håndterer det selv).
+func checkReceivedClock(local ClockSnapshot, received ClockSnapshot) error {
+	if local.IsVector() && received.IsVector() {
+		if err := checkVectorLengths(local.vector, received.vector); err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedClock, err)
+		}
+	}
+	for i := 0; i < len(local.stamps) && i < len(received.stamps); i++ {
+		if err := checkReceivedClock(local.stamps[i], received.stamps[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
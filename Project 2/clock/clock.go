@@ -0,0 +1,135 @@
+// Package clock er en drop-in erstatning for time.Now når tidsstempler bruges til at
+// ordne events: Now returnerer et hybrid logical timestamp (HLC) der følger det fysiske
+// ur, men aldrig går baglæns og altid er efter ethvert timestamp processen har modtaget.
+// Kun standardbiblioteket bruges, så pakken kan indlejres i andre Go services.
+//
+// Brug: erstat time.Now() med clock.Now() hvor et event stemples, send timestampet med
+// beskeder (String/Parse), og kald clock.Update med det modtagne timestamp.
+//
+// API stabilitet: som i causality ændres eksporterede navne, signaturer og tekstformatet
+// fra String/Parse kun bagudkompatibelt.
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Et hybrid logical timestamp: den højeste fysiske tid set (ms siden epoch) og en
+// logisk tæller der ordner events inden for samme ms
+type Timestamp struct {
+	Wall    int64
+	Logical uint32
+}
+
+// Sammenligner to timestamps: -1 hvis t er før other, 1 hvis efter, 0 hvis ens
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.Wall < other.Wall, t.Wall == other.Wall && t.Logical < other.Logical:
+		return -1
+	case t == other:
+		return 0
+	}
+	return 1
+}
+
+// Er t før other?
+func (t Timestamp) Before(other Timestamp) bool {
+	return t.Compare(other) < 0
+}
+
+// Er t efter other?
+func (t Timestamp) After(other Timestamp) bool {
+	return t.Compare(other) > 0
+}
+
+// Den fysiske del som et klokkeslæt
+func (t Timestamp) Time() time.Time {
+	return time.UnixMilli(t.Wall)
+}
+
+// Print funktion: <ms>.<logisk>, fx 1704110400000.3
+func (t Timestamp) String() string {
+	return strconv.FormatInt(t.Wall, 10) + "." + strconv.FormatUint(uint64(t.Logical), 10)
+}
+
+// Parser et timestamp skrevet med String, fx fra en request header
+func Parse(s string) (Timestamp, error) {
+	wall, logical, ok := strings.Cut(s, ".")
+	if !ok {
+		return Timestamp{}, fmt.Errorf("malformed timestamp %q", s)
+	}
+	w, err := strconv.ParseInt(wall, 10, 64)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("malformed timestamp %q", s)
+	}
+	l, err := strconv.ParseUint(logical, 10, 32)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("malformed timestamp %q", s)
+	}
+	return Timestamp{Wall: w, Logical: uint32(l)}, nil
+}
+
+// Et hybrid logical clock. Sikker at bruge fra flere goroutines.
+type HLC struct {
+	physical func() time.Time
+	last     Timestamp
+	mutex    sync.Mutex
+}
+
+// Opretter et ur der læser physical (time.Now hvis nil)
+func New(physical func() time.Time) *HLC {
+	if physical == nil {
+		physical = time.Now
+	}
+	return &HLC{physical: physical}
+}
+
+// Timestamp for et lokalt event eller en send: det fysiske ur hvis det er foran, ellers
+// det sidste timestamp med den logiske tæller talt op
+func (c *HLC) Now() Timestamp {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if physical := c.physical().UnixMilli(); physical > c.last.Wall {
+		c.last = Timestamp{Wall: physical}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Timestamp for modtagelsen af en besked stemplet med remote: efter både remote, det
+// sidste lokale timestamp og det fysiske ur
+func (c *HLC) Update(remote Timestamp) Timestamp {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	wall := max(c.last.Wall, remote.Wall, c.physical().UnixMilli())
+	switch {
+	case wall == c.last.Wall && wall == remote.Wall:
+		c.last.Logical = max(c.last.Logical, remote.Logical) + 1
+	case wall == c.last.Wall:
+		c.last.Logical++
+	case wall == remote.Wall:
+		c.last.Logical = remote.Logical + 1
+	default:
+		c.last.Logical = 0
+	}
+	c.last.Wall = wall
+	return c.last
+}
+
+// Uret Now og Update bruger
+var Default = New(time.Now)
+
+// Timestamp fra Default (erstatter time.Now)
+func Now() Timestamp {
+	return Default.Now()
+}
+
+// Opdaterer Default med et modtaget timestamp
+func Update(remote Timestamp) Timestamp {
+	return Default.Update(remote)
+}
@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHLC(t *testing.T) {
+	now := time.UnixMilli(1000)
+	behind, ahead := New(func() time.Time { return now }), New(func() time.Time { return now.Add(50 * time.Millisecond) })
+
+	sent := ahead.Now()
+	received := behind.Update(sent)
+	if !sent.Before(received) || received.Wall != 1050 || received.Logical != 1 {
+		t.Errorf("Forventede 1050.1 efter %s, fik %s", sent, received)
+	}
+	if next := behind.Now(); !received.Before(next) || next.Wall != 1050 {
+		t.Errorf("Uret gik baglæns: %s efter %s", next, received)
+	}
+
+	now = now.Add(time.Second)
+	if ts := behind.Now(); ts != (Timestamp{Wall: 2000}) {
+		t.Errorf("Forventede at følge det fysiske ur, fik %s", ts)
+	}
+
+	parsed, err := Parse(received.String())
+	if err != nil || parsed != received {
+		t.Errorf("Parse gav %v (%v)", parsed, err)
+	}
+	if _, err := Parse("1050"); err == nil {
+		t.Error("Manglende logisk del skulle give en fejl")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Et par events hvor urene bryder clock betingelsen
+type ClockConditionViolation struct {
+	From      LoggedEvent
+	To        LoggedEvent
+	Condition string // "clock" (a → b men ikke C(a) < C(b)) eller "strong" (C(a) < C(b) men ikke a → b)
+}
+
+// Print funktion
+func (v ClockConditionViolation) String() string {
+	if v.Condition == "strong" {
+		return fmt.Sprintf("%s#%d %s < %s#%d %s, but no happens-before path",
+			v.From.Label, v.From.Index, v.From.Clock, v.To.Label, v.To.Index, v.To.Clock)
+	}
+	return fmt.Sprintf("%s#%d → %s#%d, but %s is not before %s",
+		v.From.Label, v.From.Index, v.To.Label, v.To.Index, v.From.Clock, v.To.Clock)
+}
+
+// ClockConditionVerifier tjekker en optaget kørsel mod urenes grundlæggende garantier.
+// Happens-before findes ud fra loggenes rækkefølge og besked ID'erne, uafhængigt af urene.
+// Clock betingelsen (a → b ⇒ C(a) < C(b)) tjekkes for alle ure, også hvert ur i en
+// multi-clock; med Strong tjekkes også den omvendte vej (C(a) < C(b) ⇒ a → b), som kun
+// vector clocks opfylder.
+type ClockConditionVerifier struct {
+	Strong        bool
+	MaxViolations int // Stop efter så mange brud (0 = find alle)
+}
+
+// Finder alle brud på betingelserne i sim
+func (v ClockConditionVerifier) Verify(sim *Simulation) []ClockConditionViolation {
+	violations := make([]ClockConditionViolation, 0)
+	full := func() bool {
+		return v.MaxViolations > 0 && len(violations) >= v.MaxViolations
+	}
+
+	// Er alle kanter i reduktionen ordnet, følger resten af transitiviteten
+	for _, edge := range HappensBeforeReduction(sim) {
+		if full() {
+			return violations
+		}
+		if !clockOrdered(edge.From.Clock, edge.To.Clock) {
+			violations = append(violations, ClockConditionViolation{From: edge.From, To: edge.To, Condition: "clock"})
+		}
+	}
+	if !v.Strong {
+		return violations
+	}
+
+	past := newCausalPast(sim)
+	events := make([]LoggedEvent, 0)
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			events = append(events, p.loggedEvent(i))
+		}
+	}
+	for _, a := range events {
+		for _, b := range events {
+			if full() {
+				return violations
+			}
+			if !a.Clock.IsVector() || !b.Clock.IsVector() {
+				continue
+			}
+			if compareJoined(a.Clock.vector, b.Clock.vector) == Before && !past.happenedBefore(a, b) {
+				violations = append(violations, ClockConditionViolation{From: a, To: b, Condition: "strong"})
+			}
+		}
+	}
+	return violations
+}
+
+// Returnerer en fejl der beskriver de første brud, eller nil hvis kørslen er i orden
+func (v ClockConditionVerifier) Check(sim *Simulation) error {
+	violations := v.Verify(sim)
+	if len(violations) == 0 {
+		return nil
+	}
+	shown := make([]string, 0, 3)
+	for _, violation := range violations[:min(3, len(violations))] {
+		shown = append(shown, violation.String())
+	}
+	return fmt.Errorf("%d clock condition violations: %s", len(violations), strings.Join(shown, "; "))
+}
+
+// Er from før to i alle ure eventet blev stemplet med? Ure af forskellig slags (fx før
+// og efter en migration) kan ikke sammenlignes og springes over.
+func clockOrdered(from ClockSnapshot, to ClockSnapshot) bool {
+	if from.IsVector() != to.IsVector() {
+		return true
+	}
+	if from.IsVector() && compareJoined(from.vector, to.vector) != Before {
+		return false
+	}
+	if !from.IsVector() && !from.HappensBefore(to) {
+		return false
+	}
+	for i := range from.stamps {
+		if i < len(to.stamps) && !clockOrdered(from.stamps[i], to.stamps[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare for vectors fra før og efter AddProcess: manglende entries er 0
+func compareJoined(v1, v2 []int) Relation {
+	n := max(len(v1), len(v2))
+	return Compare(padVector(v1, n), padVector(v2, n))
+}
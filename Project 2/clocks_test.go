@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -8,8 +9,8 @@ import (
 func TestLamportClock(t *testing.T) {
 	clock := NewLamportClock()
 	
-	if clock.GetTime() != 0 {
-		t.Errorf("Clock skulle starte ved 0, men er %d", clock.GetTime())
+	if clock.GetTime().Time() != 0 {
+		t.Errorf("Clock skulle starte ved 0, men er %d", clock.GetTime().Time())
 	}
 	
 	time1 := clock.LocalEvent()
@@ -40,7 +41,7 @@ func TestVectorClock(t *testing.T) {
 	clock1 := NewVectorClock(3, 1)
 	
 	// Test at alle starter ved [0,0,0]
-	vec := clock0.GetVector()
+	vec := clock0.GetVector().Vector()
 	for i, v := range vec {
 		if v != 0 {
 			t.Errorf("Vector[%d] skulle være 0, men er %d", i, v)
@@ -94,6 +95,24 @@ func TestCompareVectors(t *testing.T) {
 	}
 }
 
+// Tester at Compare skelner lige vectors fra concurrent
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		v1, v2 []int
+		want   Relation
+	}{
+		{[]int{1, 2, 3}, []int{2, 3, 4}, Before},
+		{[]int{2, 3, 4}, []int{1, 2, 3}, After},
+		{[]int{1, 3, 2}, []int{2, 2, 3}, Concurrent},
+		{[]int{5, 5, 5}, []int{5, 5, 5}, Equal},
+	}
+	for _, c := range cases {
+		if got := Compare(c.v1, c.v2); got != c.want {
+			t.Errorf("Compare(%v, %v) = %s, forventede %s", c.v1, c.v2, got, c.want)
+		}
+	}
+}
+
 // Tester  happened-before relation for Lamport
 func TestLamportHappenedBefore(t *testing.T) {
 	clock := NewLamportClock()
@@ -138,6 +157,168 @@ func TestVectorCausalRelation(t *testing.T) {
 	}
 }
 
+// Tester at snapshots ikke deler state med uret
+func TestClockSnapshot(t *testing.T) {
+	clock := NewVectorClock(2, 0)
+	clock.LocalEvent()
+
+	snap := clock.GetVector()
+	v := snap.Vector()
+	v[0] = 100
+	if snap.At(0) != 1 || clock.GetVector().At(0) != 1 {
+		t.Errorf("Ændring af Vector() må ikke påvirke snapshot eller ur")
+	}
+
+	clock.LocalEvent()
+	if snap.String() != "[1,0]" {
+		t.Errorf("Snapshot skulle stadig være [1,0], men er %s", snap)
+	}
+	if !snap.HappensBefore(clock.GetVector()) {
+		t.Errorf("[1,0] skulle happen before [2,0]")
+	}
+
+	lamport := NewLamportClock()
+	before := lamport.GetTime()
+	lamport.LocalEvent()
+	if before.String() != "T0" || before.Compare(lamport.GetTime()) != -1 {
+		t.Errorf("Lamport snapshot T0 skulle være før T1, fik %s", before)
+	}
+}
+
+// Tester Merge, Clone og Equals
+func TestVectorMergeCloneEquals(t *testing.T) {
+	clock0 := NewVectorClock(3, 0)
+	clock1 := NewVectorClock(3, 1)
+	clock0.LocalEvent()
+	clock0.LocalEvent()
+	clock1.LocalEvent()
+
+	clone := clock0.Clone()
+	if !clone.Equals(clock0) {
+		t.Errorf("Clone skulle være lig originalen")
+	}
+
+	// Merge tæller ikke et event
+	if err := clock1.Merge(clock0); err != nil {
+		t.Fatal(err)
+	}
+	if got := clock1.GetVector().String(); got != "[2,1,0]" {
+		t.Errorf("Efter merge forventede [2,1,0], fik %s", got)
+	}
+
+	clone.LocalEvent()
+	if clone.Equals(clock0) || clock0.GetVector().At(0) != 2 {
+		t.Errorf("Clone skulle være uafhængig af originalen")
+	}
+
+	// Merge med sig selv må ikke deadlocke
+	clock0.Merge(clock0)
+
+	// Et kortere ur afvises uden at ændre uret
+	if err := clock0.Merge(NewVectorClock(2, 0)); err == nil || clock0.GetVector().String() != "[2,0,0]" {
+		t.Errorf("Forventede fejl og uændret ur ved merge med kortere ur, fik %v og %s", err, clock0.GetVector())
+	}
+}
+
+// Tester at pruned clocks kun kender K entries og er præcise når K = n
+func TestPrunedVectorClock(t *testing.T) {
+	clock0 := NewPrunedVectorClock(4, 0, 2)
+	clock1 := NewPrunedVectorClock(4, 1, 2)
+	clock2 := NewPrunedVectorClock(4, 2, 2)
+
+	clock2.ReceiveEvent(clock1.SendEvent())
+	vec := clock0.ReceiveEvent(clock2.SendEvent()) // Beholder P2 (nyest) og sig selv, P1 pruned
+
+	if vec[0] != 1 || vec[1] != UnknownEntry || vec[2] != 2 || vec[3] != 0 {
+		t.Errorf("Forventede [1,?,2,0], fik %v", vec)
+	}
+
+	// En 0 fra P3 må hverken gøre P1's pruned entry kendt eller skubbe P2 ud
+	clock3 := NewPrunedVectorClock(4, 3, 2)
+	vec = clock0.ReceiveEvent(clock3.SendEvent())
+	if vec[0] != 2 || vec[1] != UnknownEntry || vec[2] != UnknownEntry || vec[3] != 1 {
+		t.Errorf("Forventede [2,?,?,1], fik %v", vec)
+	}
+	vec = clock0.ReceiveEvent([]int{0, 0, 0, 0})
+	if vec[1] != UnknownEntry || vec[3] != 1 {
+		t.Errorf("Forventede at P1 forblev ukendt og P3 kendt, fik %v", vec)
+	}
+
+	if errors, _ := measurePruningErrors(5, 5, 0.5, 20, 1); errors != 0 {
+		t.Errorf("Med K = n skulle der ikke være fejl, men der var %d", errors)
+	}
+	errors1, pairs1 := measurePruningErrors(5, 2, 0.5, 20, 3)
+	errors2, pairs2 := measurePruningErrors(5, 2, 0.5, 20, 3)
+	if errors1 != errors2 || pairs1 != pairs2 {
+		t.Errorf("Samme seed skulle give samme workload, fik %d/%d og %d/%d", errors1, pairs1, errors2, pairs2)
+	}
+}
+
+// Tester at Lamport operationer ikke allokerer
+func TestLamportAllocations(t *testing.T) {
+	clock := NewLamportClock()
+
+	ops := map[string]func(){
+		"LocalEvent":   func() { clock.LocalEvent() },
+		"SendEvent":    func() { clock.SendEvent() },
+		"ReceiveEvent": func() { clock.ReceiveEvent(5) },
+		"GetTime":      func() { clock.GetTime() },
+	}
+
+	for name, op := range ops {
+		if allocs := testing.AllocsPerRun(100, op); allocs != 0 {
+			t.Errorf("Lamport %s skulle allokere 0 gange, men allokerede %.1f", name, allocs)
+		}
+	}
+}
+
+// Tester at Vector operationer kun allokerer den returnerede kopi
+func TestVectorAllocations(t *testing.T) {
+	clock := NewVectorClock(10, 0)
+	received := make([]int, 10)
+
+	ops := map[string]func(){
+		"LocalEvent":   func() { clock.LocalEvent() },
+		"SendEvent":    func() { clock.SendEvent() },
+		"ReceiveEvent": func() { clock.ReceiveEvent(received) },
+		"GetVector":    func() { clock.GetVector() },
+	}
+
+	for name, op := range ops {
+		if allocs := testing.AllocsPerRun(100, op); allocs != 1 {
+			t.Errorf("Vector %s skulle allokere præcis 1 slice (kopien den returnerer), men allokerede %.1f", name, allocs)
+		}
+	}
+}
+
+// Tester at sammenligning af vectors ikke allokerer
+func TestCompareVectorsAllocations(t *testing.T) {
+	v1 := []int{1, 2, 3}
+	v2 := []int{2, 3, 4}
+
+	allocs := testing.AllocsPerRun(100, func() { CompareVectors(v1, v2) })
+	if allocs != 0 {
+		t.Errorf("CompareVectors skulle allokere 0 gange, men allokerede %.1f", allocs)
+	}
+}
+
+// Tester formattering af vectors og at AppendFormat ikke allokerer med en genbrugt buffer
+func TestAppendFormat(t *testing.T) {
+	v := []int{1, 20, 300}
+	if got := string(AppendFormat(nil, v)); got != "[1,20,300]" {
+		t.Errorf("AppendFormat skulle give [1,20,300], men gav %s", got)
+	}
+	if got := FormatVector([]int{}); got != "[]" {
+		t.Errorf("Tom vector skulle give [], men gav %s", got)
+	}
+
+	buf := make([]byte, 0, 64)
+	allocs := testing.AllocsPerRun(100, func() { buf = AppendFormat(buf[:0], v) })
+	if allocs != 0 {
+		t.Errorf("AppendFormat skulle allokere 0 gange, men allokerede %.1f", allocs)
+	}
+}
+
 // Benchmark for Lamport local events
 func BenchmarkLamportLocalEvent(b *testing.B) {
 	clock := NewLamportClock()
@@ -175,7 +356,66 @@ func BenchmarkVectorReceive(b *testing.B) {
 func BenchmarkCompareVectors(b *testing.B) {
 	v1 := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	v2 := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		CompareVectors(v1, v2)
 	}
 }
+
+// Benchmark for FormatVector
+func BenchmarkFormatVector(b *testing.B) {
+	v := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FormatVector(v)
+	}
+}
+
+// Benchmark for AppendFormat med genbrugt buffer
+func BenchmarkAppendFormat(b *testing.B) {
+	v := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendFormat(buf[:0], v)
+	}
+}
+
+// Benchmark for Vector local events over forskellige vector størrelser
+func BenchmarkVectorLocalEventSizes(b *testing.B) {
+	for _, size := range clockOperationSizes {
+		b.Run(fmt.Sprintf("n=%d", size), benchVectorLocalEvent(size))
+	}
+}
+
+// Benchmark for Vector receive events over forskellige vector størrelser
+func BenchmarkVectorReceiveSizes(b *testing.B) {
+	for _, size := range clockOperationSizes {
+		b.Run(fmt.Sprintf("n=%d", size), benchVectorReceive(size))
+	}
+}
+
+// Returnerer et benchmark af Vector LocalEvent for en given vector størrelse
+func benchVectorLocalEvent(size int) func(b *testing.B) {
+	return func(b *testing.B) {
+		clock := NewVectorClock(size, 0)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			clock.LocalEvent()
+		}
+	}
+}
+
+// Returnerer et benchmark af Vector ReceiveEvent for en given vector størrelse
+func benchVectorReceive(size int) func(b *testing.B) {
+	return func(b *testing.B) {
+		clock := NewVectorClock(size, 0)
+		received := make([]int, size)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			clock.ReceiveEvent(received)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// En version af en værdi: hvem skrev den og hvilken vector clock den har
+type Version struct {
+	Value  string
+	Vector []int
+	Writer int
+}
+
+// ConflictResolver afgør hvad der sker når to concurrent versioner mødes.
+// Resolve returnerer de versioner der overlever, eller en fejl hvis konflikten afvises.
+type ConflictResolver interface {
+	Name() string
+	Resolve(current, incoming Version) ([]Version, error)
+}
+
+// Afviser concurrent skrivninger: klienten må læse igen og prøve forfra
+type RejectResolver struct{}
+
+func (RejectResolver) Name() string { return "reject" }
+
+func (RejectResolver) Resolve(current, incoming Version) ([]Version, error) {
+	return nil, fmt.Errorf("concurrent write: %s || %s", FormatVector(incoming.Vector), FormatVector(current.Vector))
+}
+
+// Beholder begge versioner som siblings (som Dynamo/Riak), læseren skal selv vælge
+type MultiValueResolver struct{}
+
+func (MultiValueResolver) Name() string { return "multi-value" }
+
+func (MultiValueResolver) Resolve(current, incoming Version) ([]Version, error) {
+	return []Version{current, incoming}, nil
+}
+
+// Kombinerer de to værdier med en applikations-specifik merge funktion
+type MergeResolver struct {
+	Merge func(a, b string) string
+}
+
+func (MergeResolver) Name() string { return "merge" }
+
+func (m MergeResolver) Resolve(current, incoming Version) ([]Version, error) {
+	return []Version{{
+		Value:  m.Merge(current.Value, incoming.Value),
+		Vector: maxVector(current.Vector, incoming.Vector),
+		Writer: incoming.Writer,
+	}}, nil
+}
+
+// Last-writer-wins: versionen med flest events bag sig vinder, ved lighed den højeste
+// writer ID. Taberens skrivning går tabt, men resultatet er det samme på alle replicas.
+type LWWResolver struct{}
+
+func (LWWResolver) Name() string { return "lww" }
+
+func (LWWResolver) Resolve(current, incoming Version) ([]Version, error) {
+	winner := current
+	cs, is := vectorSum(current.Vector), vectorSum(incoming.Vector)
+	if is > cs || (is == cs && incoming.Writer > current.Writer) {
+		winner = incoming
+	}
+	return []Version{{
+		Value:  winner.Value,
+		Vector: maxVector(current.Vector, incoming.Vector),
+		Writer: winner.Writer,
+	}}, nil
+}
+
+// Standard merge funktion: sorteret union af komma-separerede elementer
+func mergeSets(a, b string) string {
+	seen := make(map[string]bool)
+	items := make([]string, 0)
+	for _, item := range append(strings.Split(a, ","), strings.Split(b, ",")...) {
+		item = strings.TrimSpace(item)
+		if item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// Navnene på de indbyggede strategier
+var ConflictStrategies = []string{"reject", "multi-value", "merge", "lww"}
+
+// Finder en indbygget strategi ud fra navnet
+func ResolverByName(name string) (ConflictResolver, error) {
+	switch name {
+	case "reject":
+		return RejectResolver{}, nil
+	case "multi-value":
+		return MultiValueResolver{}, nil
+	case "merge":
+		return MergeResolver{Merge: mergeSets}, nil
+	case "lww":
+		return LWWResolver{}, nil
+	}
+	return nil, fmt.Errorf("unknown conflict strategy %q (choose %s)", name, strings.Join(ConflictStrategies, ", "))
+}
+
+// Entry-vis max af to vectors
+func maxVector(v1, v2 []int) []int {
+	result := copyVector(v1)
+	for i := range result {
+		if v2[i] > result[i] {
+			result[i] = v2[i]
+		}
+	}
+	return result
+}
+
+// Summen af alle entries (antal events versionen kender til)
+func vectorSum(v []int) int {
+	total := 0
+	for _, x := range v {
+		total += x
+	}
+	return total
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Ét punkt i conflict sweepet: par af events på forskellige processer for én
+// kombination af antal processer og concurrency level
+type ConflictRatePoint struct {
+	Processes       int
+	Concurrency     float64
+	Pairs           int // Par af events på forskellige processer
+	ConcurrentPairs int // Heraf concurrent, dvs. reelle konflikter (vector clocks finder dem alle)
+	LamportTies     int // Concurrent par med samme Lamport tid; de eneste Lamport kan se før tiebreak
+}
+
+// Andel af parrene som vector clocks melder som konflikt
+func (p ConflictRatePoint) VectorRate() float64 {
+	if p.Pairs == 0 {
+		return 0
+	}
+	return float64(p.ConcurrentPairs) / float64(p.Pairs)
+}
+
+// Andel af parrene som Lamport melder som konflikt (samme tid før tiebreak på proces ID)
+func (p ConflictRatePoint) LamportRate() float64 {
+	if p.Pairs == 0 {
+		return 0
+	}
+	return float64(p.LamportTies) / float64(p.Pairs)
+}
+
+// Andel af de reelle konflikter som Lamport med tiebreak ordner i stilhed
+func (p ConflictRatePoint) SilentRate() float64 {
+	if p.ConcurrentPairs == 0 {
+		return 0
+	}
+	return float64(p.ConcurrentPairs-p.LamportTies) / float64(p.ConcurrentPairs)
+}
+
+// Samme workload som MeasureOrderingCapability, men med synkron levering så to
+// simulationer med samme seed får præcis den samme historie
+func runConcurrencyWorkload(sim *Simulation, rounds int, concurrencyLevel float64) {
+	numProcesses := len(sim.Processes)
+	for i := 0; i < rounds; i++ {
+		for _, p := range sim.Processes {
+			if sim.Rand.Float64() < concurrencyLevel {
+				p.HandleLocalEvent(fmt.Sprintf("Local %d", i))
+			} else if target := sim.Rand.Intn(numProcesses); target != p.ID {
+				p.SendMessage(sim.Processes[target], fmt.Sprintf("Msg %d", i))
+			}
+		}
+		sim.deliverPending()
+	}
+}
+
+// Kører workloaden med Lamport og med vector clocks og sammenligner hvert par af events
+func measureConflictRate(numProcesses int, concurrencyLevel float64, rounds int, seed int64) ConflictRatePoint {
+	lamportSim := NewSimulation(numProcesses, false)
+	lamportSim.Seed(seed)
+	runConcurrencyWorkload(lamportSim, rounds, concurrencyLevel)
+
+	vectorSim := NewSimulation(numProcesses, true)
+	vectorSim.Seed(seed)
+	runConcurrencyWorkload(vectorSim, rounds, concurrencyLevel)
+
+	point := ConflictRatePoint{Processes: numProcesses, Concurrency: concurrencyLevel}
+	for a := 0; a < numProcesses; a++ {
+		for b := a + 1; b < numProcesses; b++ {
+			pa, pb := vectorSim.Processes[a], vectorSim.Processes[b]
+			la, lb := lamportSim.Processes[a], lamportSim.Processes[b]
+			for i := range pa.EventLog {
+				for j := range pb.EventLog {
+					point.Pairs++
+					if CompareVectors(pa.EventVectors[i], pb.EventVectors[j]) != 0 {
+						continue
+					}
+					point.ConcurrentPairs++
+					if la.EventTimestamps[i] == lb.EventTimestamps[j] {
+						point.LamportTies++
+					}
+				}
+			}
+		}
+	}
+	return point
+}
+
+// Skriver sweepet som CSV: processes,concurrency,pairs,concurrent_pairs,vector_rate,lamport_rate,silent_rate
+func WriteConflictRateCSV(w io.Writer, points []ConflictRatePoint) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"processes", "concurrency", "pairs", "concurrent_pairs", "vector_rate", "lamport_rate", "silent_rate"})
+	for _, p := range points {
+		writer.Write([]string{
+			strconv.Itoa(p.Processes),
+			strconv.FormatFloat(p.Concurrency, 'f', 2, 64),
+			strconv.Itoa(p.Pairs),
+			strconv.Itoa(p.ConcurrentPairs),
+			strconv.FormatFloat(p.VectorRate(), 'f', 4, 64),
+			strconv.FormatFloat(p.LamportRate(), 'f', 4, 64),
+			strconv.FormatFloat(p.SilentRate(), 'f', 4, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Sweep over concurrency levels og antal replicas: hvor ofte er to events en konflikt,
+// og hvor mange af dem opdager Lamport med tiebreak overhovedet?
+func MeasureConflictRateSweep(out io.Writer, processCounts []int, concurrencyLevels []float64, csvOut io.Writer) {
+	fmt.Fprintln(out, "\n=== CONFLICT RATE VS CONCURRENCY LEVEL ===")
+	fmt.Fprintf(out, "%-10s | %-12s | %-15s | %-15s | %-15s | %-15s\n",
+		"Replicas", "Concurrency", "Pairs", "Vector detects", "Lamport detects", "Lamport misses")
+	fmt.Fprintln(out, "-----------|--------------|-----------------|-----------------|-----------------|-----------------")
+
+	points := make([]ConflictRatePoint, 0, len(processCounts)*len(concurrencyLevels))
+	for _, numProcesses := range processCounts {
+		for _, level := range concurrencyLevels {
+			point := measureConflictRate(numProcesses, level, 30, int64(numProcesses))
+			points = append(points, point)
+			fmt.Fprintf(out, "%-10d | %11.0f%% | %-15d | %14.1f%% | %14.1f%% | %14.1f%%\n",
+				numProcesses, level*100, point.Pairs,
+				point.VectorRate()*100, point.LamportRate()*100, point.SilentRate()*100)
+		}
+	}
+
+	if csvOut != nil {
+		if err := WriteConflictRateCSV(csvOut, points); err != nil {
+			fmt.Fprintln(out, "Could not write conflict rate CSV:", err)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Vector clocks detect every concurrent pair, so their rate is the true conflict rate")
+	fmt.Fprintln(out, "• Lamport with a process ID tiebreak only sees a conflict when two times happen to be equal")
+	fmt.Fprintln(out, "• 'Lamport misses' is the share of real conflicts where Lamport/LWW picks a winner unnoticed")
+	fmt.Fprintln(out, "• Rule of thumb: once the conflict rate is more than a few percent, lost updates are routine")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Hvad der sker med en proces efter et crash
+type FailureModel int
+
+const (
+	FailStop      FailureModel = iota // Processen stopper for altid
+	CrashRecovery                     // Processen kan genstarte med det den har på stabilt lager
+)
+
+// Print funktion
+func (m FailureModel) String() string {
+	if m == CrashRecovery {
+		return "crash-recovery"
+	}
+	return "fail-stop"
+}
+
+// Crasher processen: den laver ingen events, og beskeder der ankommer (eller ligger i
+// køen) går tabt. Uret og alt andet i hukommelsen regnes for tabt; loggen er observatørens.
+func (p *Process) Crash() {
+	p.crashed.Store(true)
+	for {
+		select {
+		case event := <-p.MessageQueue:
+			p.inFlight.Add(-1)
+			event.acknowledge(DeliveryReceipt{}, ErrProcessCrashed)
+		default:
+			return
+		}
+	}
+}
+
+// Er processen crashet (og ikke genstartet)?
+func (p *Process) Crashed() bool {
+	return p.crashed.Load()
+}
+
+// Genstarter en crashet proces. Med PersistClock genindlæses uret fra stabilt lager;
+// ellers starter uret forfra, som om processen var ny. En fail-stop proces kan ikke genstarte.
+func (p *Process) Recover() error {
+	if !p.Crashed() {
+		return fmt.Errorf("%s has not crashed", p.Label())
+	}
+	if p.Failure == FailStop {
+		return fmt.Errorf("%s is fail-stop and cannot recover", p.Label())
+	}
+	state := ClockSnapshot{}
+	if p.PersistClock {
+		state = p.persistedClock
+	}
+	if err := restoreClock(p.Clock, state); err != nil {
+		return err
+	}
+	p.clockChanged(p.Clock.Now())
+	p.crashed.Store(false)
+	return nil
+}
+
+// Sætter uret til state. Et tomt snapshot giver urets starttilstand.
+func restoreClock(clock LogicalClock, state ClockSnapshot) error {
+	switch c := clock.(type) {
+	case *LamportClock:
+		c.mutex.Lock()
+		c.time = state.time
+		c.mutex.Unlock()
+	case *VectorClock:
+		c.mutex.Lock()
+		for i := range c.vector {
+			c.vector[i] = 0
+			if i < len(state.vector) {
+				c.vector[i] = state.vector[i]
+			}
+		}
+		c.mutex.Unlock()
+	case *HybridLogicalClock:
+		c.mutex.Lock()
+		c.wall, c.logical = state.Physical(), state.Logical()
+		c.mutex.Unlock()
+	case *MultiClock:
+		primary := state
+		primary.stamps = nil
+		if err := restoreClock(c.Primary, primary); err != nil {
+			return err
+		}
+		for i, other := range c.Others {
+			stamp := ClockSnapshot{}
+			if i < len(state.stamps) {
+				stamp = state.stamps[i]
+			}
+			if err := restoreClock(other, stamp); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot restore a %T after a crash", clock)
+	}
+	return nil
+}
+
+// Resultatet af én kørsel af crash scenariet
+type crashOutcome struct {
+	recovered  bool
+	clockAfter ClockSnapshot // P1's ur lige efter genstarten
+	violations int           // Brud på clock betingelsen (a → b men ikke C(a) < C(b))
+}
+
+// Kører replikerings scenariet hvor P1 crasher efter 42 ms og forsøger at genstarte efter 70 ms
+func runCrashScenario(clockType string, failure FailureModel, persist bool) crashOutcome {
+	sim := newSimulationOfType(3, clockType)
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p1.Failure = failure
+	p1.PersistClock = persist
+
+	var outcome crashOutcome
+	s := NewScheduler(sim, 1)
+	for r := 0; r < 10; r++ {
+		at := time.Duration(r) * 10 * time.Millisecond
+		s.Local(at, p0, fmt.Sprintf("write x=%d", r))
+		s.Send(at, p0, p1, fmt.Sprintf("x=%d", r))
+		s.Send(at+5*time.Millisecond, p1, p2, "forward")
+		s.Send(at+8*time.Millisecond, p2, p1, "ack")
+	}
+	s.At(42*time.Millisecond, p1.Crash)
+	s.At(70*time.Millisecond, func() {
+		outcome.recovered = p1.Recover() == nil
+		outcome.clockAfter = p1.Clock.Now()
+	})
+	s.Run()
+
+	outcome.violations = len(ClockConditionVerifier{}.Verify(sim))
+	return outcome
+}
+
+// DemonstrateCrashRecovery viser hvad der sker med urene når en proces crasher og
+// genstarter med eller uden sit ur på stabilt lager
+func DemonstrateCrashRecovery(out io.Writer) {
+	fmt.Fprintln(out, "\n=== CRASH AND RECOVERY ===")
+	fmt.Fprintln(out, "P0 replicates to P1, P1 forwards to P2 and P2 acks. P1 crashes at 42ms, tries to restart at 70ms.")
+
+	fmt.Fprintf(out, "\n%-8s | %-32s | %-10s | %-28s | %-10s\n", "Clock", "Failure model", "Restarted", "P1 clock after restart", "Violations")
+	fmt.Fprintln(out, "---------|----------------------------------|------------|------------------------------|-----------")
+	for _, clockType := range clockTypes {
+		for _, variant := range []struct {
+			name    string
+			failure FailureModel
+			persist bool
+		}{
+			{FailStop.String(), FailStop, false},
+			{CrashRecovery.String() + ", volatile clock", CrashRecovery, false},
+			{CrashRecovery.String() + ", persisted clock", CrashRecovery, true},
+		} {
+			outcome := runCrashScenario(clockType, variant.failure, variant.persist)
+			clock := "-"
+			if outcome.recovered {
+				clock = outcome.clockAfter.String()
+			}
+			fmt.Fprintf(out, "%-8s | %-32s | %-10t | %-28s | %-10d\n", clockType, variant.name, outcome.recovered, clock, outcome.violations)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Fail-stop never breaks the clocks: the process simply has no more events")
+	fmt.Fprintln(out, "• A restart with a volatile clock reuses old timestamps, so P1's own later events look older")
+	fmt.Fprintln(out, "• HLC heals itself: after the downtime the physical clock is already past the lost value")
+	fmt.Fprintln(out, "• Persisting the clock after every event (or a bound ahead of it) keeps the clock condition")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Et cut angiver for hver proces hvor mange af dens events der er med (et prefix af EventLog)
+type Cut []int
+
+// En proces' tilstand i et cut
+type ProcessState struct {
+	Label  string
+	Events []LoggedEvent
+	Clock  ClockSnapshot // Clock efter sidste event i cuttet (nul hvis ingen)
+}
+
+// Verdens tilstand i et konsistent cut
+type WorldState struct {
+	Cut       Cut
+	Processes []ProcessState
+	InTransit []string // Besked ID'er der er sendt men ikke modtaget i cuttet
+}
+
+// Finder eventet der sendte eller modtog besked id
+func (sim *Simulation) findMessageEvent(id string, eventType string) (*Process, int, bool) {
+	for _, p := range sim.Processes {
+		for i, messageID := range p.EventMessageIDs {
+			if messageID == id && p.EventTypes[i] == eventType {
+				return p, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// Rekonstruerer alle processers tilstand og clock i cuttet. Fejler hvis cuttet ikke
+// er konsistent, dvs. hvis en besked er modtaget i cuttet men sendt udenfor.
+func (sim *Simulation) StateAt(cut Cut) (WorldState, error) {
+	if len(cut) != len(sim.Processes) {
+		return WorldState{}, fmt.Errorf("cut has %d entries, expected %d", len(cut), len(sim.Processes))
+	}
+	for i, p := range sim.Processes {
+		if cut[i] < 0 || cut[i] > len(p.EventLog) {
+			return WorldState{}, fmt.Errorf("cut entry for %s is %d, but it has %d events", p.Label(), cut[i], len(p.EventLog))
+		}
+	}
+
+	state := WorldState{Cut: append(Cut(nil), cut...), Processes: make([]ProcessState, len(sim.Processes))}
+	sent := make(map[string]bool)
+	for i, p := range sim.Processes {
+		for e := 0; e < cut[i]; e++ {
+			if p.EventTypes[e] == "send" {
+				sent[p.EventMessageIDs[e]] = true
+			}
+		}
+	}
+
+	received := make(map[string]bool)
+	for i, p := range sim.Processes {
+		ps := ProcessState{Label: p.Label(), Events: make([]LoggedEvent, 0, cut[i])}
+		for e := 0; e < cut[i]; e++ {
+			event := p.loggedEvent(e)
+			if event.Type == "receive" {
+				if !sent[event.MessageID] {
+					return WorldState{}, fmt.Errorf("inconsistent cut: %s received %s, which is not sent in the cut", p.Label(), event.MessageID)
+				}
+				received[event.MessageID] = true
+			}
+			ps.Events = append(ps.Events, event)
+		}
+		if cut[i] > 0 {
+			ps.Clock = p.clockAt(cut[i] - 1)
+		} else if sim.UsesVectorClock() {
+			ps.Clock = VectorSnapshot(make([]int, len(sim.Processes)))
+		} else {
+			ps.Clock = LamportSnapshot(0)
+		}
+		state.Processes[i] = ps
+	}
+
+	for i, p := range sim.Processes {
+		for e := 0; e < cut[i]; e++ {
+			if id := p.EventMessageIDs[e]; p.EventTypes[e] == "send" && !received[id] {
+				state.InTransit = append(state.InTransit, id)
+			}
+		}
+	}
+	return state, nil
+}
+
+// Det mindste konsistente cut der indeholder alt der happened-before event index på p,
+// men ikke selve eventet ("lige før P2 modtog M1"). Kræver vector clocks: entry j i
+// eventets vector er netop antallet af Pj's events i dets causale fortid.
+func (sim *Simulation) CutBefore(p *Process, index int) (Cut, error) {
+	if !sim.UsesVectorClock() {
+		return nil, fmt.Errorf("causal cuts require vector clocks")
+	}
+	if index < 0 || index >= len(p.EventLog) {
+		return nil, fmt.Errorf("%s has no event %d", p.Label(), index)
+	}
+	clock := p.clockAt(index)
+	cut := make(Cut, len(sim.Processes))
+	for j := range cut {
+		cut[j] = clock.At(j)
+	}
+	cut[p.ID] = index
+	return cut, nil
+}
+
+// Cuttet lige før besked id blev modtaget
+func (sim *Simulation) CutBeforeReceive(id string) (Cut, error) {
+	p, index, ok := sim.findMessageEvent(id, "receive")
+	if !ok {
+		return nil, fmt.Errorf("message %s was never received", id)
+	}
+	return sim.CutBefore(p, index)
+}
+
+// Print funktion
+func (w WorldState) Print(out io.Writer) {
+	fmt.Fprintf(out, "Cut %v\n", []int(w.Cut))
+	for _, p := range w.Processes {
+		last := "(no events yet)"
+		if len(p.Events) > 0 {
+			last = p.Events[len(p.Events)-1].Log
+		}
+		fmt.Fprintf(out, "  %-4s clock %-10s last: %s\n", p.Label, p.Clock, last)
+	}
+	if len(w.InTransit) > 0 {
+		fmt.Fprintf(out, "  In transit: %v\n", w.InTransit)
+	}
+}
+
+// DemonstrateTimeTravel viser tilstanden i et optaget run "lige før" en besked modtages
+func DemonstrateTimeTravel(out io.Writer) {
+	sim := NewSimulation(3, true)
+	sim.Out = out
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+
+	p0.HandleLocalEvent("Prepare order")
+	p0.SendMessage(p1, "Order #7")
+	p2.HandleLocalEvent("Restock")
+	p2.SendMessage(p0, "Stock level")
+	sim.deliverPending()
+	p1.HandleLocalEvent("Charge card")
+	p1.SendMessage(p2, "Ship #7")
+	sim.deliverPending()
+	p2.HandleLocalEvent("Shipped")
+
+	sim.PrintLogs()
+
+	shipID := p1.EventMessageIDs[len(p1.EventMessageIDs)-1]
+	fmt.Fprintf(out, "\n=== State just before %s received %s (\"Ship #7\") ===\n", p2.Label(), shipID)
+	cut, err := sim.CutBeforeReceive(shipID)
+	if err != nil {
+		fmt.Fprintln(out, "Query failed:", err)
+		return
+	}
+	state, err := sim.StateAt(cut)
+	if err != nil {
+		fmt.Fprintln(out, "Query failed:", err)
+		return
+	}
+	state.Print(out)
+
+	fmt.Fprintln(out, "\n=== An inconsistent cut is rejected ===")
+	if _, err := sim.StateAt(Cut{0, 3, 0}); err != nil {
+		fmt.Fprintln(out, "  Cut [0 3 0]:", err)
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• The cut is the receive event's vector minus the event itself: its exact causal past")
+	fmt.Fprintln(out, "• Events outside the causal past (P0 receiving 'Stock level') may or may not be included")
+	fmt.Fprintln(out, "• A cut is consistent when every received message was also sent inside the cut")
+}
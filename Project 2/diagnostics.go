@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+)
+
+// Diagnostics for én proces
+type ProcessDiagnostics struct {
+	Label         string `json:"label"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	RunLoops      int    `json:"run_loops"`
+	Received      int64  `json:"received"`
+}
+
+// Øjebliksbillede af en kørende simulation: køer, goroutines og ikke-leverede beskeder
+type DiagnosticsReport struct {
+	Goroutines  int                  `json:"goroutines"` // runtime.NumGoroutine for hele programmet
+	RunLoops    int                  `json:"run_loops"`  // Run goroutines i denne simulation
+	Processes   []ProcessDiagnostics `json:"processes"`
+	Sent        int64                `json:"sent"`
+	Undelivered int                  `json:"undelivered"` // Beskeder der ligger i en kø
+	Lost        int64                `json:"lost"`        // Sendt men hverken leveret eller i kø (droppet)
+	Warnings    []string             `json:"warnings"`
+}
+
+// Samler diagnostics for simulationen
+func (sim *Simulation) Diagnostics() DiagnosticsReport {
+	report := DiagnosticsReport{
+		Goroutines: runtime.NumGoroutine(),
+		Processes:  make([]ProcessDiagnostics, len(sim.Processes)),
+		Warnings:   make([]string, 0),
+	}
+
+	var received int64
+	for i, p := range sim.Processes {
+		d := ProcessDiagnostics{
+			Label:         p.Label(),
+			QueueDepth:    len(p.MessageQueue),
+			QueueCapacity: cap(p.MessageQueue),
+			RunLoops:      int(p.runLoops.Load()),
+			Received:      p.Counters.Snapshot().Received,
+		}
+		report.Processes[i] = d
+		report.RunLoops += d.RunLoops
+		report.Undelivered += d.QueueDepth
+		report.Sent += p.Counters.Snapshot().Sent
+		received += d.Received
+
+		if d.RunLoops > 1 {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("%s has %d run loops (Run called more than once)", d.Label, d.RunLoops))
+		}
+		if d.QueueDepth == d.QueueCapacity {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("%s queue is full: senders will block", d.Label))
+		}
+		if d.RunLoops == 0 && d.QueueDepth > 0 {
+			report.Warnings = append(report.Warnings,
+				fmt.Sprintf("%s is stopped with %d undelivered messages", d.Label, d.QueueDepth))
+		}
+	}
+	report.Lost = report.Sent - received - int64(report.Undelivered)
+	return report
+}
+
+// Fejl hvis simulationen stadig har kørende goroutines, fx efter done er lukket
+func (r DiagnosticsReport) CheckStopped() error {
+	if r.RunLoops > 0 {
+		return fmt.Errorf("%d run loops still running after stop", r.RunLoops)
+	}
+	return nil
+}
+
+// Print funktion
+func (r DiagnosticsReport) Print(out io.Writer) {
+	fmt.Fprintln(out, "\n=== Simulation Diagnostics ===")
+	fmt.Fprintf(out, "%-10s | %-12s | %-10s | %-10s\n", "Process", "Queue", "Run loops", "Received")
+	fmt.Fprintln(out, "-----------|--------------|------------|-----------")
+	for _, p := range r.Processes {
+		fmt.Fprintf(out, "%-10s | %4d / %-5d | %-10d | %-10d\n",
+			p.Label, p.QueueDepth, p.QueueCapacity, p.RunLoops, p.Received)
+	}
+	fmt.Fprintf(out, "\nGoroutines: %d total, %d run loops in this simulation\n", r.Goroutines, r.RunLoops)
+	fmt.Fprintf(out, "Messages: %d sent, %d undelivered, %d lost\n", r.Sent, r.Undelivered, r.Lost)
+	for _, warning := range r.Warnings {
+		fmt.Fprintln(out, "  WARNING: "+warning)
+	}
+}
+
+// HTTP endpoint der returnerer simulationens diagnostics som JSON
+func (sim *Simulation) DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(sim.Diagnostics())
+	})
+}
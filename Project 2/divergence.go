@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// En key hvis replicas ikke har samme værdi og vector ved slutningen af en kørsel
+type KeyDivergence struct {
+	Key      string
+	Replicas []int           // Replica ID'erne, sorteret
+	Versions map[int]Version // Hver replicas kopi
+	Reasons  map[int]string  // Forklaring for hver replica der afviger ("" hvis den er opdateret)
+	InFlight int             // Replikerings-beskeder for key der ikke er leveret endnu
+}
+
+// Sammenligner alle replicas af hver key og forklarer hver afvigelse ud fra vectorerne:
+// en replica der er bagud mangler skrivninger fra bestemte replicas, og replicas der er
+// concurrent har konflikter som resolveren ikke har samlet
+func (s *PartialStore) Divergence() []KeyDivergence {
+	names := make([]string, 0, len(s.Keys))
+	for name := range s.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	divergent := make([]KeyDivergence, 0)
+	for _, name := range names {
+		key := s.Keys[name]
+		versions := s.replicas[name]
+		if replicasAgree(key, versions) {
+			continue
+		}
+
+		d := KeyDivergence{Key: name, Versions: versions, Reasons: make(map[int]string)}
+		d.Replicas = append(d.Replicas, key.Replicas...)
+		sort.Ints(d.Replicas)
+		for _, update := range s.pending {
+			if update.key == name {
+				d.InFlight++
+			}
+		}
+
+		latest := versions[key.Replicas[0]].Vector
+		for _, id := range key.Replicas[1:] {
+			latest = maxVector(latest, versions[id].Vector)
+		}
+		lagging := false
+		for _, id := range key.Replicas {
+			d.Reasons[id] = explainLag(key, versions[id], latest)
+			lagging = lagging || d.Reasons[id] != ""
+		}
+		if !lagging {
+			// Alle har set de samme skrivninger, men har løst en konflikt forskelligt
+			for _, id := range key.Replicas {
+				d.Reasons[id] = "same writes seen, but the conflict was resolved differently"
+			}
+		}
+		divergent = append(divergent, d)
+	}
+	return divergent
+}
+
+// Har alle replicas samme værdi og vector?
+func replicasAgree(key PartialKey, versions map[int]Version) bool {
+	first := versions[key.Replicas[0]]
+	for _, id := range key.Replicas[1:] {
+		if v := versions[id]; v.Value != first.Value || !vectorsEqual(v.Vector, first.Vector) {
+			return false
+		}
+	}
+	return true
+}
+
+// Forklaring på hvorfor en replica afviger fra den samlede viden (latest)
+func explainLag(key PartialKey, version Version, latest []int) string {
+	missing := make([]string, 0)
+	for slot, seen := range version.Vector {
+		if gap := latest[slot] - seen; gap > 0 {
+			missing = append(missing, fmt.Sprintf("%d write(s) by P%d", gap, key.Replicas[slot]))
+		}
+	}
+	if len(missing) > 0 {
+		return "missing " + strings.Join(missing, ", ")
+	}
+	return ""
+}
+
+// Skriver en rapport over replica divergens, eller at alle keys er konvergeret
+func (s *PartialStore) FprintDivergence(w io.Writer) {
+	fmt.Fprintln(w, "\n=== Replica divergence at end of run ===")
+	divergent := s.Divergence()
+	if len(divergent) == 0 {
+		fmt.Fprintf(w, "  All %d keys converged\n", len(s.Keys))
+		return
+	}
+
+	for _, d := range divergent {
+		fmt.Fprintf(w, "  %s: diverged\n", d.Key)
+		for _, id := range d.Replicas {
+			v := d.Versions[id]
+			line := fmt.Sprintf("    P%d: %-12q %s", id, v.Value, FormatVector(v.Vector))
+			if reason := d.Reasons[id]; reason != "" {
+				line += " → " + reason
+			} else {
+				line += " (up to date)"
+			}
+			fmt.Fprintln(w, line)
+		}
+		if d.InFlight > 0 {
+			fmt.Fprintf(w, "    %d update(s) still in flight; replicas converge once they are delivered\n", d.InFlight)
+		} else {
+			fmt.Fprintln(w, "    No updates in flight: the replicas will not converge on their own (rejected conflicts or lost writes)")
+		}
+	}
+	fmt.Fprintf(w, "  %d of %d keys diverged\n", len(divergent), len(s.Keys))
+}
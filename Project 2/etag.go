@@ -0,0 +1,443 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// En HTTP ressource hvis version er en vector clock med én entry per klient.
+// GET returnerer værdien med vectoren som ETag; PUT kræver If-Match med klientens
+// vector og afvises hvis den er forældet (412). Concurrent skrivninger afgøres af
+// Resolver; uden en resolver afvises de (409).
+type VersionedResource struct {
+	value    string
+	vector   []int
+	writer   int
+	siblings []Version // Concurrent versioner bevaret af en multi-value resolver
+	stored   []Version // Hver version ressourcen har gemt, i rækkefølge
+	Resolver ConflictResolver
+	mutex    sync.Mutex
+}
+
+// Opretter en ressource til numClients klienter
+func NewVersionedResource(numClients int, initial string) *VersionedResource {
+	return &VersionedResource{
+		value:  initial,
+		vector: make([]int, numClients),
+	}
+}
+
+// Retuner nuværende værdi og ETag
+func (r *VersionedResource) Current() (string, string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.value, vectorETag(r.vector)
+}
+
+// Retuner de concurrent versioner der er bevaret som siblings (nil hvis der ikke er nogen)
+func (r *VersionedResource) Siblings() []Version {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Version(nil), r.siblings...)
+}
+
+// Husker den nuværende version efter en accepteret skrivning (kræver mutex)
+func (r *VersionedResource) store() {
+	r.stored = append(r.stored, Version{Value: r.value, Vector: copyVector(r.vector), Writer: r.writer})
+}
+
+// Safety: hver gemt version indeholder den forrige (ingen lost update: en skrivning
+// erstatter kun en værdi den har set, bortset fra en gentagelse af samme skrivning).
+// Liveness: hver klient der har skrevet fik til sidst mindst én skrivning accepteret.
+func (r *VersionedResource) Verdicts(history *History) []Verdict {
+	r.mutex.Lock()
+	violation := ""
+	for i := 1; i < len(r.stored) && violation == ""; i++ {
+		previous, next := r.stored[i-1], r.stored[i]
+		relation := Compare(previous.Vector, next.Vector)
+		if relation != Before && !(relation == Equal && previous.Value == next.Value) {
+			violation = fmt.Sprintf("%q %s replaced %q %s", next.Value, FormatVector(next.Vector),
+				previous.Value, FormatVector(previous.Vector))
+		}
+	}
+	r.mutex.Unlock()
+
+	clients := make([]int, 0)
+	writes, accepted := make(map[int]int), make(map[int]int)
+	for _, op := range history.Operations() {
+		if op.Kind != "write" {
+			continue
+		}
+		if writes[op.ClientID] == 0 {
+			clients = append(clients, op.ClientID)
+		}
+		writes[op.ClientID]++
+		if op.OK {
+			accepted[op.ClientID]++
+		}
+	}
+	sort.Ints(clients)
+	missing := ""
+	for _, client := range clients {
+		if accepted[client] == 0 {
+			missing = fmt.Sprintf("client %d: 0 of %d writes accepted", client, writes[client])
+			break
+		}
+	}
+	return []Verdict{
+		safetyVerdict("no lost update (versions only grow)", violation),
+		livenessVerdict("every writing client eventually accepted", missing),
+	}
+}
+
+// Afgør en concurrent skrivning med resolveren og gemmer resultatet
+func (r *VersionedResource) resolve(incoming Version) error {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = RejectResolver{}
+	}
+	current := Version{Value: r.value, Vector: r.vector, Writer: r.writer}
+	versions, err := resolver.Resolve(current, incoming)
+	if err != nil {
+		return err
+	}
+
+	// Den gemte vector dominerer alle overlevende versioner
+	merged := copyVector(r.vector)
+	values := make([]string, len(versions))
+	for i, v := range versions {
+		merged = maxVector(merged, v.Vector)
+		values[i] = v.Value
+	}
+	r.value = strings.Join(values, " | ")
+	r.vector = merged
+	r.writer = versions[len(versions)-1].Writer
+	r.siblings = nil
+	if len(versions) > 1 {
+		r.siblings = versions
+	}
+	return nil
+}
+
+// Formatterer en vector som en (strong) ETag: "[1,0,2]"
+func vectorETag(v []int) string {
+	return `"` + FormatVector(v) + `"`
+}
+
+// Parser en ETag tilbage til en vector og validerer citationstegnene og længden
+func parseVectorETag(etag string, length int) ([]int, error) {
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return nil, fmt.Errorf("malformed vector ETag %q: not a quoted strong ETag", etag)
+	}
+	v, err := parseVector(etag[1 : len(etag)-1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed vector ETag: %w", err)
+	}
+	if len(v) != length {
+		return nil, fmt.Errorf("vector ETag has %d entries, expected %d", len(v), length)
+	}
+	return v, nil
+}
+
+// HTTP handler for GET og PUT
+func (r *VersionedResource) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("ETag", vectorETag(r.vector))
+		io.WriteString(w, r.value)
+
+	case http.MethodPut:
+		ifMatch := req.Header.Get("If-Match")
+		if ifMatch == "" {
+			http.Error(w, "If-Match header required", http.StatusPreconditionRequired)
+			return
+		}
+		supplied, err := parseVectorETag(ifMatch, len(r.vector))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch Compare(supplied, r.vector) {
+		case Equal, After:
+			// Klienten har set alt serveren har (og evt. mere): skrivningen er sikker
+			body, _ := io.ReadAll(req.Body)
+			r.value = string(body)
+			r.vector = supplied
+			r.writer = writerOf(req)
+			r.siblings = nil
+			r.store()
+			w.Header().Set("ETag", vectorETag(r.vector))
+			w.WriteHeader(http.StatusOK)
+		case Before:
+			// Klienten har ikke set den seneste version
+			w.Header().Set("ETag", vectorETag(r.vector))
+			http.Error(w, "stale write: "+FormatVector(supplied)+" happened before "+FormatVector(r.vector), http.StatusPreconditionFailed)
+		default:
+			// Concurrent skrivning, resolveren bestemmer
+			body, _ := io.ReadAll(req.Body)
+			err := r.resolve(Version{Value: string(body), Vector: supplied, Writer: writerOf(req)})
+			w.Header().Set("ETag", vectorETag(r.vector))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			r.store()
+			w.WriteHeader(http.StatusOK)
+		}
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Klientens ID fra X-Writer headeren (-1 hvis den mangler)
+func writerOf(req *http.Request) int {
+	id, err := strconv.Atoi(req.Header.Get("X-Writer"))
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// Er to vectors ens entry for entry?
+func vectorsEqual(v1, v2 []int) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i := range v1 {
+		if v1[i] != v2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// En klient der husker den seneste vector den har set og tæller sine egne skrivninger
+type ETagClient struct {
+	ID       int
+	URL      string
+	History  *History         // Hvis sat, registreres alle GET og PUT
+	Counters *MessageCounters // Hvis sat, tælles requests og svar (ETag og If-Match er clock headeren)
+	vector   []int
+}
+
+// Tæller en request og svaret på den
+func (c *ETagClient) count(ifMatch string, requestBytes int, resp *http.Response, responseBytes int64) {
+	if c.Counters == nil {
+		return
+	}
+	c.Counters.recordSend(len(ifMatch), requestBytes)
+	c.Counters.recordSend(len(resp.Header.Get("ETag")), int(responseBytes))
+	c.Counters.recordReceive()
+}
+
+// Henter værdien og merger serverens vector ind i klientens
+func (c *ETagClient) Get() (value string, err error) {
+	if c.History != nil {
+		call := c.History.now()
+		defer func() {
+			c.History.record(Operation{ClientID: c.ID, Kind: "read", Output: value, OK: err == nil, Call: call, Return: c.History.now()})
+		}()
+	}
+
+	resp, err := http.Get(c.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	c.count("", 0, resp, int64(len(body)))
+	server, err := parseVectorETag(resp.Header.Get("ETag"), len(c.vector))
+	if err != nil {
+		return "", err
+	}
+	for i := range c.vector {
+		if server[i] > c.vector[i] {
+			c.vector[i] = server[i]
+		}
+	}
+	return string(body), nil
+}
+
+// Skriver en ny værdi betinget af klientens vector og returnerer HTTP status. Klienten
+// tæller kun skrivningen med i sin vector hvis serveren accepterer den (200).
+func (c *ETagClient) Put(value string) (status int, err error) {
+	if c.History != nil {
+		call := c.History.now()
+		defer func() {
+			// Ved en transportfejl ved klienten ikke om serveren nåede at skrive
+			ok := err == nil && status == http.StatusOK
+			c.History.record(Operation{ClientID: c.ID, Kind: "write", Input: value, OK: ok, Unknown: err != nil,
+				Call: call, Return: c.History.now()})
+		}()
+	}
+
+	proposed := c.nextVector()
+	req, err := http.NewRequest(http.MethodPut, c.URL, strings.NewReader(value))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("If-Match", vectorETag(proposed))
+	req.Header.Set("X-Writer", strconv.Itoa(c.ID))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	n, _ := io.Copy(io.Discard, resp.Body)
+	c.count(req.Header.Get("If-Match"), len(value), resp, n)
+	if resp.StatusCode == http.StatusOK {
+		c.vector = proposed
+	}
+	return resp.StatusCode, nil
+}
+
+// Vectoren klientens næste skrivning sender som If-Match: dens vector med skrivningen talt med
+func (c *ETagClient) nextVector() []int {
+	next := copyVector(c.vector)
+	next[c.ID]++
+	return next
+}
+
+// En HTTP server på en ledig port på localhost til demoerne (som httptest.NewServer,
+// men uden at trække testing pakken med ind i programmet)
+type localServer struct {
+	URL    string
+	server *http.Server
+}
+
+// Starter handler på en ledig port
+func startLocalServer(handler http.Handler) (*localServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &localServer{URL: "http://" + listener.Addr().String(), server: &http.Server{Handler: handler}}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// Stopper serveren og lukker dens forbindelser
+func (s *localServer) Close() {
+	s.server.Close()
+}
+
+// DemonstrateETagConcurrency viser optimistic concurrency control med vector clock ETags.
+// resolver afgør concurrent skrivninger (nil afviser dem med 409). Retuner klienternes
+// operationshistorik så den kan eksporteres til en linearizability checker.
+func DemonstrateETagConcurrency(out io.Writer, resolver ConflictResolver) *History {
+	resource := NewVersionedResource(2, "draft")
+	resource.Resolver = resolver
+	history := NewHistory()
+	server, err := startLocalServer(resource)
+	if err != nil {
+		fmt.Fprintln(out, "Could not start the HTTP server:", err)
+		return history
+	}
+	defer server.Close()
+
+	counters := &MessageCounters{}
+	alice := &ETagClient{ID: 0, URL: server.URL, History: history, Counters: counters, vector: make([]int, 2)}
+	bob := &ETagClient{ID: 1, URL: server.URL, History: history, Counters: counters, vector: make([]int, 2)}
+
+	step := func(who string, action string, status int, err error) {
+		if err != nil {
+			fmt.Fprintf(out, "  %-5s %-28s error: %v\n", who, action, err)
+			return
+		}
+		_, etag := resource.Current()
+		fmt.Fprintf(out, "  %-5s %-28s → %d %s (server ETag %s)\n",
+			who, action, status, http.StatusText(status), etag)
+	}
+
+	fmt.Fprintln(out, "\nBoth clients read the document, then edit it independently:")
+	alice.Get()
+	bob.Get()
+
+	ifMatch := FormatVector(alice.nextVector())
+	status, err := alice.Put("alice's edit")
+	step("Alice", "PUT If-Match "+ifMatch, status, err)
+
+	ifMatch = FormatVector(bob.nextVector())
+	status, err = bob.Put("bob's edit")
+	step("Bob", "PUT If-Match "+ifMatch, status, err)
+
+	fmt.Fprintln(out, "\nBob re-reads (merging the server clock) and retries:")
+	value, _ := bob.Get()
+	fmt.Fprintf(out, "  Bob   GET → %q, clock now %s\n", value, FormatVector(bob.vector))
+	ifMatch = FormatVector(bob.nextVector())
+	status, err = bob.Put("bob's edit on top of alice's")
+	step("Bob", "PUT If-Match "+ifMatch, status, err)
+
+	fmt.Fprintln(out, "\nA replayed old request from Alice:")
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("replay"))
+	req.Header.Set("If-Match", vectorETag([]int{1, 0}))
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		step("Alice", "PUT If-Match [1,0] (replay)", resp.StatusCode, nil)
+	}
+	PrintVerdicts(out, resource.Verdicts(history))
+	accepted := 0
+	for _, op := range history.Operations() {
+		if op.Kind == "write" && op.OK {
+			accepted++
+		}
+	}
+	PrintMessageStats(out, "\nAlice and Bob's HTTP traffic", counters.Snapshot(), accepted, "accepted write")
+
+	CompareConflictStrategies(out)
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• 409 Conflict: the supplied clock is concurrent with the server's and the strategy rejects it")
+	fmt.Fprintln(out, "• 412 Precondition Failed: the supplied clock happened before the server's (stale write)")
+	final, _ := resource.Current()
+	fmt.Fprintln(out, "• 200 OK: the client had seen every write the server has: "+strconv.Quote(final))
+	fmt.Fprintln(out, "• Every rejected PUT is a round trip that wrote nothing, so conflicts raise the cost per accepted write")
+	return history
+}
+
+// Kører den samme concurrent skrivning mod en ressource for hver indbygget strategi
+func CompareConflictStrategies(out io.Writer) {
+	fmt.Fprintln(out, "\nThe same concurrent edit under each conflict strategy:")
+	fmt.Fprintf(out, "  %-12s | %-8s | %-22s | %s\n", "Strategy", "Bob PUT", "Stored value", "ETag")
+	fmt.Fprintln(out, "  -------------|----------|------------------------|--------")
+
+	for _, name := range ConflictStrategies {
+		resolver, _ := ResolverByName(name)
+		resource := NewVersionedResource(2, "milk")
+		resource.Resolver = resolver
+		server, err := startLocalServer(resource)
+		if err != nil {
+			fmt.Fprintln(out, "Could not start the HTTP server:", err)
+			return
+		}
+
+		alice := &ETagClient{ID: 0, URL: server.URL, vector: make([]int, 2)}
+		bob := &ETagClient{ID: 1, URL: server.URL, vector: make([]int, 2)}
+		alice.Get()
+		bob.Get()
+		alice.Put("milk,eggs")
+		status, _ := bob.Put("milk,bread")
+		server.Close()
+
+		value, etag := resource.Current()
+		fmt.Fprintf(out, "  %-12s | %-8d | %-22s | %s\n", name, status, value, etag)
+	}
+}
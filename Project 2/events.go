@@ -0,0 +1,117 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Et event fra en proces' log sammen med dens clock værdi
+type LoggedEvent struct {
+	ProcessID int
+	Label     string        // Procesnavn eller P<id>
+	Index     int           // Position i processens EventLog
+	Type      string        // "local", "send" eller "receive"
+	Clock     ClockSnapshot // Clock værdien da eventet skete
+	Log       string        // Log linjen
+	MessageID string        // Besked ID for send og receive events
+	WallTime  time.Time     // Processens wall clock tid (nul hvis WallClock ikke var sat)
+}
+
+// Samler et event fra processens parallelle slices
+func (p *Process) loggedEvent(i int) LoggedEvent {
+	event := LoggedEvent{
+		ProcessID: p.ID,
+		Label:     p.Label(),
+		Index:     i,
+		Log:       p.EventLog[i],
+	}
+	if i < len(p.EventTypes) {
+		event.Type = p.EventTypes[i]
+	}
+	if i < len(p.EventMessageIDs) {
+		event.MessageID = p.EventMessageIDs[i]
+	}
+	if i < len(p.EventWallTimes) {
+		event.WallTime = p.EventWallTimes[i]
+	}
+	event.Clock = p.clockAt(i)
+	return event
+}
+
+// Clock værdien for event i i EventLog (tager højde for skift fra Lamport til vector)
+func (p *Process) clockAt(i int) ClockSnapshot {
+	var clock ClockSnapshot
+	if i >= p.vectorFrom && i-p.vectorFrom < len(p.EventVectors) {
+		clock = VectorSnapshot(p.EventVectors[i-p.vectorFrom])
+	} else {
+		clock = ClockSnapshot{time: p.EventTimestamps[i], hybrid: p.Clock.Now().IsHybrid()}
+	}
+	if i < len(p.EventStamps) {
+		clock.stamps = p.EventStamps[i]
+	}
+	return clock
+}
+
+// Retuner alle events fra alle processer i én liste der respekterer happens-before.
+// Lamport events sorteres efter (tid, proces ID); vector events efter summen af
+// entries, som altid er større for et event der kommer causalt efter.
+func ConsolidatedEvents(sim *Simulation) []LoggedEvent {
+	events := make([]LoggedEvent, 0)
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			events = append(events, p.loggedEvent(i))
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		ki, kj := clockSortKey(events[i].Clock), clockSortKey(events[j].Clock)
+		if ki != kj {
+			return ki < kj
+		}
+		return events[i].ProcessID < events[j].ProcessID
+	})
+	return events
+}
+
+// Sorteringsnøgle der respekterer happens-before: Lamport tiden, eller summen af
+// vectorens entries (a → b medfører sum(a) < sum(b))
+func clockSortKey(clock ClockSnapshot) int {
+	if !clock.IsVector() {
+		return clock.Time()
+	}
+	sum := 0
+	for i := 0; i < clock.Len(); i++ {
+		sum += clock.At(i)
+	}
+	return sum
+}
+
+// En besked som kant mellem send og receive eventet
+type MessageEdge struct {
+	ID      string
+	Send    LoggedEvent
+	Receive LoggedEvent
+}
+
+// Finder alle send→receive kanter ud fra besked ID'erne. Beskeder der ikke er
+// modtaget (endnu) er ikke med.
+func MessageEdges(sim *Simulation) []MessageEdge {
+	sends := make(map[string]LoggedEvent)
+	for _, p := range sim.Processes {
+		for i, id := range p.EventMessageIDs {
+			if id != "" && p.EventTypes[i] == "send" {
+				sends[id] = p.loggedEvent(i)
+			}
+		}
+	}
+
+	edges := make([]MessageEdge, 0)
+	for _, p := range sim.Processes {
+		for i, id := range p.EventMessageIDs {
+			if send, ok := sends[id]; ok && p.EventTypes[i] == "receive" {
+				edges = append(edges, MessageEdge{ID: id, Send: send, Receive: p.loggedEvent(i)})
+			}
+		}
+	}
+	return edges
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Et scenarie der kan gentages: kører én gang med seed og returnerer målingerne ved navn
+type ExperimentScenario func(seed int64) (map[string]float64, error)
+
+// Fordelingen af én måling over alle kørsler i et eksperiment
+type ExperimentMetric struct {
+	Name         string
+	Distribution ClockDistribution
+	Low, High    float64 // 95% konfidensinterval for middelværdien
+}
+
+// Resultatet af et eksperiment: én ExperimentMetric per måling, sorteret efter navn
+type ExperimentResult struct {
+	Runs    int
+	Seed    int64
+	Metrics []ExperimentMetric
+	Elapsed time.Duration
+}
+
+// Kritiske værdier for Students t fordeling (tosidet 95%) for 1-30 frihedsgrader;
+// derover bruges normalfordelingens 1.96
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// 95% konfidensinterval for middelværdien af d (t fordelingen, da kørslerne er få)
+func confidenceInterval95(d ClockDistribution) (float64, float64) {
+	n := len(d.Values)
+	if n < 2 {
+		return d.Mean, d.Mean
+	}
+	t := 1.96
+	if n-1 <= len(tCritical95) {
+		t = tCritical95[n-2]
+	}
+	margin := t * d.StdDev() / math.Sqrt(float64(n))
+	return d.Mean - margin, d.Mean + margin
+}
+
+// Kører scenario runs gange med seed, seed+1, ... fordelt på parallel goroutines, og
+// samler hver måling over kørslerne. Kørsel r får altid seed+r og gemmes på plads r, så
+// resultatet ikke afhænger af parallel (bortset fra tidsmålinger, der deler CPU'en).
+// Den første fejl returneres når alle kørsler er færdige.
+func RunExperiment(scenario ExperimentScenario, runs int, parallel int, seed int64) (*ExperimentResult, error) {
+	if runs < 1 {
+		return nil, fmt.Errorf("need at least one run, got %d", runs)
+	}
+	started := time.Now()
+	samples := make([]map[string]float64, runs)
+	errs := make([]error, runs)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < max(1, parallel); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range next {
+				samples[r], errs[r] = scenario(seed + int64(r))
+			}
+		}()
+	}
+	for r := 0; r < runs; r++ {
+		next <- r
+	}
+	close(next)
+	wg.Wait()
+
+	for r, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("run %d (seed %d): %w", r, seed+int64(r), err)
+		}
+	}
+	names := make([]string, 0)
+	for name := range samples[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &ExperimentResult{Runs: runs, Seed: seed, Elapsed: time.Since(started)}
+	for _, name := range names {
+		values := make([]float64, runs)
+		for r, sample := range samples {
+			value, ok := sample[name]
+			if !ok {
+				return nil, fmt.Errorf("run %d (seed %d) did not measure %q", r, seed+int64(r), name)
+			}
+			values[r] = value
+		}
+		metric := ExperimentMetric{Name: name, Distribution: NewClockDistribution(values)}
+		metric.Low, metric.High = confidenceInterval95(metric.Distribution)
+		result.Metrics = append(result.Metrics, metric)
+	}
+	return result, nil
+}
+
+// Retuner målingen name, eller false hvis eksperimentet ikke målte den
+func (r *ExperimentResult) Metric(name string) (ExperimentMetric, bool) {
+	for _, m := range r.Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return ExperimentMetric{}, false
+}
+
+// Print funktion
+func (r *ExperimentResult) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d runs (seeds %d-%d) in %v\n\n", r.Runs, r.Seed, r.Seed+int64(r.Runs)-1, r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "%-26s | %-10s | %-23s | %-10s | %-10s | %s\n", "Metric", "Mean", "95% CI", "Std dev", "Min", "Max")
+	fmt.Fprintln(w, "---------------------------|------------|-------------------------|------------|------------|-----------")
+	for _, m := range r.Metrics {
+		d := m.Distribution
+		fmt.Fprintf(w, "%-26s | %-10.3f | %-23s | %-10.3f | %-10.3f | %.3f\n", m.Name, d.Mean,
+			fmt.Sprintf("[%.3f, %.3f]", m.Low, m.High), d.StdDev(), d.Min, d.Max)
+	}
+}
+
+// Scenariet experiment kommandoen kører: en tilfældig workload med seed, kørt med
+// Lamport og vector clocks. Måler hvor mange event par Lamport kan ordne, hvor mange
+// der faktisk er concurrent, header bytes per besked og tiden per kørsel.
+func workloadExperiment(processes int, events int, sendRatio float64) ExperimentScenario {
+	return func(seed int64) (map[string]float64, error) {
+		w := GenerateWorkload(processes, events, sendRatio, seed)
+		started := time.Now()
+		lamportSim, err := w.run("Lamport", nil)
+		if err != nil {
+			return nil, err
+		}
+		lamportTime := time.Since(started)
+		started = time.Now()
+		vectorSim, err := w.run("Vector", nil)
+		if err != nil {
+			return nil, err
+		}
+		vectorTime := time.Since(started)
+
+		logged := 0
+		for _, p := range vectorSim.Processes {
+			logged += len(p.EventVectors)
+		}
+		pairs := logged * (logged - 1) / 2
+		return map[string]float64{
+			"Lamport orderable pairs %": calculateOrderingCorrectness(lamportSim),
+			"Concurrent pairs %":        100 * float64(CountConcurrentPairs(vectorSim)) / float64(max(1, pairs)),
+			"Causal depth":              float64(CausalDepth(vectorSim)),
+			"Lamport header B/msg":      lamportSim.MessageStats().HeaderBytesPerMessage(),
+			"Vector header B/msg":       vectorSim.MessageStats().HeaderBytesPerMessage(),
+			"Lamport run µs":            float64(lamportTime.Microseconds()),
+			"Vector run µs":             float64(vectorTime.Microseconds()),
+		}, nil
+	}
+}
+
+// Et eksperiment over en scenarie fil: kører filen med seed i stedet for filens seed og
+// måler events, beskeder, header bytes per besked, causal depth og (med vector clocks)
+// andelen af concurrent event par
+func scenarioExperiment(f *ScenarioFile) ExperimentScenario {
+	return func(seed int64) (map[string]float64, error) {
+		run := *f
+		run.Seed = seed
+		sim, err := run.Run("", io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		events := len(ConsolidatedEvents(sim))
+		metrics := map[string]float64{
+			"Events":       float64(events),
+			"Messages":     float64(sim.MessageStats().Sent),
+			"Header B/msg": sim.MessageStats().HeaderBytesPerMessage(),
+			"Causal depth": float64(CausalDepth(sim)),
+		}
+		if sim.UsesVectorClock() {
+			pairs := events * (events - 1) / 2
+			metrics["Concurrent pairs %"] = 100 * float64(CountConcurrentPairs(sim)) / float64(max(1, pairs))
+		}
+		return metrics, nil
+	}
+}
+
+// "experiment [-runs n] [-parallel n] [-seed s] [-processes n] [-events n] [-send-ratio r]"
+// eller "experiment -scenario file [-set name=value ...]": gentager workload scenariet (eller
+// scenarie filen) med forskellige seeds og printer fordelingen af målingerne
+func experimentCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("experiment", flag.ContinueOnError)
+	flags.SetOutput(out)
+	runs := flags.Int("runs", 100, "number of runs, each with its own seed")
+	parallel := flags.Int("parallel", runtime.NumCPU(), "runs executed at the same time")
+	seed := flags.Int64("seed", 1, "seed of the first run")
+	processes := flags.Int("processes", 4, "processes in each run")
+	events := flags.Int("events", 200, "events in each run")
+	sendRatio := flags.Float64("send-ratio", 0.5, "fraction of events that are sends")
+	scenarioPath := flags.String("scenario", "", "run this scenario file instead of the random workload")
+	params := paramFlag{}
+	flags.Var(params, "set", "set a parameter of the scenario file, as name=value (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *scenarioPath != "" {
+		data, err := os.ReadFile(*scenarioPath)
+		if err != nil {
+			return err
+		}
+		f, err := ParseScenarioTemplate(data, params)
+		if err != nil {
+			return err
+		}
+		result, err := RunExperiment(scenarioExperiment(f), *runs, *parallel, *seed)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Scenario %s (%d processes, %d steps", *scenarioPath, f.Processes, len(f.Steps))
+		if len(params) > 0 {
+			fmt.Fprintf(out, ", %s", params)
+		}
+		fmt.Fprintf(out, "), %d in parallel\n", *parallel)
+		result.Print(out)
+		return nil
+	}
+	if len(params) > 0 {
+		return fmt.Errorf("-set needs -scenario")
+	}
+	if *processes < 2 || *events < 1 || *sendRatio < 0 || *sendRatio > 1 {
+		return fmt.Errorf("need at least 2 processes, 1 event and a send ratio between 0 and 1")
+	}
+
+	result, err := RunExperiment(workloadExperiment(*processes, *events, *sendRatio), *runs, *parallel, *seed)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d processes, %d events, send ratio %.2f, %d in parallel\n", *processes, *events, *sendRatio, *parallel)
+	result.Print(out)
+	return nil
+}
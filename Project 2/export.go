@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Syslog facility local0 og severity informational: PRI = 16*8 + 6
+const syslogPriority = 134
+
+// Skriver de samlede logs som RFC 5424 syslog linjer med clock felter som structured data
+func ExportSyslog(w io.Writer, sim *Simulation) error {
+	return writeSyslog(w, sim, newClockEncoder(false))
+}
+
+func writeSyslog(w io.Writer, sim *Simulation, clocks *clockEncoder) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range ConsolidatedEvents(sim) {
+		// Simulationen har ingen fysiske tider, så TIMESTAMP er NILVALUE (-)
+		name, value := clocks.field(e)
+		messageID := ""
+		if e.MessageID != "" {
+			messageID = fmt.Sprintf(" msg_id=\"%s\"", syslogParam(e.MessageID))
+		}
+		fmt.Fprintf(bw, "<%d>1 - %s dissy - %s [dissy@32473 process=\"%s\" event=\"%d\" %s=\"%s\"%s] %s\n",
+			syslogPriority, syslogHost(e.Label), e.Type,
+			syslogParam(e.Label), e.Index, name, syslogParam(value), messageID, e.Log)
+	}
+	return bw.Flush()
+}
+
+// Skriver de samlede logs i ArcSight Common Event Format med clock felter som custom strings
+func ExportCEF(w io.Writer, sim *Simulation) error {
+	return writeCEF(w, sim, newClockEncoder(false))
+}
+
+func writeCEF(w io.Writer, sim *Simulation, clocks *clockEncoder) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range ConsolidatedEvents(sim) {
+		name, value := clocks.field(e)
+		messageID := ""
+		if e.MessageID != "" {
+			messageID = " cs2Label=message_id cs2=" + cefValue(e.MessageID)
+		}
+		fmt.Fprintf(bw, "CEF:0|ZoneKnud|dissy|1.0|%s|%s event|1|dvchost=%s cnt=%d cs1Label=%s cs1=%s%s msg=%s\n",
+			cefHeader(e.Type), cefHeader(e.Type), cefValue(e.Label), e.Index,
+			name, cefValue(value), messageID, cefValue(e.Log))
+	}
+	return bw.Flush()
+}
+
+// Eksporterer logs fra simulationerne til en fil i det valgte format ("syslog" eller "cef").
+// Med compress gemmes vector clocks som ændringer i forhold til processens forrige vector.
+func ExportLogsToFile(path string, format string, compress bool, sims ...*Simulation) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := exportLogs(file, format, compress, sims...); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Skriver logs fra simulationerne til w. Encoderen deles af alle simulationerne, så
+// deltaer i filen altid er i forhold til den forrige linje for samme proces.
+func exportLogs(w io.Writer, format string, compress bool, sims ...*Simulation) error {
+	var export func(io.Writer, *Simulation, *clockEncoder) error
+	switch format {
+	case "syslog":
+		export = writeSyslog
+	case "cef":
+		export = writeCEF
+	default:
+		return fmt.Errorf("unknown export format %q (use syslog or cef)", format)
+	}
+
+	clocks := newClockEncoder(compress)
+	for _, sim := range sims {
+		if err := export(w, sim, clocks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HOSTNAME må ikke indeholde mellemrum
+func syslogHost(label string) string {
+	return strings.ReplaceAll(label, " ", "_")
+}
+
+// Escaper en PARAM-VALUE i syslog structured data
+func syslogParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// Escaper et felt i CEF headeren
+func cefHeader(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`).Replace(value)
+}
+
+// Escaper en værdi i CEF extension
+func cefValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`).Replace(value)
+}
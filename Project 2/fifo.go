@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Slår FIFO levering til på alle links mellem processerne: hver besked får et
+// sekvensnummer per (afsender, modtager), og modtageren holder beskeder tilbage indtil
+// alle tidligere beskeder på samme link er leveret. Ligesom TCP på en forbindelse, men
+// uden retransmission: en tabt besked blokerer sit link.
+func (sim *Simulation) EnableFIFODelivery() {
+	for _, from := range sim.Processes {
+		for _, to := range sim.Processes {
+			if from != to {
+				sim.EnableFIFOLink(from, to)
+			}
+		}
+	}
+}
+
+// Slår FIFO levering til på linket fra from til to (beskeder i den anden retning og på
+// andre links leveres stadig ved ankomst). Beskeder der allerede er sendt er ikke med.
+func (sim *Simulation) EnableFIFOLink(from *Process, to *Process) {
+	if from.fifoSent == nil {
+		from.fifoSent = make(map[int]int)
+	}
+	if to.fifoDelivered == nil {
+		to.fifoDelivered = make(map[int]int)
+		to.fifoHeld = make(map[[2]int]Event)
+	}
+	if _, ok := from.fifoSent[to.ID]; !ok {
+		from.fifoSent[to.ID] = to.fifoDelivered[from.ID]
+	}
+}
+
+// Lægger en ankommet besked fra et FIFO link i bufferen og leverer beskederne fra linket
+// der nu er i orden
+func (p *Process) deliverFIFO(event Event) {
+	sender := event.ProcessID
+	key := [2]int{sender, event.linkSeq}
+	if _, held := p.fifoHeld[key]; held || event.linkSeq <= p.fifoDelivered[sender] {
+		event.acknowledge(DeliveryReceipt{}, ErrMessageDropped) // Dublet
+		return
+	}
+	p.fifoHeld[key] = event
+	for {
+		next := [2]int{sender, p.fifoDelivered[sender] + 1}
+		ready, ok := p.fifoHeld[next]
+		if !ok {
+			return
+		}
+		delete(p.fifoHeld, next)
+		p.fifoDelivered[sender]++
+		p.release(ready)
+	}
+}
+
+// Tæller leveringer der bryder FIFO (to beskeder fra samme afsender modtaget i omvendt
+// rækkefølge) og causal orden (en besked modtaget før en besked hvis send happened before
+// dens send). Kræver vector clocks.
+func DeliveryOrderViolations(sim *Simulation) (fifo int, causal int) {
+	received := make(map[int][]MessageEdge)
+	for _, edge := range MessageEdges(sim) {
+		received[edge.Receive.ProcessID] = append(received[edge.Receive.ProcessID], edge)
+	}
+	for _, edges := range received {
+		for _, a := range edges {
+			for _, b := range edges {
+				if a.Receive.Index >= b.Receive.Index {
+					continue
+				}
+				// a er modtaget før b: en fejl hvis b blev sendt før a
+				if b.Send.ProcessID == a.Send.ProcessID && b.Send.Index < a.Send.Index {
+					fifo++
+				}
+				if compareJoined(b.Send.Clock.vector, a.Send.Clock.vector) == Before {
+					causal++
+				}
+			}
+		}
+	}
+	return fifo, causal
+}
+
+// DemonstrateDeliveryOrder kører den samme workload på et netværk der omrokerer beskeder,
+// med leveringsgarantierne slået til hver for sig og sammen
+func DemonstrateDeliveryOrder(out io.Writer) {
+	fmt.Fprintln(out, "\n=== FIFO, CAUSAL AND UNORDERED DELIVERY ===")
+	fmt.Fprintln(out, "3 processes, 60 updates every 2ms (every 4th a broadcast); 30% of messages are delayed 20ms extra")
+	fmt.Fprintf(out, "\n%-14s | %-9s | %-15s | %-17s | %s\n", "Delivery", "Delivered", "FIFO violations", "Causal violations", "Held back")
+	fmt.Fprintln(out, "---------------|-----------|-----------------|-------------------|-----------")
+	for _, mode := range []string{"unordered", "FIFO", "causal", "FIFO + causal"} {
+		sim := NewSimulation(3, true)
+		sim.Out = io.Discard
+		if mode == "FIFO" || mode == "FIFO + causal" {
+			sim.EnableFIFODelivery()
+		}
+		if mode == "causal" || mode == "FIFO + causal" {
+			sim.EnableCausalDelivery()
+		}
+
+		// En besked er holdt tilbage hvis en senere ankomst til modtageren blev leveret først
+		heldBack, lastArrival := 0, make(map[int]string)
+		for _, p := range sim.Processes {
+			p.OnDeliver = func(p *Process, event Event) bool {
+				lastArrival[p.ID] = event.MessageID
+				return true
+			}
+			p.OnEvent = func(p *Process, event LoggedEvent) {
+				if event.Type == "receive" && event.MessageID != lastArrival[p.ID] {
+					heldBack++
+				}
+			}
+		}
+
+		s := NewScheduler(sim, 7)
+		s.Faults = TransportFaults{Reorder: 0.3, ReorderDelay: 20 * time.Millisecond}
+		for i := 0; i < 60; i++ {
+			p, broadcast := sim.Processes[i%3], i%4 == 3
+			message := fmt.Sprintf("update %d", i)
+			s.At(time.Duration(i)*2*time.Millisecond, func() {
+				if broadcast {
+					p.Broadcast(message)
+				} else {
+					p.SendMessage(sim.Processes[(p.ID+1)%3], message)
+				}
+				s.forwardQueued()
+			})
+		}
+		s.Run()
+
+		fifo, causal := DeliveryOrderViolations(sim)
+		fmt.Fprintf(out, "%-14s | %-9d | %-15d | %-17d | %d\n", mode, len(MessageEdges(sim)), fifo, causal, heldBack)
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Without a guarantee the network's reordering shows up directly as out-of-order receives")
+	fmt.Fprintln(out, "• FIFO only orders each link: a message can still overtake one it depends on from another sender")
+	fmt.Fprintln(out, "• Causal delivery here only covers broadcasts, so unicasts can still break both orders")
+	fmt.Fprintln(out, "• Each guarantee is paid for by holding early messages back until the gap is filled")
+}
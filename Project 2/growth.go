@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Advarsel om at en proces' clock tæller nærmer sig overflow
+type GrowthWarning struct {
+	Process   string
+	ProcessID int
+	Counter   int           // Den største tæller i uret (Lamport tid, største vector entry eller HLC logisk del)
+	Limit     int           // Største værdi tælleren kan have med den konfigurerede bredde
+	Rate      float64       // Vækst per sekund siden sidste observation
+	Remaining time.Duration // Estimeret tid til overflow med den nuværende rate (0 hvis ukendt)
+}
+
+// Hvor stor en del af grænsen tælleren har brugt
+func (w GrowthWarning) Used() float64 {
+	return float64(w.Counter) / float64(w.Limit)
+}
+
+// Print funktion
+func (w GrowthWarning) String() string {
+	eta := "unknown"
+	if w.Remaining > 0 {
+		eta = w.Remaining.Round(time.Second).String()
+	}
+	return fmt.Sprintf("%s: counter %d is %.0f%% of the %d limit (%.0f/s, overflow in %s)",
+		w.Process, w.Counter, w.Used()*100, w.Limit, w.Rate, eta)
+}
+
+// Seneste observation af en proces
+type growthSample struct {
+	counter int
+	at      time.Time
+	rate    float64
+}
+
+// GrowthMonitor følger hvor hurtigt processernes clock tællere vokser og advarer når en
+// tæller nærmer sig den største værdi den kan have i Bits bits (fx i et fast header
+// format eller en database kolonne). HLC's logiske del har altid hlcLogicalBits bits.
+// OnWarning er stedet at starte en epoch rollover: en barrier som MigrateToVectorClocks
+// hvor alle beskeder leveres før urene nulstilles, så den nye epoch ordnes efter den gamle.
+type GrowthMonitor struct {
+	Bits      int                 // Bredden tællerne gemmes med (højst 63)
+	WarnAt    float64             // Andel af grænsen hvor der advares, fx 0.8
+	OnWarning func(GrowthWarning) // Kaldes første gang en proces passerer WarnAt (nil = kun rapporten)
+	samples   map[int]growthSample
+	warned    map[int]bool
+}
+
+// Opretter en monitor for tællere med bits bits der advarer ved andelen warnAt af grænsen
+func NewGrowthMonitor(bits int, warnAt float64) *GrowthMonitor {
+	return &GrowthMonitor{
+		Bits:    bits,
+		WarnAt:  warnAt,
+		samples: make(map[int]growthSample),
+		warned:  make(map[int]bool),
+	}
+}
+
+// Den største tæller i et clock snapshot og dens grænse
+func (m *GrowthMonitor) counter(clock ClockSnapshot) (int, int) {
+	if clock.IsHybrid() {
+		return clock.Logical(), 1<<hlcLogicalBits - 1
+	}
+	limit := math.MaxInt
+	if m.Bits < 63 {
+		limit = 1<<m.Bits - 1
+	}
+	if !clock.IsVector() {
+		return clock.Time(), limit
+	}
+	highest := 0
+	for i := 0; i < clock.Len(); i++ {
+		highest = max(highest, clock.At(i))
+	}
+	return highest, limit
+}
+
+// Aflæser alle processers ure på tidspunktet at og returnerer en advarsel for hver proces
+// der er over WarnAt. OnWarning kaldes kun første gang en proces passerer grænsen.
+func (m *GrowthMonitor) Observe(sim *Simulation, at time.Time) []GrowthWarning {
+	warnings := make([]GrowthWarning, 0)
+	for _, p := range sim.Processes {
+		counter, limit := m.counter(p.Clock.Now())
+		sample := growthSample{counter: counter, at: at}
+		if previous, ok := m.samples[p.ID]; ok {
+			sample.rate = previous.rate
+			if elapsed := at.Sub(previous.at).Seconds(); elapsed > 0 {
+				sample.rate = float64(counter-previous.counter) / elapsed
+			}
+		}
+		m.samples[p.ID] = sample
+
+		if float64(counter) < m.WarnAt*float64(limit) {
+			continue
+		}
+		warning := GrowthWarning{Process: p.Label(), ProcessID: p.ID, Counter: counter, Limit: limit, Rate: sample.rate}
+		if sample.rate > 0 {
+			warning.Remaining = time.Duration(float64(limit-counter) / sample.rate * float64(time.Second))
+		}
+		warnings = append(warnings, warning)
+		if !m.warned[p.ID] && m.OnWarning != nil {
+			m.OnWarning(warning)
+		}
+		m.warned[p.ID] = true
+	}
+	return warnings
+}
+
+// Skriver seneste observation for hver proces
+func (m *GrowthMonitor) Fprint(w io.Writer, sim *Simulation) {
+	fmt.Fprintf(w, "%-8s | %-10s | %-12s | %-10s | %-18s\n", "Process", "Counter", "Growth (/s)", "Of limit", "Overflow in")
+	fmt.Fprintln(w, "---------|------------|--------------|------------|-------------------")
+	for _, p := range sim.Processes {
+		sample, ok := m.samples[p.ID]
+		if !ok {
+			continue
+		}
+		_, limit := m.counter(p.Clock.Now())
+		eta := "never"
+		if sample.rate > 0 {
+			eta = time.Duration(float64(limit-sample.counter) / sample.rate * float64(time.Second)).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%-8s | %-10d | %-12.0f | %9.1f%% | %-18s\n",
+			p.Label(), sample.counter, sample.rate, float64(sample.counter)/float64(limit)*100, eta)
+	}
+}
+
+// DemonstrateClockGrowth viser overflow overvågningen med 12-bit tællere, så grænsen nås
+// på få sekunders simuleret tid
+func DemonstrateClockGrowth(out io.Writer) {
+	fmt.Fprintln(out, "\n=== CLOCK GROWTH MONITORING ===")
+	const bits, rounds = 12, 10
+	fmt.Fprintf(out, "Counters stored in %d bits (limit %d), warning at 80%%, one observation per simulated second\n\n", bits, 1<<bits-1)
+
+	sim := NewSimulation(4, false)
+	sim.Seed(1)
+	monitor := NewGrowthMonitor(bits, 0.8)
+	warned := 0
+	monitor.OnWarning = func(w GrowthWarning) {
+		warned++
+		fmt.Fprintf(out, "  ⚠ %s\n", w)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for second := 0; second <= rounds; second++ {
+		if second > 0 {
+			// Én proces er langt mere aktiv end resten og trækker de andre med via beskeder
+			for i := 0; i < 350; i++ {
+				sim.Processes[0].HandleLocalEvent("hot path")
+			}
+			runConcurrencyWorkload(sim, 10, 0.5)
+		}
+		monitor.Observe(sim, start.Add(time.Duration(second)*time.Second))
+	}
+
+	if warned > 0 {
+		fmt.Fprintln(out, "  → schedule an epoch rollover: drain all messages at a barrier, then reset the clocks")
+	}
+
+	fmt.Fprintln(out)
+	monitor.Fprint(out, sim)
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• A Lamport counter grows with the busiest process, and messages pull every other process along")
+	fmt.Fprintln(out, "• Growth rate, not the current value, tells how long is left before the counter wraps")
+	fmt.Fprintln(out, "• A wrapped counter silently breaks ordering, so the rollover has to happen before it, at a barrier")
+}
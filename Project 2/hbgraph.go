@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// En kant i happens-before grafen: enten to events efter hinanden i samme proces,
+// eller et send event og det tilhørende receive event
+type HappensBeforeEdge struct {
+	From      LoggedEvent
+	To        LoggedEvent
+	Kind      string // "program" eller "message"
+	MessageID string // Kun for "message" kanter
+}
+
+// Den causale fortid for hvert event, fundet ud fra loggenes rækkefølge og besked
+// ID'erne, så den virker for alle slags ure
+type causalPast struct {
+	position map[int]int            // Process ID → plads i sim.Processes
+	sendOf   map[[2]int]MessageEdge // Receive eventet → beskeden
+	known    map[[2]int][]int       // Event → seneste event i hver proces der happened-before det (-1 = intet)
+}
+
+// Opretter en tom causal fortid for sim; eventernes fortid udregnes når den bruges
+func newCausalPast(sim *Simulation) *causalPast {
+	c := &causalPast{
+		position: make(map[int]int),
+		sendOf:   make(map[[2]int]MessageEdge),
+		known:    make(map[[2]int][]int),
+	}
+	for i, p := range sim.Processes {
+		c.position[p.ID] = i
+	}
+	for _, edge := range MessageEdges(sim) {
+		c.sendOf[[2]int{edge.Receive.ProcessID, edge.Receive.Index}] = edge
+	}
+	return c
+}
+
+// Det seneste event i hver proces (efter plads i sim.Processes) der happened-before
+// eller er event
+func (c *causalPast) at(event [2]int) []int {
+	if k, ok := c.known[event]; ok {
+		return k
+	}
+	k := make([]int, len(c.position))
+	for i := range k {
+		k[i] = -1
+	}
+	if event[1] > 0 {
+		copy(k, c.at([2]int{event[0], event[1] - 1}))
+	}
+	if edge, ok := c.sendOf[event]; ok {
+		for i, index := range c.at([2]int{edge.Send.ProcessID, edge.Send.Index}) {
+			k[i] = max(k[i], index)
+		}
+	}
+	k[c.position[event[0]]] = event[1]
+	c.known[event] = k
+	return k
+}
+
+// Gælder a → b?
+func (c *causalPast) happenedBefore(a LoggedEvent, b LoggedEvent) bool {
+	if a.ProcessID == b.ProcessID && a.Index == b.Index {
+		return false
+	}
+	return c.at([2]int{b.ProcessID, b.Index})[c.position[a.ProcessID]] >= a.Index
+}
+
+// Finder den transitive reduktion af happens-before: de kanter der ikke følger af
+// andre kanter. Rækkefølgen i en proces er altid med; en besked kan kun udelades hvis
+// modtageren allerede kendte send eventet via en anden vej (fx en relay der kom først).
+func HappensBeforeReduction(sim *Simulation) []HappensBeforeEdge {
+	past := newCausalPast(sim)
+	edges := make([]HappensBeforeEdge, 0)
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			event := p.loggedEvent(i)
+			if i > 0 {
+				edges = append(edges, HappensBeforeEdge{From: p.loggedEvent(i - 1), To: event, Kind: "program"})
+			}
+			edge, ok := past.sendOf[[2]int{p.ID, i}]
+			if !ok {
+				continue
+			}
+			if i > 0 && past.happenedBefore(edge.Send, p.loggedEvent(i-1)) {
+				continue
+			}
+			edges = append(edges, HappensBeforeEdge{From: edge.Send, To: event, Kind: "message", MessageID: edge.ID})
+		}
+	}
+	return edges
+}
+
+// Node ID for et event i graf filerne, fx "P0:3"
+func graphNodeID(e LoggedEvent) string {
+	return fmt.Sprintf("%s:%d", strings.ReplaceAll(e.Label, " ", "_"), e.Index)
+}
+
+// Skriver den reducerede happens-before graf som en edge list: én "fra til kind" linje
+// per kant, som networkx.read_edgelist kan læse med data=[("kind", str)]
+func WriteHappensBeforeEdgeList(w io.Writer, sim *Simulation) error {
+	bw := bufio.NewWriter(w)
+	for _, edge := range HappensBeforeReduction(sim) {
+		fmt.Fprintf(bw, "%s %s %s\n", graphNodeID(edge.From), graphNodeID(edge.To), edge.Kind)
+	}
+	return bw.Flush()
+}
+
+// Skriver den reducerede happens-before graf som GraphML med alle events som noder,
+// så events uden kanter også kommer med. Noderne har proces, type, clock og log linje.
+func WriteHappensBeforeGraphML(w io.Writer, sim *Simulation) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, xml.Header+`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, key := range [][3]string{
+		{"process", "node", "string"}, {"index", "node", "int"}, {"type", "node", "string"},
+		{"clock", "node", "string"}, {"log", "node", "string"},
+		{"kind", "edge", "string"}, {"message", "edge", "string"},
+	} {
+		fmt.Fprintf(bw, `  <key id="%s" for="%s" attr.name="%s" attr.type="%s"/>`+"\n", key[0], key[1], key[0], key[2])
+	}
+	fmt.Fprintln(bw, `  <graph id="happens-before" edgedefault="directed">`)
+
+	for _, e := range ConsolidatedEvents(sim) {
+		fmt.Fprintf(bw, `    <node id="%s">`, graphMLText(graphNodeID(e)))
+		fmt.Fprintf(bw, `<data key="process">%s</data><data key="index">%d</data>`, graphMLText(e.Label), e.Index)
+		fmt.Fprintf(bw, `<data key="type">%s</data><data key="clock">%s</data>`, e.Type, graphMLText(e.Clock.String()))
+		fmt.Fprintf(bw, `<data key="log">%s</data></node>`+"\n", graphMLText(e.Log))
+	}
+	for _, edge := range HappensBeforeReduction(sim) {
+		fmt.Fprintf(bw, `    <edge source="%s" target="%s"><data key="kind">%s</data>`,
+			graphMLText(graphNodeID(edge.From)), graphMLText(graphNodeID(edge.To)), edge.Kind)
+		if edge.MessageID != "" {
+			fmt.Fprintf(bw, `<data key="message">%s</data>`, graphMLText(edge.MessageID))
+		}
+		fmt.Fprintln(bw, "</edge>")
+	}
+
+	fmt.Fprintln(bw, "  </graph>\n</graphml>")
+	return bw.Flush()
+}
+
+// Escaper tekst til XML indhold og attributter
+func graphMLText(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// Skriver happens-before grafen til en fil i formatet "edgelist" eller "graphml"
+func ExportHappensBeforeToFile(path string, format string, sim *Simulation) error {
+	var export func(io.Writer, *Simulation) error
+	switch format {
+	case "edgelist":
+		export = WriteHappensBeforeEdgeList
+	case "graphml":
+		export = WriteHappensBeforeGraphML
+	default:
+		return fmt.Errorf("unknown graph format %q (use edgelist or graphml)", format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := export(file, sim); err != nil {
+		return err
+	}
+	return file.Close()
+}
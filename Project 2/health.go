@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Aktuelle clock værdier fra alle processer plus skew/divergens imellem dem
+type ClusterTimeReport struct {
+	ClockType   string
+	Labels      []string        // Procesnavne, indekseret efter proces ID
+	Snapshots   []ClockSnapshot // Én per proces, indekseret efter proces ID
+	MaxSkew     int             // Lamport: største forskel mellem to processers tid (ms for HLC)
+	Divergence  [][]int         // Vector: L1 afstand mellem hvert par af vectors
+	MaxDiverged [2]int          // Vector: det par af processer der divergerer mest
+}
+
+// Samler clock værdier fra alle processer i simulationen
+func (sim *Simulation) QueryClocks() ClusterTimeReport {
+	report := ClusterTimeReport{
+		ClockType: sim.GetClockType(),
+		Labels:    make([]string, len(sim.Processes)),
+		Snapshots: make([]ClockSnapshot, len(sim.Processes)),
+	}
+
+	for i, p := range sim.Processes {
+		report.Labels[i] = p.Label()
+		report.Snapshots[i] = p.Clock.Now()
+	}
+
+	if len(report.Snapshots) > 0 && report.Snapshots[0].IsVector() {
+		report.Divergence, report.MaxDiverged = vectorDivergence(report.Snapshots)
+	} else {
+		report.MaxSkew = lamportSkew(report.Snapshots)
+	}
+
+	return report
+}
+
+// Største forskel mellem mindste og største Lamport tid (for HLC den fysiske del i ms)
+func lamportSkew(snapshots []ClockSnapshot) int {
+	if len(snapshots) == 0 {
+		return 0
+	}
+	value := func(s ClockSnapshot) int {
+		if s.IsHybrid() {
+			return int(s.Physical())
+		}
+		return s.Time()
+	}
+	lowest, highest := value(snapshots[0]), value(snapshots[0])
+	for _, s := range snapshots[1:] {
+		if value(s) < lowest {
+			lowest = value(s)
+		}
+		if value(s) > highest {
+			highest = value(s)
+		}
+	}
+	return highest - lowest
+}
+
+// Parvis L1 afstand mellem vectors: hvor mange events den ene kender som den anden ikke gør
+func vectorDivergence(snapshots []ClockSnapshot) ([][]int, [2]int) {
+	n := len(snapshots)
+	divergence := make([][]int, n)
+	maxPair := [2]int{0, 0}
+	maxValue := -1
+
+	for i := 0; i < n; i++ {
+		divergence[i] = make([]int, n)
+		for j := 0; j < n; j++ {
+			distance := 0
+			for k := 0; k < snapshots[i].Len(); k++ {
+				diff := snapshots[i].At(k) - snapshots[j].At(k)
+				if diff < 0 {
+					diff = -diff
+				}
+				distance += diff
+			}
+			divergence[i][j] = distance
+			if i < j && distance > maxValue {
+				maxValue = distance
+				maxPair = [2]int{i, j}
+			}
+		}
+	}
+
+	return divergence, maxPair
+}
+
+// Skriver rapporten til w
+func (r ClusterTimeReport) Fprint(w io.Writer) {
+	fmt.Fprintf(w, "\n=== Cluster Logical Time (%s) ===\n", r.ClockType)
+	for i, s := range r.Snapshots {
+		fmt.Fprintf(w, "  %s: %s\n", r.Labels[i], s)
+	}
+
+	if len(r.Snapshots) > 0 && r.Snapshots[0].IsHybrid() {
+		fmt.Fprintf(w, "Max HLC skew: %d ms\n", r.MaxSkew)
+		return
+	}
+	if len(r.Divergence) == 0 {
+		fmt.Fprintf(w, "Max Lamport skew: %d\n", r.MaxSkew)
+		return
+	}
+
+	fmt.Fprintln(w, "Pairwise vector divergence:")
+	for i, row := range r.Divergence {
+		fmt.Fprintf(w, "  %s: %v\n", r.Labels[i], row)
+	}
+	a, b := r.MaxDiverged[0], r.MaxDiverged[1]
+	fmt.Fprintf(w, "Most diverged: %s and %s (%d)\n", r.Labels[a], r.Labels[b], r.Divergence[a][b])
+}
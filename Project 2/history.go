@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// En operation i en klient-historik: kaldt ved Call, svar modtaget ved Return (ns)
+type Operation struct {
+	ClientID int
+	Kind     string // "read" eller "write"
+	Input    string
+	Output   string
+	OK       bool
+	Unknown  bool // Svaret gik tabt (fx en transportfejl): skrivningen kan være sket eller ej
+	Call     int64
+	Return   int64
+}
+
+// Opsamler operationer fra flere klienter (sikker ved concurrent brug)
+type History struct {
+	start      time.Time
+	operations []Operation
+	mutex      sync.Mutex
+}
+
+// Opretter en tom historik; tider måles relativt til nu
+func NewHistory() *History {
+	return &History{start: time.Now()}
+}
+
+// Tidspunkt i nanosekunder siden historikken startede
+func (h *History) now() int64 {
+	return time.Since(h.start).Nanoseconds()
+}
+
+// Tilføjer en afsluttet operation
+func (h *History) record(op Operation) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.operations = append(h.operations, op)
+}
+
+// Retuner en kopi af operationerne
+func (h *History) Operations() []Operation {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]Operation(nil), h.operations...)
+}
+
+// Skriver historikken som en JSON liste af operationer med samme felter som
+// porcupine.Operation (client_id, input, call, output, return). En operation med ukendt
+// udfald får return tiden uendelig (math.MaxInt64), som porcupine forventer.
+func WritePorcupineJSON(w io.Writer, ops []Operation) error {
+	type porcupineOp struct {
+		ClientID int               `json:"client_id"`
+		Input    map[string]string `json:"input"`
+		Call     int64             `json:"call"`
+		Output   map[string]any    `json:"output"`
+		Return   int64             `json:"return"`
+	}
+
+	out := make([]porcupineOp, len(ops))
+	for i, op := range ops {
+		out[i] = porcupineOp{
+			ClientID: op.ClientID,
+			Input:    map[string]string{"op": op.Kind, "value": op.Input},
+			Call:     op.Call,
+			Output:   map[string]any{"ok": op.OK, "value": op.Output},
+			Return:   op.Return,
+		}
+		if op.Unknown {
+			out[i].Return = math.MaxInt64
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// Skriver historikken som Jepsen/Knossos events: et "invoke" og et "ok"/"fail" per
+// operation ("info" hvis udfaldet er ukendt), sorteret efter tid
+func WriteKnossosJSON(w io.Writer, ops []Operation) error {
+	type knossosEvent struct {
+		Process int    `json:"process"`
+		Type    string `json:"type"`
+		F       string `json:"f"`
+		Value   any    `json:"value"`
+		Time    int64  `json:"time"`
+	}
+
+	events := make([]knossosEvent, 0, 2*len(ops))
+	for _, op := range ops {
+		var invoked any
+		if op.Kind == "write" {
+			invoked = op.Input
+		}
+		events = append(events, knossosEvent{op.ClientID, "invoke", op.Kind, invoked, op.Call})
+
+		completion := "ok"
+		if op.Unknown {
+			completion = "info"
+		} else if !op.OK {
+			completion = "fail"
+		}
+		value := invoked
+		if op.Kind == "read" {
+			value = op.Output
+		}
+		events = append(events, knossosEvent{op.ClientID, completion, op.Kind, value, op.Return})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time < events[j].Time
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(events)
+}
+
+// Skriver historikken til en fil i formatet "knossos" eller "porcupine"
+func ExportHistoryToFile(path string, format string, history *History) error {
+	var export func(io.Writer, []Operation) error
+	switch format {
+	case "knossos":
+		export = WriteKnossosJSON
+	case "porcupine":
+		export = WritePorcupineJSON
+	default:
+		return fmt.Errorf("unknown history format %q (use knossos or porcupine)", format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := export(file, history.Operations()); err != nil {
+		return err
+	}
+	return file.Close()
+}
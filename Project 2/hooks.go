@@ -0,0 +1,71 @@
+package main
+
+import "fmt"
+
+// Et lokalt, send eller receive event i det øjeblik det sker, med den clock værdi det
+// blev stemplet med
+type StampedEvent struct {
+	Process   *Process
+	Type      string        // "local", "send" eller "receive"
+	Clock     ClockSnapshot // Uret efter eventet (værdien eventet får i loggen)
+	MessageID string        // Besked ID for send og receive events
+	Peer      string        // Modtageren af et send, afsenderen af et receive
+	Payload   string        // Beskeden eller den lokale operation
+	Received  ClockSnapshot // Clock headeren i en modtaget besked
+	Before    ClockSnapshot // Uret før et receive
+}
+
+// Log linjen for eventet, som den står i EventLog
+func (e StampedEvent) Log() string {
+	switch e.Type {
+	case "send":
+		return fmt.Sprintf("%s: Send to %s at %s: %s", e.Process.Label(), e.Peer, e.Clock, e.Payload)
+	case "receive":
+		return fmt.Sprintf("%s: Receive from %s (received %s, was %s → synchronized to %s): %s",
+			e.Process.Label(), e.Peer, e.Received, e.Before, e.Clock, e.Payload)
+	}
+	return fmt.Sprintf("%s: Local event %s: %s", e.Process.Label(), e.Clock, e.Payload)
+}
+
+// Kaldes for hvert lokalt, send og receive event, fx til metrics, assertions eller tracing
+type EventHook func(event StampedEvent)
+
+// Registrerer hook på processen. Hooks kaldes i den rækkefølge de er registreret, på den
+// goroutine der laver eventet, og efter event loggen (selv den første hook) har gemt
+// eventet. De kaldes også når events ikke gemmes (DiscardEvents). Registrer dem før
+// simulationen kører.
+func (p *Process) AddEventHook(hook EventHook) {
+	p.eventHooks = append(p.eventHooks, hook)
+}
+
+// Registrerer hook på alle processer, også dem AddProcess tilføjer senere
+func (sim *Simulation) AddEventHook(hook EventHook) {
+	sim.eventHooks = append(sim.eventHooks, hook)
+	for _, p := range sim.Processes {
+		p.AddEventHook(hook)
+	}
+}
+
+// Bliver events gemt, eller har processen hooks ud over event loggen? Ellers kan et event
+// springe det over der kun skal bruges til at beskrive det (fx uret før et receive).
+func (p *Process) observesEvents() bool {
+	return !p.DiscardEvents || len(p.eventHooks) > 1
+}
+
+// Giver et event til processens hooks
+func (p *Process) stamp(event StampedEvent) {
+	event.Process = p
+	for _, hook := range p.eventHooks {
+		hook(event)
+	}
+}
+
+// Hooken der gemmer events i processens log (installeres af NewProcess)
+func logEvent(event StampedEvent) {
+	p := event.Process
+	if p.DiscardEvents {
+		return
+	}
+	p.recordClock(event.Clock)
+	p.appendLog(event.Type, event.MessageID, event.Log())
+}
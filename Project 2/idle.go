@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Hvor længe WaitUntilIdle venter uden at nogen besked bliver færdig før den giver op
+var idleStallTimeout = 2 * time.Second
+
+// Venter til simulationen er i ro: alle beskeder der er sendt er færdigbehandlet, så
+// køerne er tomme og ingen Run goroutine er midt i en levering (inklusive beskeder den
+// sender undervejs, fx fra en Behavior). Erstatter sleeps der "venter på levering".
+// Returnerer en fejl hvis intet bliver færdigt i idleStallTimeout, fx fordi en proces
+// med beskeder i køen ikke kører.
+func (sim *Simulation) WaitUntilIdle() error {
+	last, lastProgress := int64(-1), time.Now()
+	for {
+		inFlight := sim.inFlight()
+		if inFlight == 0 {
+			return nil
+		}
+		if inFlight != last {
+			last, lastProgress = inFlight, time.Now()
+		} else if time.Since(lastProgress) > idleStallTimeout {
+			stuck := make([]string, 0)
+			for _, p := range sim.Processes {
+				if n := p.inFlight.Load(); n > 0 {
+					stuck = append(stuck, fmt.Sprintf("%s: %d (queue %d, run loops %d)", p.Label(), n, len(p.MessageQueue), p.runLoops.Load()))
+				}
+			}
+			return fmt.Errorf("simulation not idle after %v without progress: %s", idleStallTimeout, strings.Join(stuck, ", "))
+		}
+		time.Sleep(50 * time.Microsecond)
+	}
+}
+
+// Antal beskeder i hele simulationen der ikke er færdigbehandlet. Beskeder en test eller
+// demo har taget direkte fra en kø og givet til ReceiveMessage tælles aldrig ned, og
+// beskeder leveret uden at være sendt tælles ned under 0, så hver proces tæller mindst 0.
+func (sim *Simulation) inFlight() int64 {
+	total := int64(0)
+	for _, p := range sim.Processes {
+		total += max(0, p.inFlight.Load())
+	}
+	return total
+}
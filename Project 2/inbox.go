@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Tager den næste besked fra p's inbox (hvis p ikke er i gang med en) og leverer den
+// efter ServiceTime
+func (s *Scheduler) serveNext(p *Process) {
+	inbox := s.inbox[p.ID]
+	if s.busy[p.ID] || len(inbox) == 0 {
+		return
+	}
+	next, best := 0, s.effectivePriority(p, 0)
+	for i := 1; i < len(inbox); i++ {
+		if priority := s.effectivePriority(p, i); priority > best {
+			next, best = i, priority
+		}
+	}
+	event := inbox[next]
+	s.inbox[p.ID] = append(inbox[:next], inbox[next+1:]...)
+	s.busy[p.ID] = true
+	s.At(s.Now+s.ServiceTime, func() {
+		s.record(RecordedEvent{Kind: "deliver", Process: p.ID, MessageID: event.MessageID})
+		p.deliver(event)
+		s.forwardQueued()
+		s.busy[p.ID] = false
+		s.serveNext(p)
+	})
+}
+
+// Prioriteten af den i'te besked i p's inbox. Med InheritPriority er det den højeste af
+// dens egen og prioriteten af beskeder (i inboxen eller causal bufferen) der afhænger af
+// den. Broadcast vectorer er transitive, så det dækker også indirekte afhængigheder.
+func (s *Scheduler) effectivePriority(p *Process, i int) int {
+	if s.Priority == nil {
+		return 0
+	}
+	inbox := s.inbox[p.ID]
+	priority := s.Priority(inbox[i])
+	if !s.InheritPriority || inbox[i].causal == nil {
+		return priority
+	}
+	for j, m := range inbox {
+		if j != i && causallyDependsOn(m, inbox[i]) {
+			priority = max(priority, s.Priority(m))
+		}
+	}
+	for _, m := range p.heldBack {
+		if causallyDependsOn(m, inbox[i]) {
+			priority = max(priority, s.Priority(m))
+		}
+	}
+	return priority
+}
+
+// Afhænger broadcasten m af broadcasten c (havde m's afsender leveret c da m blev sendt)?
+func causallyDependsOn(m Event, c Event) bool {
+	if m.causal == nil || c.causal == nil || c.ProcessID >= len(m.causal) {
+		return false
+	}
+	if m.ProcessID == c.ProcessID && m.causal[m.ProcessID] == c.causal[c.ProcessID] {
+		return false // Samme besked (fx en dublet)
+	}
+	return c.causal[c.ProcessID] <= m.causal[c.ProcessID]
+}
+
+// Svarer med en alert broadcast på hver every'te bulk besked fra Source
+type alertOnBulk struct {
+	Source int
+	Every  int
+	seen   int
+}
+
+func (a *alertOnBulk) OnTick(p *Process) {}
+
+func (a *alertOnBulk) OnReceive(p *Process, from int, payload string) {
+	if from == a.Source && strings.HasPrefix(payload, "bulk") {
+		if a.seen++; a.seen%a.Every == 0 {
+			p.Broadcast(fmt.Sprintf("alert after %s", payload))
+		}
+	}
+}
+
+// DemonstratePriorityInheritance måler hvor længe alerts venter når beskeder har
+// prioritet og causal delivery, med og uden priority inheritance
+func DemonstratePriorityInheritance(out io.Writer) {
+	fmt.Fprintln(out, "\n=== PRIORITY INHERITANCE IN CAUSAL DELIVERY ===")
+	fmt.Fprintln(out, "P0 broadcasts bulk every 4ms, P3 sends a burst of 34 bulk to P2 every 20ms, and P1 broadcasts a")
+	fmt.Fprintln(out, "high-priority alert after every 3rd bulk from P0. Each process handles one message per 0.5ms, which")
+	fmt.Fprintln(out, "keeps P2 close to saturated; latency is measured at P2 over 400ms.")
+	fmt.Fprintf(out, "\n%-22s | %-8s | %-14s | %-14s | %s\n", "Inbox order", "Alerts", "Alert mean", "Alert p99", "Bulk mean")
+	fmt.Fprintln(out, "-----------------------|----------|----------------|----------------|-----------")
+	for _, mode := range []string{"arrival", "priority", "priority + inheritance"} {
+		sim := NewSimulation(4, true)
+		sim.Out = io.Discard
+		sim.EnableCausalDelivery()
+		sim.Processes[1].Behavior = &alertOnBulk{Source: 0, Every: 3}
+
+		s := NewScheduler(sim, 3)
+		s.ServiceTime = 500 * time.Microsecond
+		if mode != "arrival" {
+			s.Priority = func(event Event) int {
+				if strings.Contains(event.Message, "|alert") {
+					return 1
+				}
+				return 0
+			}
+		}
+		s.InheritPriority = mode == "priority + inheritance"
+
+		// Latency fra send til levering hos P2
+		sentAt := make(map[string]time.Duration)
+		alerts, bulk := make([]time.Duration, 0), make([]time.Duration, 0)
+		for _, p := range sim.Processes {
+			p.OnEvent = func(p *Process, event LoggedEvent) {
+				switch {
+				case event.Type == "send":
+					sentAt[event.MessageID] = s.Now
+				case event.Type != "receive" || p.ID != 2:
+				case strings.Contains(event.Log, ": alert"):
+					alerts = append(alerts, s.Now-sentAt[event.MessageID])
+				default:
+					bulk = append(bulk, s.Now-sentAt[event.MessageID])
+				}
+			}
+		}
+
+		p0, p2, p3 := sim.Processes[0], sim.Processes[2], sim.Processes[3]
+		for at := time.Duration(0); at < 400*time.Millisecond; at += 4 * time.Millisecond {
+			message := fmt.Sprintf("bulk P0 %v", at)
+			s.At(at, func() {
+				p0.Broadcast(message)
+				s.forwardQueued()
+			})
+		}
+		for at := time.Duration(0); at < 400*time.Millisecond; at += 20 * time.Millisecond {
+			for i := 0; i < 34; i++ {
+				message := fmt.Sprintf("bulk P3 %v #%d", at, i)
+				s.At(at, func() {
+					p3.Multicast([]*Process{p2}, message)
+					s.forwardQueued()
+				})
+			}
+		}
+		s.Run()
+
+		alertMean, alertP99 := latencySummary(alerts)
+		bulkMean, _ := latencySummary(bulk)
+		fmt.Fprintf(out, "%-22s | %-8d | %-14v | %-14v | %v\n", mode, len(alerts),
+			alertMean.Round(time.Microsecond), alertP99.Round(time.Microsecond), bulkMean.Round(time.Microsecond))
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Serving alerts first only helps partly: an alert taken early waits in the causal buffer for its bulk")
+	fmt.Fprintln(out, "• That bulk message is low priority, so it queues behind P3's unrelated burst (priority inversion)")
+	fmt.Fprintln(out, "• Inheritance boosts exactly the messages an alert depends on, so alerts skip the unrelated backlog")
+	fmt.Fprintln(out, "• Bulk latency is almost unchanged: the boosted messages are few and had to be delivered anyway")
+}
@@ -49,11 +49,11 @@ func (lc *LamportClock) ReceiveEvent(receivedTime int) int {
 	return lc.time
 }
 
-// Retuner tid
-func (lc *LamportClock) GetTime() int {
+// Retuner et snapshot af tiden
+func (lc *LamportClock) GetTime() ClockSnapshot {
 	lc.mutex.Lock()
 	defer lc.mutex.Unlock()
-	return lc.time
+	return LamportSnapshot(lc.time)
 }
 
 // Lamport message struct initialization
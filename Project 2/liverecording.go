@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Optager en kørende applikations beskeder og lokale events i optagelsesformatet, så dens
+// kommunikationsmønster kan afspilles og analyseres offline (replay, whatif). Sends og
+// leveringer optages af middlewaren, lokale events af en event hook. Tider er rigtig tid
+// siden optagelsen startede, og events står i den rækkefølge optageren så dem.
+type RecordingMiddleware struct {
+	sim    *Simulation
+	start  time.Time
+	mu     sync.Mutex
+	events []RecordedEvent
+}
+
+// Installerer en RecordingMiddleware først i alle sim's processers middleware kæde, så
+// payloads optages som applikationen sendte dem. Kaldes før applikationen sender noget,
+// ellers peger leveringer på beskeder optagelsen ikke har.
+func NewRecordingMiddleware(sim *Simulation) *RecordingMiddleware {
+	m := &RecordingMiddleware{sim: sim, start: time.Now()}
+	for _, p := range sim.Processes {
+		p.Middleware = append([]MessageMiddleware{m}, p.Middleware...)
+	}
+	sim.AddEventHook(func(event StampedEvent) {
+		if event.Type == "local" {
+			m.record(RecordedEvent{Kind: "local", Process: event.Process.ID, Message: event.Payload})
+		}
+	})
+	return m
+}
+
+func (m *RecordingMiddleware) Send(env *Envelope) error {
+	to := env.To.ID
+	m.record(RecordedEvent{Kind: "send", Process: env.From.ID, To: &to, MessageID: env.MessageID, Message: env.Payload})
+	return nil
+}
+
+func (m *RecordingMiddleware) Receive(env *Envelope) error {
+	m.record(RecordedEvent{Kind: "deliver", Process: env.To.ID, MessageID: env.MessageID})
+	return nil
+}
+
+// Tilføjer e med tiden siden start. Tiden læses under låsen, så den aldrig går baglæns.
+func (m *RecordingMiddleware) record(e RecordedEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.AtNs = int64(time.Since(m.start))
+	m.events = append(m.events, e)
+}
+
+// Optagelsen af alt indtil nu, klar til WriteRecording eller Replay
+func (m *RecordingMiddleware) Recording() *Recording {
+	m.mu.Lock()
+	t := &Recording{Start: m.start, Events: append([]RecordedEvent(nil), m.events...)}
+	m.mu.Unlock()
+	clockType := "Lamport"
+	if clock := m.sim.Processes[0].Clock.Now(); clock.IsVector() {
+		clockType = "Vector"
+	} else if clock.IsHybrid() {
+		clockType = "HLC"
+	}
+	t.finish(m.sim, clockType, 0)
+	return t
+}
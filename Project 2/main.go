@@ -1,48 +1,482 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"time"
 )
 
 func main() {
-	fmt.Println("=================================================")
-	fmt.Println("   DISTRIBUTED SYSTEMS - LOGICAL CLOCKS PROJECT")
-	fmt.Println("   Lamport Timestamps vs Vector Clocks")
-	fmt.Println("=================================================")
+	// Benchmark indstillinger
+	opts := DefaultBenchmarkOptions()
+	flag.IntVar(&opts.GOMAXPROCS, "gomaxprocs", 0, "GOMAXPROCS for benchmarks (0 = runtime default)")
+	flag.BoolVar(&opts.Isolate, "isolate", opts.Isolate, "force GC and wait for goroutines between benchmark cells")
+	flag.DurationVar(&opts.Cooldown, "cooldown", 0, "pause between benchmark cells (requires -isolate)")
+	flag.IntVar(&opts.PayloadSize, "payload-size", 0, "payload size in bytes for benchmark messages")
+	flag.IntVar(&opts.PayloadPadding, "payload-padding", 0, "up to this many random extra payload bytes per message")
+	flag.BoolVar(&opts.DiscardEvents, "discard-events", false, "do not retain events in the scalability benchmark, measuring clock overhead only")
+	exportFile := flag.String("export-file", "", "write the demo 1 and 2 logs to this file")
+	exportFormat := flag.String("export-format", "syslog", "format for -export-file: syslog or cef")
+	exportCompress := flag.Bool("export-compress", false, "write only the changed vector entries per event in -export-file")
+	graphFile := flag.String("graph-file", "", "write the demo 2 happens-before graph (transitive reduction) to this file")
+	graphFormat := flag.String("graph-format", "graphml", "format for -graph-file: edgelist or graphml")
+	conflictCSV := flag.String("conflict-csv", "", "write the conflict rate sweep from demo 6 to this CSV file")
+	stalenessCSV := flag.String("staleness-csv", "", "write the staleness time series from demo 8 to this CSV file")
+	propagationCSV := flag.String("propagation-csv", "", "write the knowledge propagation measurements from demo 27 to this CSV file")
+	conflictStrategy := flag.String("conflict-strategy", "reject", "how demo 10 resolves concurrent writes: reject, multi-value, merge or lww")
+	stragglerDelay := flag.Duration("straggler-delay", 2*time.Millisecond, "mean processing delay of the slow process in demo 11")
+	historyFile := flag.String("history-file", "", "write the demo 10 client operation history to this JSON file")
+	historyFormat := flag.String("history-format", "knossos", "format for -history-file: knossos or porcupine")
+	diagnostics := flag.Bool("diagnostics", false, "print queue depth and goroutine diagnostics after demos 1 and 2")
+	profileContention := flag.Bool("profile-contention", false, "enable mutex/block profiling and print the hottest contention points")
+	outputSink := flag.String("output", "stdout", "where demo and benchmark output goes: stdout, file:<path>, tcp:<host:port> or unix:<path>")
+	archive := flag.Bool("archive", false, "store output, trace, metrics and history of this run under -runs-dir")
+	walkthroughState := flag.String("walkthrough-state", "walkthrough.state", "where the walkthrough saves its progress between sessions")
+	realTime := flag.Bool("realtime", false, "run the demo 1 and 2 scenarios in real time instead of virtual time")
+	runsDir := flag.String("runs-dir", "runs", "directory for archived runs (see: runs list | show <id> | delete <id>)")
+	flag.Parse()
+
+	// Output sink: demoer og underkommandoer skriver til en fil eller socket i stedet for stdout
+	var out io.Writer = os.Stdout
+	if *outputSink != "stdout" {
+		sink, err := OpenOutputSink(*outputSink)
+		if err != nil {
+			fmt.Fprintln(out, "Could not open output sink:", err)
+			os.Exit(1)
+		}
+		defer sink.Close()
+		out = sink
+	}
+
+	// Underkommando: interaktiv walkthrough af et partition scenarie (kan genoptages)
+	if flag.Arg(0) == "walkthrough" {
+		if err := RunWalkthrough(os.Stdin, out, *walkthroughState); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: demo scenariet ét event ad gangen med alle ure efter hvert step
+	if flag.Arg(0) == "step" {
+		if err := stepCommand(flag.Args()[1:], os.Stdin, out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: scalability målinger fittet til teoriens O(1) og O(n)
+	if flag.Arg(0) == "report" {
+		if err := reportCommand(flag.Args()[1:], out, opts); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: samme workload kørt med mange seeds, rapporteret som fordelinger
+	if flag.Arg(0) == "experiment" {
+		if err := experimentCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: runs list/show/delete
+	if flag.Arg(0) == "runs" {
+		if err := runsCommand(*runsDir, flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: scenario run/hooks (scenarier fra YAML/JSON filer)
+	if flag.Arg(0) == "scenario" {
+		if err := scenarioCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: replay af en optagelse lavet med scenario run -record
+	if flag.Arg(0) == "replay" {
+		if err := replayCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: interaktiv what-if på en optagelse (forsink eller byt leveringer om)
+	if flag.Arg(0) == "whatif" {
+		if err := whatifCommand(flag.Args()[1:], os.Stdin, out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: en trace med kun fysiske tider afspillet med Lamport, vector og HLC
+	if flag.Arg(0) == "retime" {
+		if err := retimeCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: følg events (med ure) mens demo scenariet eller en scenarie fil kører
+	if flag.Arg(0) == "watch" {
+		if err := watchCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: soak test der leder efter leaks over lang tid
+	if flag.Arg(0) == "soak" {
+		if err := soakCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Underkommando: workload generate/fuzz/run (delbare workload filer)
+	if flag.Arg(0) == "workload" {
+		if err := workloadCommand(flag.Args()[1:], out); err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Arkivering: kopier output til output.txt og skriv eksporterne ind i kørslens mappe
+	if *archive {
+		run, err := CreateRun(*runsDir, os.Args[1:])
+		if err != nil {
+			fmt.Fprintln(out, "Could not create run:", err)
+			os.Exit(1)
+		}
+		if *exportFile == "" {
+			*exportFile = run.Path("trace.log")
+		}
+		if *graphFile == "" {
+			*graphFile = run.Path("happens_before." + *graphFormat)
+		}
+		if *conflictCSV == "" {
+			*conflictCSV = run.Path("conflict_rate.csv")
+		}
+		if *stalenessCSV == "" {
+			*stalenessCSV = run.Path("staleness.csv")
+		}
+		if *propagationCSV == "" {
+			*propagationCSV = run.Path("propagation.csv")
+		}
+		if *historyFile == "" {
+			*historyFile = run.Path("history.json")
+		}
+		output, err := os.Create(run.Path("output.txt"))
+		if err == nil {
+			defer output.Close()
+			out = io.MultiWriter(out, output)
+		}
+		defer func() {
+			if err := run.Finish(); err != nil {
+				fmt.Fprintln(out, "Could not finish run:", err)
+				return
+			}
+			fmt.Fprintf(out, "Archived run %s in %s\n", run.Metadata.ID, run.Dir)
+		}()
+	}
+
+	if *profileContention {
+		disable := EnableContentionProfiling()
+		defer disable()
+	}
+
+	fmt.Fprintln(out, "=================================================")
+	fmt.Fprintln(out, "   DISTRIBUTED SYSTEMS - LOGICAL CLOCKS PROJECT")
+	fmt.Fprintln(out, "   Lamport Timestamps vs Vector Clocks")
+	fmt.Fprintln(out, "=================================================")
 
 	// Demo 1: Kør Lamport simulation
-	fmt.Println("\n\n### DEMO 1: LAMPORT CLOCK SIMULATION ###")
+	fmt.Fprintln(out, "\n\n### DEMO 1: LAMPORT CLOCK SIMULATION ###")
 	lamportSim := NewSimulation(3, false)
+	lamportSim.Out = out
+	if *realTime {
+		lamportSim.TimeMode = RealTime
+	}
 	lamportSim.RunScenario()
 
 	// Demo 2: Kør Vector clock simulation
-	fmt.Println("\n\n### DEMO 2: VECTOR CLOCK SIMULATION ###")
+	fmt.Fprintln(out, "\n\n### DEMO 2: VECTOR CLOCK SIMULATION ###")
 	vectorSim := NewSimulation(3, true)
+	vectorSim.Out = out
+	if *realTime {
+		vectorSim.TimeMode = RealTime
+	}
 	vectorSim.RunScenario()
+	PrintCausalityMatrix(vectorSim)
+
+	if *diagnostics {
+		lamportSim.Diagnostics().Print(out)
+		vectorSim.Diagnostics().Print(out)
+	}
+
+	if *exportFile != "" {
+		if err := ExportLogsToFile(*exportFile, *exportFormat, *exportCompress, lamportSim, vectorSim); err != nil {
+			fmt.Fprintln(out, "Export failed:", err)
+		}
+	}
+	if *graphFile != "" {
+		if err := ExportHappensBeforeToFile(*graphFile, *graphFormat, vectorSim); err != nil {
+			fmt.Fprintln(out, "Graph export failed:", err)
+		}
+	}
 
 	// Demo 3: Concurrent Message Arrival
 	// Viser hvad der sker når 2 beskeder ankommer med samme Lamport timestamp
-	fmt.Println("\n\n### DEMO 3: CONCURRENT MESSAGE ARRIVAL ###")
-	fmt.Println("(This demonstrates Lamport's fundamental limitation)")
-	DemonstrateConcurrentMessages()
+	fmt.Fprintln(out, "\n\n### DEMO 3: CONCURRENT MESSAGE ARRIVAL ###")
+	fmt.Fprintln(out, "(This demonstrates Lamport's fundamental limitation)")
+	DemonstrateConcurrentMessages(out)
 
 	// Demo 4: Comprehensive Scalability Analysis
 	// Måler O(1) vs O(n) kompleksitet med 5-100 processer
-	fmt.Println("\n\n### DEMO 4: SCALABILITY ANALYSIS ###")
-	fmt.Println("(Measuring O(1) vs O(n) complexity with increasing process count)")
-	BenchmarkScalability([]int{5, 10, 20, 50}, 10)
+	fmt.Fprintln(out, "\n\n### DEMO 4: SCALABILITY ANALYSIS ###")
+	fmt.Fprintln(out, "(Measuring O(1) vs O(n) complexity with increasing process count)")
+	BenchmarkScalability(out, []int{5, 10, 20, 50}, 10, opts)
 
 	// Demo 5: Message Complexity Analysis
 	// Viser hvordan message size vokser med antal processer
-	fmt.Println("\n\n### DEMO 5: MESSAGE COMPLEXITY ANALYSIS ###")
-	BenchmarkMessageComplexity(50)
+	fmt.Fprintln(out, "\n\n### DEMO 5: MESSAGE COMPLEXITY ANALYSIS ###")
+	BenchmarkMessageComplexity(out, 50, opts)
 
 	// Demo 6: Ordering Capability Measurement
 	// Måler faktisk ordering correctness under forskellige workloads
-	fmt.Println("\n\n### DEMO 6: ORDERING CAPABILITY MEASUREMENT ###")
-	MeasureOrderingCapability(10, 0.6) // 60% concurrency
+	fmt.Fprintln(out, "\n\n### DEMO 6: ORDERING CAPABILITY MEASUREMENT ###")
+	MeasureOrderingCapability(out, 10, 0.6) // 60% concurrency
+	var conflictOut io.Writer
+	if *conflictCSV != "" {
+		file, err := os.Create(*conflictCSV)
+		if err != nil {
+			fmt.Fprintln(out, "Could not create conflict rate CSV:", err)
+		} else {
+			defer file.Close()
+			conflictOut = file
+		}
+	}
+	MeasureConflictRateSweep(out, []int{3, 5, 10, 20}, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}, conflictOut)
+	MeasureTickBatching(out, 10, 0.9, []int{1, 2, 4, 8, 16})
+
+	// Demo 7: Hash-chained event logs
+	// Viser hvordan clock + hash kæde gør manipulation af loggen synlig
+	fmt.Fprintln(out, "\n\n### DEMO 7: TAMPER-EVIDENT EVENT LOGS ###")
+	DemonstrateHashChain(out)
+
+	// Demo 8: Information propagation lag
+	// Måler hvor forældet processernes viden om hinanden er i forskellige topologier
+	fmt.Fprintln(out, "\n\n### DEMO 8: INFORMATION PROPAGATION LAG ###")
+	var csvOut io.Writer
+	if *stalenessCSV != "" {
+		file, err := os.Create(*stalenessCSV)
+		if err != nil {
+			fmt.Fprintln(out, "Could not create staleness CSV:", err)
+		} else {
+			defer file.Close()
+			csvOut = file
+		}
+	}
+	MeasureStalenessByTopology(out, 6, csvOut)
+
+	// Demo 9: Clock migration
+	// Viser et skift fra Lamport til vector clocks ved en barrier
+	fmt.Fprintln(out, "\n\n### DEMO 9: CLOCK MIGRATION (LAMPORT → VECTOR) ###")
+	DemonstrateClockMigration(out)
+
+	// Demo 10: Vector clock ETags
+	// Viser optimistic concurrency control i en HTTP service
+	fmt.Fprintln(out, "\n\n### DEMO 10: VERSION-STAMPED HTTP RESPONSES ###")
+	resolver, err := ResolverByName(*conflictStrategy)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		resolver = RejectResolver{}
+	}
+	history := DemonstrateETagConcurrency(out, resolver)
+	if *historyFile != "" {
+		if err := ExportHistoryToFile(*historyFile, *historyFormat, history); err != nil {
+			fmt.Fprintln(out, "History export failed:", err)
+		}
+	}
+
+	// Demo 11: Scheduling noise
+	// Viser hvordan én langsom proces påvirker levering og causal stability
+	fmt.Fprintln(out, "\n\n### DEMO 11: STRAGGLER PROCESSES ###")
+	MeasureStragglerImpact(out, 5, *stragglerDelay)
+
+	// Demo 12: Checkpoints og rollback recovery
+	// Viser hvordan vector clocks finder en konsistent recovery line
+	fmt.Fprintln(out, "\n\n### DEMO 12: CHECKPOINTS AND RECOVERY LINES ###")
+	DemonstrateRecoveryLine(out)
+
+	// Demo 13: Causal broadcast
+	// Viser et chat rum hvor svar aldrig vises før spørgsmålet
+	fmt.Fprintln(out, "\n\n### DEMO 13: CAUSAL BROADCAST CHAT ###")
+	DemonstrateCausalChat(out)
+	BenchmarkCausalDelivery(out, 5, []float64{0, 0.01, 0.05}, []float64{0, 0.1, 0.3})
+
+	// Demo 14: Time-travel queries
+	// Viser tilstanden af alle processer i et konsistent cut af et optaget run
+	fmt.Fprintln(out, "\n\n### DEMO 14: TIME-TRAVEL QUERIES ###")
+	DemonstrateTimeTravel(out)
+
+	// Demo 15: Omission faults
+	// Viser forskellen på send- og receive-omission for en heartbeat failure detector
+	fmt.Fprintln(out, "\n\n### DEMO 15: SEND AND RECEIVE OMISSION ###")
+	DemonstrateOmissionFaults(out)
+
+	// Demo 16: Trace compression
+	// Viser hvor meget trace filerne fylder med og uden komprimerede vectors
+	fmt.Fprintln(out, "\n\n### DEMO 16: TRACE COMPRESSION ###")
+	MeasureTraceCompression(out)
+
+	// Demo 17: Partial replication
+	// Viser per-key vector clocks der kun dækker keyens replica set
+	fmt.Fprintln(out, "\n\n### DEMO 17: PARTIAL REPLICATION WITH PER-KEY VECTORS ###")
+	DemonstratePartialReplication(out)
+
+	// Demo 18: Client sessions
+	// Viser read-your-writes og monotonic reads for klienter der skifter replica
+	fmt.Fprintln(out, "\n\n### DEMO 18: CLIENT SESSION GUARANTEES ###")
+	DemonstrateClientSessions(out)
+
+	// Demo 19: Hybrid logical clocks
+	// Viser HLC timestamps der følger den fysiske tid, også når et ur går forkert
+	fmt.Fprintln(out, "\n\n### DEMO 19: HYBRID LOGICAL CLOCKS ###")
+	DemonstrateHLC(out)
+
+	// Demo 20: Multi-clock stamping
+	// Stempler de samme events med vector, Lamport og HLC og sammenligner dem
+	fmt.Fprintln(out, "\n\n### DEMO 20: MULTI-CLOCK STAMPING ###")
+	DemonstrateMultiClock(out)
+
+	// Demo 21: Wall clock skew
+	// Viser causale par som wall clock tiden ordner forkert når processernes ure er forskudt
+	fmt.Fprintln(out, "\n\n### DEMO 21: WALL CLOCK SKEW ###")
+	DemonstrateWallClockSkew(out)
+
+	// Demo 22: Clock growth monitoring
+	// Følger tællernes vækstrate og advarer før de løber over den konfigurerede bredde
+	fmt.Fprintln(out, "\n\n### DEMO 22: CLOCK GROWTH MONITORING ###")
+	DemonstrateClockGrowth(out)
+
+	// Demo 23: Reordering and duplication faults
+	// Viser at vector clocks genkender duplikater og forældede beskeder, hvor Lamport gætter
+	fmt.Fprintln(out, "\n\n### DEMO 23: REORDERING AND DUPLICATION FAULTS ###")
+	DemonstrateReorderingFaults(out)
+
+	// Demo 24: Online concurrency detection
+	// Finder concurrent par mens kørslen står på, med et glidende vindue af seneste events
+	fmt.Fprintln(out, "\n\n### DEMO 24: ONLINE CONCURRENCY DETECTION ###")
+	DemonstrateOnlineConcurrency(out)
+
+	// Demo 25: Crash and recovery
+	// Viser fail-stop og crash-recovery, med og uden uret på stabilt lager
+	fmt.Fprintln(out, "\n\n### DEMO 25: CRASH AND RECOVERY ###")
+	DemonstrateCrashRecovery(out)
+
+	// Demo 26: Process churn
+	// Viser processer der kommer til og forlader simulationen mens den kører
+	fmt.Fprintln(out, "\n\n### DEMO 26: PROCESS CHURN ###")
+	DemonstrateProcessChurn(out)
+
+	// Demo 27: Knowledge propagation
+	// Måler hvor mange hops og hvor lang tid det tager før alle kender ét event
+	fmt.Fprintln(out, "\n\n### DEMO 27: KNOWLEDGE PROPAGATION ###")
+	var propagationOut io.Writer
+	if *propagationCSV != "" {
+		file, err := os.Create(*propagationCSV)
+		if err != nil {
+			fmt.Fprintln(out, "Could not create propagation CSV:", err)
+		} else {
+			defer file.Close()
+			propagationOut = file
+		}
+	}
+	MeasureKnowledgePropagation(out, 16, propagationOut)
+
+	// Demo 28: Stable process identifiers
+	// Samler traces fra to kørsler efter index og efter stabile ID'er
+	fmt.Fprintln(out, "\n\n### DEMO 28: STABLE PROCESS IDENTIFIERS ###")
+	DemonstrateStableIdentifiers(out)
+
+	// Demo 29: Late joiners
+	// Sammenligner en ny proces der starter fra 0 med en der henter state fra en anden
+	fmt.Fprintln(out, "\n\n### DEMO 29: LATE JOINERS ###")
+	DemonstrateLateJoiners(out)
+
+	// Demo 30: Process behavior
+	// Kører en replikeret tæller som Behavior på processerne med hvert ur
+	fmt.Fprintln(out, "\n\n### DEMO 30: PROCESS BEHAVIOR ###")
+	DemonstrateProcessBehavior(out)
+
+	// Demo 31: Broadcast
+	// Alle-til-alle scenario med broadcast og med unicast til hver modtager
+	fmt.Fprintln(out, "\n\n### DEMO 31: BROADCAST ###")
+	DemonstrateBroadcast(out)
+
+	// Demo 32: Replacing time.Now
+	// Samme applikation ordnet med time.Now og med clock.Now (HLC) på skæve ure
+	fmt.Fprintln(out, "\n\n### DEMO 32: REPLACING time.Now ###")
+	DemonstrateTimeNowReplacement(out)
+
+	// Demo 33: Causal-order delivery
+	// Holder et broadcast tilbage indtil det det afhænger af er leveret
+	fmt.Fprintln(out, "\n\n### DEMO 33: CAUSAL-ORDER DELIVERY ###")
+	DemonstrateCausalDelivery(out)
+
+	// Demo 34: Coverage-guided stress scenarios
+	// Søger efter workloads med mest concurrency, dybeste kæder og længste køer
+	fmt.Fprintln(out, "\n\n### DEMO 34: COVERAGE-GUIDED STRESS SCENARIOS ###")
+	DemonstrateStressSearch(out)
+
+	// Demo 35: FIFO, causal and unordered delivery
+	// Sammenligner leveringsgarantierne på et netværk der omrokerer beskeder
+	fmt.Fprintln(out, "\n\n### DEMO 35: FIFO, CAUSAL AND UNORDERED DELIVERY ###")
+	DemonstrateDeliveryOrder(out)
+
+	// Demo 36: Priority inheritance in causal delivery
+	// Løfter de beskeder en vigtig broadcast venter på, så den ikke står bag uvigtige
+	fmt.Fprintln(out, "\n\n### DEMO 36: PRIORITY INHERITANCE IN CAUSAL DELIVERY ###")
+	DemonstratePriorityInheritance(out)
+
+	// Demo 37: Virtual and real time
+	// Samme scenarie i virtuel og rigtig tid, og 10.000 events på millisekunder
+	fmt.Fprintln(out, "\n\n### DEMO 37: VIRTUAL AND REAL TIME ###")
+	DemonstrateTimeModes(out)
+
+	// Demo 38: Run-to-run clock distribution
+	// Fordelingen af den logiske tid tilfældige workloads når over mange kørsler
+	fmt.Fprintln(out, "\n\n### DEMO 38: RUN-TO-RUN CLOCK DISTRIBUTION ###")
+	DemonstrateClockDistribution(out)
+
+	// Demo 39: Clock delta sessions
+	// Hvor mange header bytes en session der kun sender ændrede entries sparer per link
+	fmt.Fprintln(out, "\n\n### DEMO 39: CLOCK DELTA SESSIONS ###")
+	DemonstrateDeltaSessions(out)
+
+	if *profileContention {
+		PrintContentionReport(out, 10)
+	}
 
-	fmt.Println("\n\n=================================================")
-	fmt.Println("   SIMULATION COMPLETE")
-	fmt.Println("=================================================")
+	fmt.Fprintln(out, "\n\n=================================================")
+	fmt.Fprintln(out, "   SIMULATION COMPLETE")
+	fmt.Fprintln(out, "=================================================")
 }
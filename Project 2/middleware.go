@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// En besked på vej gennem middleware kæden, med clock headeren og payloaden hver for sig
+type Envelope struct {
+	From      *Process
+	To        *Process
+	MessageID string
+	Header    string
+	Payload   string
+}
+
+// MessageMiddleware er et led i en proces' beskedsti. Send kaldes hos afsenderen efter
+// clocken er stemplet i headeren og før beskeden lægges i køen; Receive kaldes hos
+// modtageren før OnDeliver og før clocken synkroniseres. Et led kan ændre envelopen
+// eller returnere en fejl for at droppe beskeden (ErrMessageDropped for bevidste drops).
+type MessageMiddleware interface {
+	Send(env *Envelope) error
+	Receive(env *Envelope) error
+}
+
+// Kører afsenderens middleware i rækkefølge
+func (p *Process) sendThrough(env *Envelope) error {
+	for _, m := range p.Middleware {
+		if err := m.Send(env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Kører modtagerens middleware i omvendt rækkefølge, så fx dekryptering sker før
+// dekomprimering når afsenderen komprimerede før den krypterede
+func (p *Process) receiveThrough(env *Envelope) error {
+	for i := len(p.Middleware) - 1; i >= 0; i-- {
+		if err := p.Middleware[i].Receive(env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tæller beskeder og bytes som de ser ud på tråden. Læg den sidst i kæden for at måle
+// efter komprimering og kryptering.
+type MetricsMiddleware struct {
+	Sent          atomic.Int64
+	Received      atomic.Int64
+	WireBytesSent atomic.Int64
+}
+
+func (m *MetricsMiddleware) Send(env *Envelope) error {
+	m.Sent.Add(1)
+	m.WireBytesSent.Add(int64(len(env.Header) + 1 + len(env.Payload)))
+	return nil
+}
+
+func (m *MetricsMiddleware) Receive(env *Envelope) error {
+	m.Received.Add(1)
+	return nil
+}
+
+// Injicerer fejl på beskedstien: tab ved send og ved receive, som OmissionFaults, men
+// med sin egen RNG så fejlene kan genskabes
+type FaultMiddleware struct {
+	SendDrop    float64
+	ReceiveDrop float64
+	Rand        *mathrand.Rand
+	mutex       sync.Mutex
+}
+
+// Opretter en fault injector med en fast seed
+func NewFaultMiddleware(sendDrop float64, receiveDrop float64, seed int64) *FaultMiddleware {
+	return &FaultMiddleware{SendDrop: sendDrop, ReceiveDrop: receiveDrop, Rand: mathrand.New(mathrand.NewSource(seed))}
+}
+
+// Skal beskeden tabes med sandsynlighed rate?
+func (f *FaultMiddleware) drop(rate float64) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return rate > 0 && f.Rand.Float64() < rate
+}
+
+func (f *FaultMiddleware) Send(env *Envelope) error {
+	if f.drop(f.SendDrop) {
+		return ErrMessageDropped
+	}
+	return nil
+}
+
+func (f *FaultMiddleware) Receive(env *Envelope) error {
+	if f.drop(f.ReceiveDrop) {
+		return ErrMessageDropped
+	}
+	return nil
+}
+
+// Komprimerer vector clock headere ved kun at sende entries der ikke er 0, som
+// "Z<længde>{index:værdi,...}". Lamport og HLC headere sendes uændret.
+type CompressionMiddleware struct{}
+
+func (CompressionMiddleware) Send(env *Envelope) error {
+	if !strings.HasPrefix(env.Header, "[") || strings.Contains(env.Header, ";") {
+		return nil
+	}
+	vector, err := parseVector(env.Header)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedClock, err)
+	}
+	compressed := "Z" + strconv.Itoa(len(vector)) + EncodeVectorDelta(make([]int, len(vector)), vector)
+	if len(compressed) < len(env.Header) {
+		env.Header = compressed
+	}
+	return nil
+}
+
+func (CompressionMiddleware) Receive(env *Envelope) error {
+	if !strings.HasPrefix(env.Header, "Z") {
+		return nil
+	}
+	length, delta, found := strings.Cut(env.Header[1:], "{")
+	n, err := strconv.Atoi(length)
+	if !found || err != nil || n < 0 {
+		return fmt.Errorf("malformed compressed header %q", env.Header)
+	}
+	vector, err := DecodeVectorDelta(make([]int, n), "{"+delta)
+	if err != nil {
+		return err
+	}
+	env.Header = FormatVector(vector)
+	return nil
+}
+
+// Krypterer payloaden med AES-GCM. Clock headeren forbliver læsbar, så netværket (og
+// trace værktøjer) stadig kan se causaliteten uden at kunne læse indholdet.
+type EncryptionMiddleware struct {
+	aead cipher.AEAD
+}
+
+// Opretter en krypterings middleware med en AES nøgle på 16, 24 eller 32 bytes
+func NewEncryptionMiddleware(key []byte) (*EncryptionMiddleware, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption middleware: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encryption middleware: %w", err)
+	}
+	return &EncryptionMiddleware{aead: aead}, nil
+}
+
+func (e *EncryptionMiddleware) Send(env *Envelope) error {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("encrypt %s: %w", env.MessageID, err)
+	}
+	// Headeren er additional data, så den ikke kan ændres uden at dekrypteringen fejler
+	sealed := e.aead.Seal(nonce, nonce, []byte(env.Payload), []byte(env.Header))
+	env.Payload = base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+func (e *EncryptionMiddleware) Receive(env *Envelope) error {
+	sealed, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return fmt.Errorf("decrypt %s: %w", env.MessageID, errMalformedCiphertext)
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, ciphertext, []byte(env.Header))
+	if err != nil {
+		return fmt.Errorf("decrypt %s: %w", env.MessageID, err)
+	}
+	env.Payload = string(plain)
+	return nil
+}
+
+var errMalformedCiphertext = errors.New("malformed ciphertext")
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Flere ure på én gang. Primary styrer processen (logs, checkpoints osv.); de andre
+// tikker på præcis de samme events, og alle værdier følger med i hver besked. Så kan
+// urtyperne sammenlignes på den samme historie i stedet for i to parallelle kørsler.
+type MultiClock struct {
+	Primary LogicalClock
+	Others  []LogicalClock
+}
+
+// Opretter et multi-clock med primary og de andre ure
+func NewMultiClock(primary LogicalClock, others ...LogicalClock) *MultiClock {
+	return &MultiClock{Primary: primary, Others: others}
+}
+
+// Primary snapshot med de andre ures værdier vedhæftet
+func (mc *MultiClock) stamp(primary ClockSnapshot, tick func(i int, c LogicalClock) ClockSnapshot) ClockSnapshot {
+	primary.stamps = make([]ClockSnapshot, len(mc.Others))
+	for i, c := range mc.Others {
+		primary.stamps[i] = tick(i, c)
+	}
+	return primary
+}
+
+func (mc *MultiClock) Tick() ClockSnapshot {
+	return mc.stamp(mc.Primary.Tick(), func(i int, c LogicalClock) ClockSnapshot { return c.Tick() })
+}
+
+func (mc *MultiClock) Send() ClockSnapshot {
+	return mc.stamp(mc.Primary.Send(), func(i int, c LogicalClock) ClockSnapshot { return c.Send() })
+}
+
+// Hvert ur modtager sin egen værdi fra beskeden; mangler den (afsenderen har færre
+// ure), tæller receive som et event uden ny viden
+func (mc *MultiClock) Receive(received ClockSnapshot) ClockSnapshot {
+	primary := received
+	primary.stamps = nil
+	return mc.stamp(mc.Primary.Receive(primary), func(i int, c LogicalClock) ClockSnapshot {
+		if i < len(received.stamps) {
+			return c.Receive(received.stamps[i])
+		}
+		return c.Receive(ClockSnapshot{})
+	})
+}
+
+func (mc *MultiClock) Now() ClockSnapshot {
+	return mc.stamp(mc.Primary.Now(), func(i int, c LogicalClock) ClockSnapshot { return c.Now() })
+}
+
+// Ny simulation hvor hver proces stempler med vector (primary), Lamport og HLC
+func NewMultiClockSimulation(numProcesses int) *Simulation {
+	return NewSimulationWithClocks(numProcesses, func(id int, numProcesses int) LogicalClock {
+		return NewMultiClock(NewVectorClock(numProcesses, id), NewLamportClock(), NewHybridLogicalClock())
+	})
+}
+
+// Navn på urtypen bag et snapshot
+func clockKind(s ClockSnapshot) string {
+	switch {
+	case s.IsVector():
+		return "Vector"
+	case s.IsHybrid():
+		return "HLC"
+	}
+	return "Lamport"
+}
+
+// Hvor godt et ur ordner de samme event par som vector clocken (ground truth)
+type StampAgreement struct {
+	Clock             string
+	CausalPairs       int // Par hvor vector clocken finder happens-before
+	CausalOrdered     int // Heraf ordnet i samme retning af dette ur
+	ConcurrentPairs   int // Par som vector clocken finder concurrent
+	ConcurrentOrdered int // Heraf givet en (vilkårlig) rækkefølge af dette ur
+}
+
+// Sammenligner hvert ekstra ur med vector primary over alle par af events på forskellige
+// processer i en multi-clock simulation
+func CompareClockStamps(sim *Simulation) ([]StampAgreement, error) {
+	events := make([]LoggedEvent, 0)
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			events = append(events, p.loggedEvent(i))
+		}
+	}
+	if len(events) == 0 || !events[0].Clock.IsVector() || len(events[0].Clock.Stamps()) == 0 {
+		return nil, fmt.Errorf("requires a multi-clock simulation with a vector primary")
+	}
+
+	agreements := make([]StampAgreement, len(events[0].Clock.Stamps()))
+	for k, stamp := range events[0].Clock.Stamps() {
+		agreements[k].Clock = clockKind(stamp)
+	}
+	for a := 0; a < len(events); a++ {
+		for b := a + 1; b < len(events); b++ {
+			ea, eb := events[a], events[b]
+			if ea.ProcessID == eb.ProcessID {
+				continue
+			}
+			truth := ea.Clock.Compare(eb.Clock)
+			for k := range agreements {
+				order := ea.Clock.Stamps()[k].Compare(eb.Clock.Stamps()[k])
+				if truth != 0 {
+					agreements[k].CausalPairs++
+					if order == truth {
+						agreements[k].CausalOrdered++
+					}
+				} else {
+					agreements[k].ConcurrentPairs++
+					if order != 0 {
+						agreements[k].ConcurrentOrdered++
+					}
+				}
+			}
+		}
+	}
+	return agreements, nil
+}
+
+// DemonstrateMultiClock kører scenariet én gang med vector, Lamport og HLC på de samme
+// events og sammenligner dem par for par
+func DemonstrateMultiClock(out io.Writer) {
+	sim := NewMultiClockSimulation(3)
+	sim.Out = out
+	sim.RunScenario()
+
+	agreements, err := CompareClockStamps(sim)
+	if err != nil {
+		fmt.Fprintln(out, "Could not compare clocks:", err)
+		return
+	}
+	fmt.Fprintln(out, "\n=== Clock agreement on the same events (vector clock as ground truth) ===")
+	fmt.Fprintf(out, "%-10s | %-22s | %-28s\n", "Clock", "Causal pairs ordered", "Concurrent pairs given order")
+	fmt.Fprintln(out, "-----------|------------------------|-----------------------------")
+	for _, a := range agreements {
+		fmt.Fprintf(out, "%-10s | %-22s | %-28s\n", a.Clock,
+			fmt.Sprintf("%d / %d", a.CausalOrdered, a.CausalPairs),
+			fmt.Sprintf("%d / %d", a.ConcurrentOrdered, a.ConcurrentPairs))
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Every clock is stamped on the same events, so differences are the clocks, not the runs")
+	fmt.Fprintln(out, "• Lamport and HLC order every causal pair correctly (the clock condition)")
+	fmt.Fprintln(out, "• They also order most concurrent pairs, which is exactly what hides conflicts")
+	fmt.Fprintln(out, "• The message header carries all clocks, e.g. [1,2,0];5;H<time>")
+}
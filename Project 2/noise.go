@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Returnerer hvor længe en proces skal vente før den håndterer næste besked. r er
+// processens fault RNG, så forsinkelserne følger simulationens seed.
+type DelayModel func(r *rand.Rand) time.Duration
+
+// Samme forsinkelse hver gang (en konstant langsom node)
+func FixedDelay(d time.Duration) DelayModel {
+	return func(r *rand.Rand) time.Duration { return d }
+}
+
+// Uniformt fordelt forsinkelse i [min, max] (byttes om hvis max er mindre end min)
+func UniformDelay(min, max time.Duration) DelayModel {
+	if max < min {
+		min, max = max, min
+	}
+	return func(r *rand.Rand) time.Duration {
+		return min + time.Duration(r.Int63n(int64(max-min)+1))
+	}
+}
+
+// Eksponentielt fordelt forsinkelse med et givent gennemsnit (mange korte, få lange)
+func ExponentialDelay(mean time.Duration) DelayModel {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// Ingen forsinkelse, bortset fra en lang pause med en given sandsynlighed (fx GC pause)
+func PauseDelay(probability float64, pause time.Duration) DelayModel {
+	return func(r *rand.Rand) time.Duration {
+		if r.Float64() < probability {
+			return pause
+		}
+		return 0
+	}
+}
+
+// Resultatet af én kørsel med en given straggler konfiguration
+type StragglerResult struct {
+	DeliveryLatency  []time.Duration // Fra send til levering, for alle beskeder
+	MaxQueueDepth    []int           // Største antal ventende beskeder set per proces
+	StableLatency    []time.Duration // Fra event til alle processer kender det
+	UnstableAtFinish int             // Events der aldrig blev causally stable
+}
+
+// Et event der venter på at blive kendt af alle processer
+type pendingStability struct {
+	process int
+	counter int
+	at      time.Time
+}
+
+// Følger hvornår events bliver causally stable (kendt af alle processer)
+type stabilityTracker struct {
+	pending   []pendingStability
+	latencies []time.Duration
+	mutex     sync.Mutex
+}
+
+// Registrerer at proces p netop har lavet sit counter'te event
+func (st *stabilityTracker) track(p *Process) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.pending = append(st.pending, pendingStability{p.ID, p.Clock.Now().At(p.ID), time.Now()})
+}
+
+// Markerer events som stable når alle processer kender dem
+func (st *stabilityTracker) check(sim *Simulation) {
+	n := len(sim.Processes)
+	frontier := make([]int, n)
+	for i := range frontier {
+		frontier[i] = -1
+	}
+	for _, p := range sim.Processes {
+		v := p.Clock.Now()
+		for i := 0; i < n; i++ {
+			if frontier[i] == -1 || v.At(i) < frontier[i] {
+				frontier[i] = v.At(i)
+			}
+		}
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	now := time.Now()
+	remaining := st.pending[:0]
+	for _, e := range st.pending {
+		if e.counter <= frontier[e.process] {
+			st.latencies = append(st.latencies, now.Sub(e.at))
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	st.pending = remaining
+}
+
+// Kører en tilfældig workload hvor delays[i] forsinker proces i (nil = ingen forsinkelse)
+func runStragglerWorkload(out io.Writer, numProcesses int, rounds int, delays []DelayModel) StragglerResult {
+	sim := NewSimulation(numProcesses, true)
+
+	var mutex sync.Mutex
+	result := StragglerResult{MaxQueueDepth: make([]int, numProcesses)}
+	for i, p := range sim.Processes {
+		if i < len(delays) {
+			p.ProcessingDelay = delays[i]
+		}
+		p.OnDeliver = func(p *Process, event Event) bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			result.DeliveryLatency = append(result.DeliveryLatency, time.Since(event.SentAt))
+			if depth := len(p.MessageQueue) + 1; depth > result.MaxQueueDepth[p.ID] {
+				result.MaxQueueDepth[p.ID] = depth
+			}
+			return true
+		}
+	}
+
+	tracker := &stabilityTracker{}
+	stopSampling := make(chan bool)
+	var sampler sync.WaitGroup
+	sampler.Add(1)
+	go func() {
+		defer sampler.Done()
+		ticker := time.NewTicker(500 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				tracker.check(sim)
+			case <-stopSampling:
+				return
+			}
+		}
+	}()
+
+	done := make(chan bool)
+	for _, p := range sim.Processes {
+		p.Run(done)
+	}
+
+	for r := 0; r < rounds; r++ {
+		for _, p := range sim.Processes {
+			p.HandleLocalEvent(fmt.Sprintf("Work %d", r))
+			tracker.track(p)
+			if target := sim.Rand.Intn(numProcesses); target != p.ID {
+				p.SendMessage(sim.Processes[target], fmt.Sprintf("Msg %d", r))
+				tracker.track(p)
+			}
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	// Vent til alle beskeder er leveret
+	if err := sim.WaitUntilIdle(); err != nil {
+		fmt.Fprintln(out, "Messages still in flight:", err)
+	}
+
+	close(stopSampling)
+	sampler.Wait()
+	close(done)
+	tracker.check(sim)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	result.StableLatency = tracker.latencies
+	result.UnstableAtFinish = len(tracker.pending)
+	return result
+}
+
+// Gennemsnit og 99. percentil af en række durations
+func latencySummary(durations []time.Duration) (time.Duration, time.Duration) {
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return total / time.Duration(len(sorted)), sorted[len(sorted)*99/100]
+}
+
+// Måler hvordan en langsom proces (P0) påvirker leveringsforsinkelse, buffering og
+// hvor længe det tager før events bliver causally stable
+func MeasureStragglerImpact(out io.Writer, numProcesses int, delay time.Duration) {
+	fmt.Fprintln(out, "\n=== STRAGGLER IMPACT ON DELIVERY AND CAUSAL STABILITY ===")
+	fmt.Fprintf(out, "Processes: %d, straggler: P0, delay scale: %v\n\n", numProcesses, delay)
+	fmt.Fprintf(out, "%-22s | %-12s | %-12s | %-10s | %-12s | %-12s | %-8s\n",
+		"P0 delay model", "Deliver avg", "Deliver p99", "P0 queue", "Stable avg", "Stable p99", "Unstable")
+	fmt.Fprintln(out, "-----------------------|--------------|--------------|------------|--------------|--------------|---------")
+
+	configurations := []struct {
+		name  string
+		model DelayModel
+	}{
+		{"none", nil},
+		{fmt.Sprintf("fixed %v", delay), FixedDelay(delay)},
+		{fmt.Sprintf("uniform 0-%v", 2*delay), UniformDelay(0, 2*delay)},
+		{fmt.Sprintf("exponential %v", delay), ExponentialDelay(delay)},
+		{fmt.Sprintf("pause 10%% × %v", 10*delay), PauseDelay(0.1, 10*delay)},
+	}
+
+	for _, c := range configurations {
+		delays := make([]DelayModel, numProcesses)
+		delays[0] = c.model
+		result := runStragglerWorkload(out, numProcesses, 30, delays)
+
+		deliverAvg, deliverP99 := latencySummary(result.DeliveryLatency)
+		stableAvg, stableP99 := latencySummary(result.StableLatency)
+		fmt.Fprintf(out, "%-22s | %-12v | %-12v | %-10d | %-12v | %-12v | %-8d\n",
+			c.name, deliverAvg.Round(time.Microsecond), deliverP99.Round(time.Microsecond),
+			result.MaxQueueDepth[0], stableAvg.Round(time.Microsecond), stableP99.Round(time.Microsecond),
+			result.UnstableAtFinish)
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• A single slow process delays stability for every event: nothing is stable until P0 knows it")
+	fmt.Fprintln(out, "• Messages to the straggler queue up (P0 queue) and their delivery latency grows with the backlog")
+	fmt.Fprintln(out, "• Rare long pauses hurt the tail (p99) far more than the average")
+}
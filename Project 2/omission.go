@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Directional omission faults for én proces. Til forskel fra uniform loss ligger fejlen
+// hos en bestemt proces, så man kan skelne "kan ikke sende" fra "kan ikke modtage".
+type OmissionFaults struct {
+	Send    float64 // Sandsynlighed for at en afsendt besked aldrig forlader processen
+	Receive float64 // Sandsynlighed for at en ankommet besked aldrig når processen
+}
+
+// Antal beskeder processen har tabt pga. omission faults
+type omissionCounters struct {
+	send    atomic.Int64
+	receive atomic.Int64
+}
+
+// Skal denne afsendte besked tabes? r er processens fault RNG
+func (f OmissionFaults) omitSend(r *rand.Rand) bool {
+	return f.Send > 0 && r.Float64() < f.Send
+}
+
+// Skal denne ankomne besked tabes?
+func (f OmissionFaults) omitReceive(r *rand.Rand) bool {
+	return f.Receive > 0 && r.Float64() < f.Receive
+}
+
+// En rand.Source med lås, da en proces' fault RNG bruges både ved send (i den goroutine
+// der driver workloaden) og ved receive (i processens Run goroutine). Den egentlige
+// source (ca. 5 KB) laves først ved første træk, så processer uden faults ikke betaler.
+type lockedSource struct {
+	mutex sync.Mutex
+	seed  int64
+	src   rand.Source64
+}
+
+func (s *lockedSource) source() rand.Source64 {
+	if s.src == nil {
+		s.src = rand.NewSource(s.seed).(rand.Source64)
+	}
+	return s.src
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source().Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source().Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seed, s.src = seed, nil
+}
+
+// RNG'en en proces trækker omission faults og ProcessingDelay fra
+func newFaultRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{seed: seed})
+}
+
+// Seedet for proces id's fault RNG, afledt af simulationens seed
+func (sim *Simulation) faultSeedFor(id int) int64 {
+	return sim.faultSeed*1000003 + int64(id)
+}
+
+// Giver hver proces en fault RNG afledt af seed (også processer AddProcess tilføjer
+// senere), så omission faults og forsinkelser er de samme hver gang med samme seed
+func (sim *Simulation) seedFaults(seed int64) {
+	sim.faultSeed = seed
+	for _, p := range sim.Processes {
+		p.faultRand.Seed(sim.faultSeedFor(p.ID))
+	}
+}
+
+// Retuner hvor mange beskeder processen har tabt ved send og ved receive
+func (p *Process) Omissions() (int64, int64) {
+	return p.omitted.send.Load(), p.omitted.receive.Load()
+}
+
+// Kører en heartbeat workload hvor alle sender til alle hver runde.
+// heard[j][i] er antal heartbeats Pj har modtaget fra Pi.
+func runHeartbeatWorkload(numProcesses int, rounds int, faulty int, faults OmissionFaults) [][]int {
+	sim := NewSimulation(numProcesses, true)
+	sim.Processes[faulty].Omission = faults
+
+	heard := make([][]int, numProcesses)
+	for _, p := range sim.Processes {
+		heard[p.ID] = make([]int, numProcesses)
+		p.OnDeliver = func(p *Process, event Event) bool {
+			heard[p.ID][event.ProcessID]++
+			return true
+		}
+	}
+
+	for r := 0; r < rounds; r++ {
+		for _, p := range sim.Processes {
+			for _, target := range sim.Processes {
+				if target != p {
+					p.SendMessage(target, fmt.Sprintf("heartbeat %d", r))
+				}
+			}
+		}
+		sim.deliverPending()
+	}
+	return heard
+}
+
+// Print funktion: hvem hver proces mistænker (hørt fra i under 3/4 af runderne)
+func printSuspicions(out io.Writer, heard [][]int, rounds int) {
+	for j, row := range heard {
+		suspects := make([]string, 0)
+		for i, count := range row {
+			if i != j && count < rounds*3/4 {
+				suspects = append(suspects, fmt.Sprintf("P%d", i))
+			}
+		}
+		fmt.Fprintf(out, "  P%d heard %v, suspects %v\n", j, row, suspects)
+	}
+}
+
+// DemonstrateOmissionFaults viser hvordan send- og receive-omission ser forskellige ud
+// for en simpel heartbeat failure detector
+func DemonstrateOmissionFaults(out io.Writer) {
+	const numProcesses, rounds = 4, 20
+
+	fmt.Fprintf(out, "\n%d processes send heartbeats to each other for %d rounds. P0 is faulty.\n", numProcesses, rounds)
+
+	fmt.Fprintln(out, "\n=== Send omission (P0 drops 80% of its outgoing messages) ===")
+	printSuspicions(out, runHeartbeatWorkload(numProcesses, rounds, 0, OmissionFaults{Send: 0.8}), rounds)
+
+	fmt.Fprintln(out, "\n=== Receive omission (P0 drops 80% of its incoming messages) ===")
+	printSuspicions(out, runHeartbeatWorkload(numProcesses, rounds, 0, OmissionFaults{Receive: 0.8}), rounds)
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Send omission: everyone else suspects P0, while P0 suspects nobody")
+	fmt.Fprintln(out, "• Receive omission: P0 suspects everyone, while nobody suspects P0")
+	fmt.Fprintln(out, "• Uniform loss would make all processes suspect each other a little, hiding the culprit")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// To events som den online detector fandt concurrent
+type ConcurrentPair struct {
+	Earlier LoggedEvent // Eventet der allerede lå i vinduet
+	Later   LoggedEvent // Det nye event
+}
+
+// Print funktion
+func (c ConcurrentPair) String() string {
+	return fmt.Sprintf("%s#%d %s ∥ %s#%d %s",
+		c.Earlier.Label, c.Earlier.Index, c.Earlier.Clock, c.Later.Label, c.Later.Index, c.Later.Clock)
+}
+
+// ConcurrencyDetector finder concurrent par mens simulationen kører. Hvert nyt event
+// sammenlignes med de seneste Window events, så arbejdet per event er begrænset; par hvor
+// det ene event er faldet ud af vinduet bliver ikke fundet. Kræver vector clocks; events
+// med andre ure ignoreres.
+type ConcurrencyDetector struct {
+	Window       int
+	OnConcurrent func(pair ConcurrentPair) // Kaldes for hvert nyt par (nil = gem kun)
+	window       []LoggedEvent
+	found        []ConcurrentPair
+	mutex        sync.Mutex
+}
+
+// Opretter en detector med et vindue på window events
+func NewConcurrencyDetector(window int) *ConcurrencyDetector {
+	return &ConcurrencyDetector{Window: window}
+}
+
+// Tilmelder detectoren alle processers OnEvent hook
+func (d *ConcurrencyDetector) Attach(sim *Simulation) {
+	for _, p := range sim.Processes {
+		p.OnEvent = func(p *Process, event LoggedEvent) { d.Observe(event) }
+	}
+}
+
+// Sammenligner et nyt event med vinduet og returnerer de nye concurrent par
+func (d *ConcurrencyDetector) Observe(event LoggedEvent) []ConcurrentPair {
+	if !event.Clock.IsVector() {
+		return nil
+	}
+
+	d.mutex.Lock()
+	pairs := make([]ConcurrentPair, 0)
+	for _, earlier := range d.window {
+		if earlier.ProcessID == event.ProcessID {
+			continue
+		}
+		if compareJoined(earlier.Clock.vector, event.Clock.vector) == Concurrent {
+			pairs = append(pairs, ConcurrentPair{Earlier: earlier, Later: event})
+		}
+	}
+	d.window = append(d.window, event)
+	if len(d.window) > d.Window {
+		d.window = d.window[len(d.window)-d.Window:]
+	}
+	d.found = append(d.found, pairs...)
+	d.mutex.Unlock()
+
+	// Hooken kaldes uden lås, så den selv kan læse detectoren
+	if d.OnConcurrent != nil {
+		for _, pair := range pairs {
+			d.OnConcurrent(pair)
+		}
+	}
+	return pairs
+}
+
+// Alle par fundet indtil nu
+func (d *ConcurrencyDetector) Found() []ConcurrentPair {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]ConcurrentPair(nil), d.found...)
+}
+
+// DemonstrateOnlineConcurrency viser concurrent par efterhånden som de opstår, og hvad
+// vinduets størrelse koster i forhold til en fuld analyse efter kørslen
+func DemonstrateOnlineConcurrency(out io.Writer) {
+	fmt.Fprintln(out, "\n=== ONLINE CONCURRENCY DETECTION ===")
+	fmt.Fprintln(out, "Each new event is compared with the 6 events before it as it happens:")
+
+	sim := NewSimulation(4, true)
+	sim.Seed(3)
+	detector := NewConcurrencyDetector(6)
+	shown := 0
+	detector.OnConcurrent = func(pair ConcurrentPair) {
+		if shown < 8 {
+			fmt.Fprintf(out, "  ⚡ %s\n", pair)
+		}
+		shown++
+	}
+	// I stedet for Attach: gem også rækkefølgen, så vinduerne nedenfor ser de samme events
+	order := make([]LoggedEvent, 0)
+	for _, p := range sim.Processes {
+		p.OnEvent = func(p *Process, event LoggedEvent) {
+			order = append(order, event)
+			detector.Observe(event)
+		}
+	}
+	runConcurrencyWorkload(sim, 5, 0.6)
+	if shown > 8 {
+		fmt.Fprintf(out, "  ... and %d more\n", shown-8)
+	}
+
+	fmt.Fprintf(out, "\n%-8s | %-16s | %-10s\n", "Window", "Pairs flagged", "Coverage")
+	fmt.Fprintln(out, "---------|------------------|-----------")
+	total := CountConcurrentPairs(sim)
+	for _, window := range []int{2, 6, 16, 64} {
+		replay := NewConcurrencyDetector(window)
+		for _, e := range order {
+			replay.Observe(e)
+		}
+		flagged := len(replay.Found())
+		fmt.Fprintf(out, "%-8d | %-16s | %9.1f%%\n", window, fmt.Sprintf("%d / %d", flagged, total), float64(flagged)/float64(total)*100)
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Online detection flags concurrency the moment the second event happens, not after the run")
+	fmt.Fprintln(out, "• The window bounds the cost per event; pairs further apart than the window are missed")
+	fmt.Fprintln(out, "• Most missed pairs are far apart in time, which matters less for live conflict warnings")
+}
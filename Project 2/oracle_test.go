@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Et event i den genererede DAG
+type oracleEvent struct {
+	process int
+	index   int
+}
+
+// Kører den samme tilfældige workload på en Lamport og en vector simulation.
+// Hvert par af bytes i ops er én operation: (type/proces, target).
+func runOracleWorkload(numProcesses int, ops []byte) (*Simulation, *Simulation) {
+	lamportSim := NewSimulation(numProcesses, false)
+	vectorSim := NewSimulation(numProcesses, true)
+
+	for i := 0; i+1 < len(ops); i += 2 {
+		kind := int(ops[i]>>4) % 3
+		id := int(ops[i]) % numProcesses
+		target := int(ops[i+1]) % numProcesses
+
+		for _, sim := range []*Simulation{lamportSim, vectorSim} {
+			p := sim.Processes[id]
+			switch kind {
+			case 0:
+				p.HandleLocalEvent("local")
+			case 1:
+				t := sim.Processes[target]
+				if t != p && len(t.MessageQueue) < cap(t.MessageQueue) {
+					p.SendMessage(t, "msg")
+				}
+			case 2:
+				if len(p.MessageQueue) > 0 {
+					p.ReceiveMessage(<-p.MessageQueue)
+				}
+			}
+		}
+	}
+	return lamportSim, vectorSim
+}
+
+// Ground truth: reach[a][b] er sand hvis der er en sti a → b via proces-rækkefølge
+// og send → receive kanter (fundet med besked ID'erne)
+func happensBeforeClosure(sim *Simulation) ([]oracleEvent, [][]bool) {
+	events := make([]oracleEvent, 0)
+	node := make(map[oracleEvent]int)
+	sends := make(map[string]int)
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			e := oracleEvent{p.ID, i}
+			node[e] = len(events)
+			events = append(events, e)
+			if p.EventTypes[i] == "send" {
+				sends[p.EventMessageIDs[i]] = node[e]
+			}
+		}
+	}
+
+	successors := make([][]int, len(events))
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			from := node[oracleEvent{p.ID, i}]
+			if i+1 < len(p.EventLog) {
+				successors[from] = append(successors[from], node[oracleEvent{p.ID, i + 1}])
+			}
+			if p.EventTypes[i] == "receive" {
+				send := sends[p.EventMessageIDs[i]]
+				successors[send] = append(successors[send], from)
+			}
+		}
+	}
+
+	reach := make([][]bool, len(events))
+	for start := range events {
+		reach[start] = make([]bool, len(events))
+		stack := append([]int(nil), successors[start]...)
+		for len(stack) > 0 {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !reach[start][next] {
+				reach[start][next] = true
+				stack = append(stack, successors[next]...)
+			}
+		}
+	}
+	return events, reach
+}
+
+// Tjekker at vector clocks svarer præcis til reachability og at Lamport aldrig
+// ordner to causalt relaterede events forkert
+func checkClockOracle(t *testing.T, numProcesses int, ops []byte) {
+	t.Helper()
+	lamportSim, vectorSim := runOracleWorkload(numProcesses, ops)
+	events, reach := happensBeforeClosure(vectorSim)
+
+	for a, ea := range events {
+		for b, eb := range events {
+			if a == b {
+				continue
+			}
+			va := vectorSim.Processes[ea.process].EventVectors[ea.index]
+			vb := vectorSim.Processes[eb.process].EventVectors[eb.index]
+			expected := 0
+			if reach[a][b] {
+				expected = -1
+			} else if reach[b][a] {
+				expected = 1
+			}
+			if got := CompareVectors(va, vb); got != expected {
+				t.Fatalf("Vector %v vs %v: Compare gav %d, reachability siger %d", va, vb, got, expected)
+			}
+
+			ta := lamportSim.Processes[ea.process].EventTimestamps[ea.index]
+			tb := lamportSim.Processes[eb.process].EventTimestamps[eb.index]
+			if reach[a][b] && ta >= tb {
+				t.Fatalf("Lamport: P%d#%d → P%d#%d men T%d >= T%d", ea.process, ea.index, eb.process, eb.index, ta, tb)
+			}
+		}
+	}
+}
+
+// Tester clock oraklet med tilfældige workloads (fast seed)
+func TestClockOracle(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 50; run++ {
+		ops := make([]byte, 2*(10+rng.Intn(60)))
+		rng.Read(ops)
+		checkClockOracle(t, 2+rng.Intn(4), ops)
+	}
+}
+
+// Fuzz: go test -fuzz=FuzzClockOracle
+func FuzzClockOracle(f *testing.F) {
+	f.Add(uint8(3), []byte{0x10, 1, 0x21, 0, 0x01, 2, 0x12, 0, 0x20, 0, 0x22, 0})
+	f.Add(uint8(2), []byte{0x00, 0, 0x10, 1, 0x11, 0, 0x21, 0, 0x20, 0})
+	f.Fuzz(func(t *testing.T, numProcesses uint8, ops []byte) {
+		if len(ops) > 400 {
+			ops = ops[:400]
+		}
+		checkClockOracle(t, 2+int(numProcesses)%4, ops)
+	})
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Et event fra en trace hvor systemet kun skrev fysiske tider, ingen logiske ure
+type PhysicalTraceEvent struct {
+	Label     string
+	Index     int       // Position i processens log
+	Type      string    // "local", "send" eller "receive"
+	MessageID string    // Besked ID for send og receive events
+	Time      time.Time // Processens wall clock da eventet skete
+	Payload   string
+}
+
+// Skriver de samlede logs som syslog linjer med wall clock tiden som TIMESTAMP og uden
+// clock felter, som et system uden logiske ure ville logge. Kræver WallClock på alle processer.
+func ExportPhysicalTrace(w io.Writer, sim *Simulation) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range ConsolidatedEvents(sim) {
+		if e.WallTime.IsZero() {
+			return fmt.Errorf("%s event %d has no wall clock time", e.Label, e.Index)
+		}
+		messageID := ""
+		if e.MessageID != "" {
+			messageID = fmt.Sprintf(" msg_id=\"%s\"", syslogParam(e.MessageID))
+		}
+		fmt.Fprintf(bw, "<%d>1 %s %s dissy - %s [dissy@32473 process=\"%s\" event=\"%d\"%s] %s\n",
+			syslogPriority, e.WallTime.UTC().Format(time.RFC3339Nano), syslogHost(e.Label), e.Type,
+			syslogParam(e.Label), e.Index, messageID, e.Log)
+	}
+	return bw.Flush()
+}
+
+// Læser en syslog trace med fysiske tider (TIMESTAMP) eller en CEF trace med rt (ms siden
+// epoch). Clock felter i linjerne ignoreres. Events sorteres per proces efter deres index.
+func ReadPhysicalTrace(r io.Reader) ([]PhysicalTraceEvent, error) {
+	events := make([]PhysicalTraceEvent, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		var fields map[string]string
+		var event PhysicalTraceEvent
+		var index, timestamp string
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "<"):
+			fields = syslogFields(text)
+			header := strings.Fields(text[:max(0, strings.Index(text, " [dissy@"))])
+			if len(header) != 6 {
+				return nil, fmt.Errorf("line %d: malformed syslog header", line)
+			}
+			timestamp, event.Type = header[1], header[5]
+			event.Label, index, event.MessageID = fields["process"], fields["event"], fields["msg_id"]
+			if end := strings.Index(text, "] "); end >= 0 {
+				event.Payload = text[end+2:]
+			}
+		case strings.HasPrefix(text, "CEF:"):
+			fields = cefFields(text)
+			header := strings.SplitN(text, "|", 7)
+			if len(header) != 7 {
+				return nil, fmt.Errorf("line %d: malformed CEF header", line)
+			}
+			event.Type, event.Label, index, event.Payload = header[4], fields["dvchost"], fields["cnt"], fields["msg"]
+			if fields["cs2Label"] == "message_id" {
+				event.MessageID = fields["cs2"]
+			}
+			if ms, err := strconv.ParseInt(fields["rt"], 10, 64); err == nil {
+				timestamp = time.UnixMilli(ms).UTC().Format(time.RFC3339Nano)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown trace format", line)
+		}
+
+		var err error
+		if event.Index, err = strconv.Atoi(index); err != nil || event.Label == "" {
+			return nil, fmt.Errorf("line %d: missing process or event index", line)
+		}
+		if event.Time, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return nil, fmt.Errorf("line %d: no physical timestamp", line)
+		}
+		switch event.Type {
+		case "local":
+		case "send", "receive":
+			if event.MessageID == "" {
+				return nil, fmt.Errorf("line %d: %s without a message id", line, event.Type)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown event type %q", line, event.Type)
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Label != events[j].Label {
+			return events[i].Label < events[j].Label
+		}
+		return events[i].Index < events[j].Index
+	})
+	return events, scanner.Err()
+}
+
+// Afspiller traceen med clockType: hver proces' events sker i rækkefølge, og et receive
+// venter på sit send, så ellers er den fysiske tid bestemmende for hvilket event der
+// sker næst. Processernes WallClock (og HLC's fysiske ur) står på eventets tid.
+func ReplayPhysicalTrace(events []PhysicalTraceEvent, clockType string) (*Simulation, error) {
+	labels := make([]string, 0)
+	perProcess := make(map[string][]PhysicalTraceEvent)
+	sends := make(map[string]string)     // Besked ID → afsenderens label
+	receivers := make(map[string]string) // Besked ID → modtagerens label
+	for _, e := range events {
+		if perProcess[e.Label] == nil {
+			labels = append(labels, e.Label)
+		}
+		perProcess[e.Label] = append(perProcess[e.Label], e)
+		if e.Type == "send" {
+			if _, duplicate := sends[e.MessageID]; duplicate {
+				return nil, fmt.Errorf("message %s is sent twice", e.MessageID)
+			}
+			sends[e.MessageID] = e.Label
+		} else if e.Type == "receive" {
+			receivers[e.MessageID] = e.Label
+		}
+	}
+	for _, e := range events {
+		if _, sent := sends[e.MessageID]; e.Type == "receive" && !sent {
+			return nil, fmt.Errorf("%s receives %s, which is never sent", e.Label, e.MessageID)
+		}
+	}
+
+	sim := newSimulationOfType(len(labels), clockType)
+	sim.Out = io.Discard
+	now := make([]time.Time, len(labels))
+	for i, p := range sim.Processes {
+		i := i
+		p.Name = labels[i]
+		p.WallClock = func() time.Time { return now[i] }
+		if hlc, ok := p.Clock.(*HybridLogicalClock); ok {
+			hlc.Physical = p.WallClock
+		}
+	}
+
+	next := make([]int, len(labels))
+	inFlight := make(map[string]Event) // Sendte beskeder efter trace ID
+	for done := 0; done < len(events); done++ {
+		// Det tidligste næste event der kan ske (et receive først når dets send er sket)
+		pick := -1
+		for i, label := range labels {
+			if next[i] == len(perProcess[label]) {
+				continue
+			}
+			e := perProcess[label][next[i]]
+			if _, sent := inFlight[e.MessageID]; e.Type == "receive" && !sent {
+				continue
+			}
+			if pick < 0 || e.Time.Before(perProcess[labels[pick]][next[pick]].Time) {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			return nil, fmt.Errorf("trace is not causally consistent: every process waits for a message")
+		}
+
+		e, p := perProcess[labels[pick]][next[pick]], sim.Processes[pick]
+		next[pick]++
+		now[pick] = e.Time
+		switch e.Type {
+		case "local":
+			p.HandleLocalEvent(e.Payload)
+		case "send":
+			to, received := receivers[e.MessageID]
+			if !received {
+				to = "(never received)"
+			}
+			header, id := p.recordSendEvent(to, e.Payload)
+			inFlight[e.MessageID] = Event{Type: "receive", ProcessID: p.ID, Message: header + "|" + e.Payload,
+				SenderName: p.Label(), MessageID: id}
+		case "receive":
+			if err := p.ReceiveMessage(inFlight[e.MessageID]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sim, nil
+}
+
+// Hvordan én måde at ordne events på passer med causaliteten i en trace
+type TraceOrdering struct {
+	Clock      string
+	Inverted   int // Causal par ordnet omvendt
+	Tied       int // Causal par med samme værdi, så rækkefølgen ikke kan ses
+	Concurrent int // Concurrent par der alligevel får en rækkefølge
+}
+
+// Sammenligner fysisk tid, Lamport og HLC med den causale rækkefølge fra vector clocks.
+// Alle simulationerne skal være afspillet fra den samme trace.
+func CompareTraceOrderings(vector *Simulation, others map[string]*Simulation) []TraceOrdering {
+	events := func(sim *Simulation) []LoggedEvent {
+		all := make([]LoggedEvent, 0)
+		for _, p := range sim.Processes {
+			for i := range p.EventLog {
+				all = append(all, p.loggedEvent(i))
+			}
+		}
+		return all
+	}
+	causal := events(vector)
+	count := func(name string, compare func(a int, b int) int) TraceOrdering {
+		o := TraceOrdering{Clock: name}
+		for a := range causal {
+			for b := a + 1; b < len(causal); b++ {
+				relation, order := Compare(causal[a].Clock.Vector(), causal[b].Clock.Vector()), compare(a, b)
+				switch {
+				case relation == Concurrent && order != 0:
+					o.Concurrent++
+				case relation == Concurrent:
+				case order == 0:
+					o.Tied++
+				case (relation == Before) != (order < 0):
+					o.Inverted++
+				}
+			}
+		}
+		return o
+	}
+
+	orderings := []TraceOrdering{count("Physical", func(a int, b int) int {
+		return causal[a].WallTime.Compare(causal[b].WallTime)
+	})}
+	for _, clockType := range clockTypes {
+		if sim, ok := others[clockType]; ok {
+			stamped := events(sim)
+			orderings = append(orderings, count(clockType, func(a int, b int) int {
+				return stamped[a].Clock.Time() - stamped[b].Clock.Time()
+			}))
+		}
+	}
+	return append(orderings, count("Vector", func(a int, b int) int {
+		switch Compare(causal[a].Clock.Vector(), causal[b].Clock.Vector()) {
+		case Before:
+			return -1
+		case After:
+			return 1
+		}
+		return 0
+	}))
+}
+
+// "retime <trace fil>": afspiller en trace med kun fysiske tider med Lamport,
+// vector og HLC og viser hvor mange causale par den fysiske tid ordnede forkert
+func retimeCommand(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: retime <trace file>")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	events, err := ReadPhysicalTrace(file)
+	if err != nil {
+		return err
+	}
+
+	sims := make(map[string]*Simulation)
+	for _, clockType := range clockTypes {
+		if sims[clockType], err = ReplayPhysicalTrace(events, clockType); err != nil {
+			return err
+		}
+	}
+	anomalies, causal, err := WallClockAnomalies(sims["Vector"])
+	if err != nil {
+		return err
+	}
+	vector := sims["Vector"]
+	delete(sims, "Vector")
+	orderings := CompareTraceOrderings(vector, sims)
+
+	fmt.Fprintf(out, "%d events on %d processes, %d causally related pairs\n\n", len(events), len(vector.Processes), causal)
+	fmt.Fprintf(out, "%-10s | %-10s | %-10s | %s\n", "Order by", "Inverted", "Tied", "Concurrent pairs ordered")
+	fmt.Fprintln(out, "-----------|------------|------------|-------------------------")
+	for _, o := range orderings {
+		fmt.Fprintf(out, "%-10s | %-10d | %-10d | %d\n", o.Clock, o.Inverted, o.Tied, o.Concurrent)
+	}
+	if len(anomalies) > 0 {
+		fmt.Fprintln(out, "\nLargest inversions of physical time:")
+		for _, a := range anomalies[:min(5, len(anomalies))] {
+			fmt.Fprintf(out, "  %s #%d %s is stamped %v before its cause %s #%d %s\n", a.Effect.Label, a.Effect.Index,
+				a.Effect.Type, a.Inversion(), a.Cause.Label, a.Cause.Index, a.Cause.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PlausibleClock er et R-entries vector clock (Torres-Rojas og Ahamad): vectoren har R
+// entries uanset antal processer, og proces i tæller i entry i mod R. Causale par
+// ordnes altid rigtigt, men processer der deler en entry kan få concurrent events
+// til at se ordnede ud. Med R = n er det et almindeligt vector clock.
+type PlausibleClock struct {
+	vector    []int
+	entry     int // Den entry processen tæller i
+	processID int
+	mutex     sync.Mutex
+}
+
+// Opretter et plausible clock med r entries for proces processID
+func NewPlausibleClock(r int, processID int) *PlausibleClock {
+	return &PlausibleClock{vector: make([]int, r), entry: processID % r, processID: processID}
+}
+
+// Lokal operation
+func (pc *PlausibleClock) LocalEvent() []int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	pc.vector[pc.entry]++
+	return copyVector(pc.vector)
+}
+
+// Send event, samme som LocalEvent
+func (pc *PlausibleClock) SendEvent() []int {
+	return pc.LocalEvent()
+}
+
+// Merge den modtagne vector og increment egen entry
+func (pc *PlausibleClock) ReceiveEvent(receivedVector []int) []int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	for i := range pc.vector {
+		if receivedVector[i] > pc.vector[i] {
+			pc.vector[i] = receivedVector[i]
+		}
+	}
+	pc.vector[pc.entry]++
+	return copyVector(pc.vector)
+}
+
+// Retuner et snapshot af aktuel vector
+func (pc *PlausibleClock) GetVector() ClockSnapshot {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	return ClockSnapshot{vector: copyVector(pc.vector)}
+}
+
+// Printer hvordan ordering præcisionen falder når R bliver mindre end antal processer
+func MeasurePlausibleClocks(out io.Writer, numProcesses int, concurrencyLevel float64, rs []int) {
+	fmt.Fprintln(out, "\nPlausible clocks (R entries, process i uses entry i mod R):")
+	fmt.Fprintf(out, "%-6s | %-15s | %-18s | %-10s\n", "R", "Header saved", "Wrong relations", "Error rate")
+	fmt.Fprintln(out, "-------|-----------------|--------------------|-----------")
+
+	for _, r := range rs {
+		errors, pairs := measureApproximationErrors(numProcesses, concurrencyLevel, 50, 1, func(id int) LogicalClock {
+			return NewPlausibleClock(r, id)
+		})
+		rate := 0.0
+		if pairs > 0 {
+			rate = float64(errors) / float64(pairs) * 100
+		}
+		saved := float64(numProcesses-r) / float64(numProcesses) * 100
+		fmt.Fprintf(out, "%-6d | %14.0f%% | %-18d | %9.2f%%\n", r, saved, errors, rate)
+	}
+}
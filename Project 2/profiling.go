@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Et contention punkt aggregeret fra mutex eller block profilen
+type ContentionPoint struct {
+	Kind      string // "mutex" eller "block"
+	Location  string // Første funktion i vores egen kode (fx main.(*LamportClock).LocalEvent)
+	Primitive string // Hvad der blev ventet på (fx sync.(*Mutex).Unlock, runtime.chansend1)
+	Count     int64  // Antal gange der blev ventet
+	Cycles    int64  // Samlet ventetid i CPU cycles
+}
+
+// Slår mutex og block profiling til og returnerer en funktion der slår det fra igen
+func EnableContentionProfiling() func() {
+	previousFraction := runtime.SetMutexProfileFraction(1)
+	runtime.SetBlockProfileRate(1)
+	return func() {
+		runtime.SetMutexProfileFraction(previousFraction)
+		runtime.SetBlockProfileRate(0)
+	}
+}
+
+// Læser en profil med runtime.MutexProfile eller runtime.BlockProfile
+func readProfile(read func([]runtime.BlockProfileRecord) (int, bool)) []runtime.BlockProfileRecord {
+	n, _ := read(nil)
+	for {
+		records := make([]runtime.BlockProfileRecord, n+50)
+		count, ok := read(records)
+		if ok {
+			return records[:count]
+		}
+		n = count
+	}
+}
+
+// Præfikset på funktionsnavne i vores egen kode: "main." i programmet, men pakkens import
+// sti når den er bygget til go test
+var ownFunctionPrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name() // fx "main.init.func1"
+	slash := strings.LastIndex(name, "/") + 1
+	return name[:slash+strings.Index(name[slash:], ".")+1]
+}()
+
+// Finder primitiven og det første sted i vores egen kode i en stack
+func describeStack(stack []uintptr) (string, string) {
+	frames := runtime.CallersFrames(stack)
+	primitive := ""
+	location := "(unknown)"
+	for {
+		frame, more := frames.Next()
+		if primitive == "" {
+			primitive = frame.Function
+		}
+		if strings.HasPrefix(frame.Function, ownFunctionPrefix) {
+			location = frame.Function
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	return primitive, location
+}
+
+// Aggregerer records efter (primitive, location)
+func aggregateContention(kind string, records []runtime.BlockProfileRecord) []ContentionPoint {
+	byKey := make(map[string]*ContentionPoint)
+	order := make([]string, 0)
+
+	for _, r := range records {
+		primitive, location := describeStack(r.Stack())
+		key := primitive + "@" + location
+		point, ok := byKey[key]
+		if !ok {
+			point = &ContentionPoint{Kind: kind, Location: location, Primitive: primitive}
+			byKey[key] = point
+			order = append(order, key)
+		}
+		point.Count += r.Count
+		point.Cycles += r.Cycles
+	}
+
+	points := make([]ContentionPoint, 0, len(order))
+	for _, key := range order {
+		points = append(points, *byKey[key])
+	}
+	return points
+}
+
+// Samler contention punkter fra både mutex og block profilen, sorteret efter ventetid
+func CollectContention() []ContentionPoint {
+	points := aggregateContention("mutex", readProfile(runtime.MutexProfile))
+	points = append(points, aggregateContention("block", readProfile(runtime.BlockProfile))...)
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Cycles > points[j].Cycles
+	})
+	return points
+}
+
+// Printer de top n contention punkter med andel af den samlede ventetid
+func PrintContentionReport(out io.Writer, top int) {
+	points := CollectContention()
+
+	fmt.Fprintln(out, "\n=== CONTENTION REPORT ===")
+	if len(points) == 0 {
+		fmt.Fprintln(out, "No contention recorded")
+		return
+	}
+
+	var totalCycles int64
+	for _, p := range points {
+		totalCycles += p.Cycles
+	}
+
+	fmt.Fprintf(out, "%-6s | %-45s | %-28s | %-10s | %-8s\n",
+		"Kind", "Location", "Waiting on", "Count", "Share")
+	fmt.Fprintln(out, "-------|-----------------------------------------------|------------------------------|------------|---------")
+
+	if top > len(points) {
+		top = len(points)
+	}
+	for _, p := range points[:top] {
+		share := 0.0
+		if totalCycles > 0 {
+			share = float64(p.Cycles) / float64(totalCycles) * 100
+		}
+		fmt.Fprintf(out, "%-6s | %-45s | %-28s | %-10d | %6.1f%%\n",
+			p.Kind, p.Location, p.Primitive, p.Count, share)
+	}
+}
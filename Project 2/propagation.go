@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Hvornår og efter hvor mange beskeder én proces fik kendskab til P1's opdatering
+type KnowledgeArrival struct {
+	Topology string
+	Fanout   int
+	Process  int
+	Hops     int           // Beskeder i kæden fra P1 (-1 hvis processen aldrig fik den)
+	Time     time.Duration // Simuleret tid fra opdateringen (-1 hvis aldrig)
+}
+
+// Processen der laver opdateringen i målingen
+const knowledgeOrigin = 1
+
+// Modtagerne for proces i i en gossip runde med fanout modtagere
+func gossipTargets(rng *rand.Rand, topology string, i int, round int, numProcesses int, fanout int) []int {
+	targets := make([]int, 0, fanout)
+	switch topology {
+	case "ring":
+		for f := 0; f < fanout && f < numProcesses-1; f++ {
+			targets = append(targets, (i+1+f)%numProcesses)
+		}
+	case "star":
+		// Bladene sender kun til P0; P0 sender til fanout blade round-robin
+		if i != 0 {
+			return []int{0}
+		}
+		for f := 0; f < fanout && f < numProcesses-1; f++ {
+			targets = append(targets, 1+(round*fanout+f)%(numProcesses-1))
+		}
+	default:
+		for _, j := range rng.Perm(numProcesses) {
+			if j != i && len(targets) < fanout {
+				targets = append(targets, j)
+			}
+		}
+	}
+	return targets
+}
+
+// P1 laver en opdatering til tiden 0, og alle processer gossiper deres vector hver 10. ms.
+// En proces kender opdateringen når dens entry for P1 når opdateringens counter. P1 er et
+// blad i stjernen, så opdateringen skal via P0.
+func measureKnowledgePropagation(topology string, fanout int, numProcesses int, rounds int, seed int64) []KnowledgeArrival {
+	sim := NewSimulation(numProcesses, true)
+	s := NewScheduler(sim, seed)
+	s.FIFO = true // Opdateringen sker før P1's første gossip
+
+	arrivals := make([]KnowledgeArrival, numProcesses)
+	firstIndex := make([]int, numProcesses) // Første event i processens log der kender opdateringen
+	for _, p := range sim.Processes {
+		arrivals[p.ID] = KnowledgeArrival{Topology: topology, Fanout: fanout, Process: p.ID, Hops: -1, Time: -1}
+		p.OnEvent = func(p *Process, event LoggedEvent) {
+			if arrivals[p.ID].Time < 0 && event.Clock.At(knowledgeOrigin) >= 1 {
+				arrivals[p.ID].Time, firstIndex[p.ID] = s.Now, event.Index
+			}
+		}
+	}
+
+	s.Local(0, sim.Processes[knowledgeOrigin], "update")
+	for r := 0; r < rounds; r++ {
+		at := time.Duration(r) * 10 * time.Millisecond
+		for _, p := range sim.Processes {
+			for _, target := range gossipTargets(s.Rand, topology, p.ID, r, numProcesses, fanout) {
+				s.Send(at, p, sim.Processes[target], "gossip")
+			}
+		}
+	}
+	s.Run()
+
+	// Hops: én mere end afsenderen havde, for den besked der først bragte opdateringen
+	sendOf := make(map[[2]int]LoggedEvent)
+	for _, edge := range MessageEdges(sim) {
+		sendOf[[2]int{edge.Receive.ProcessID, edge.Receive.Index}] = edge.Send
+	}
+	var hops func(id int) int
+	hops = func(id int) int {
+		switch {
+		case id == knowledgeOrigin:
+			return 0
+		case arrivals[id].Time < 0:
+			return -1
+		case arrivals[id].Hops < 0:
+			arrivals[id].Hops = hops(sendOf[[2]int{id, firstIndex[id]}].ProcessID) + 1
+		}
+		return arrivals[id].Hops
+	}
+	for id := range arrivals {
+		arrivals[id].Hops = hops(id)
+	}
+	return arrivals
+}
+
+// Skriver målingerne som CSV: topology,fanout,process,hops,time_ms (-1 = nåede aldrig frem)
+func WriteKnowledgePropagationCSV(w io.Writer, arrivals []KnowledgeArrival) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"topology", "fanout", "process", "hops", "time_ms"})
+	for _, a := range arrivals {
+		timeMs := "-1"
+		if a.Time >= 0 {
+			timeMs = strconv.FormatInt(a.Time.Milliseconds(), 10)
+		}
+		writer.Write([]string{a.Topology, strconv.Itoa(a.Fanout), strconv.Itoa(a.Process), strconv.Itoa(a.Hops), timeMs})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Måler hvor hurtigt én proces' event bliver kendt af alle andre i forskellige
+// topologier og gossip fan-outs
+func MeasureKnowledgePropagation(out io.Writer, numProcesses int, csvOut io.Writer) {
+	fmt.Fprintln(out, "\n=== KNOWLEDGE PROPAGATION ===")
+	fmt.Fprintf(out, "P1 makes an update at t=0; %d processes gossip their vector every 10ms for 20 rounds\n\n", numProcesses)
+	fmt.Fprintf(out, "%-8s | %-7s | %-8s | %-9s | %-9s | %-13s\n", "Topology", "Fan-out", "Reached", "Mean hops", "Max hops", "Time to all")
+	fmt.Fprintln(out, "---------|---------|----------|-----------|-----------|--------------")
+
+	all := make([]KnowledgeArrival, 0)
+	for _, topology := range []string{"ring", "star", "random"} {
+		for _, fanout := range []int{1, 2, 4} {
+			arrivals := measureKnowledgePropagation(topology, fanout, numProcesses, 20, 1)
+			all = append(all, arrivals...)
+
+			reached, totalHops, maxHops, slowest := 0, 0, 0, time.Duration(0)
+			for _, a := range arrivals {
+				if a.Process == knowledgeOrigin || a.Time < 0 {
+					continue
+				}
+				reached++
+				totalHops += a.Hops
+				maxHops = max(maxHops, a.Hops)
+				slowest = max(slowest, a.Time)
+			}
+			toAll, meanHops := "never", 0.0
+			if reached == numProcesses-1 {
+				toAll = slowest.String()
+			}
+			if reached > 0 {
+				meanHops = float64(totalHops) / float64(reached)
+			}
+			fmt.Fprintf(out, "%-8s | %-7d | %-8s | %-9.2f | %-9d | %-13s\n", topology, fanout,
+				fmt.Sprintf("%d/%d", reached, numProcesses-1), meanHops, maxHops, toAll)
+		}
+	}
+
+	if csvOut != nil {
+		if err := WriteKnowledgePropagationCSV(csvOut, all); err != nil {
+			fmt.Fprintln(out, "Could not write propagation CSV:", err)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• A ring with fan-out f needs about n/f hops: knowledge moves one neighbourhood per round")
+	fmt.Fprintln(out, "• A star needs only 2 hops via the hub, but the hub's fan-out decides how long the leaves wait")
+	fmt.Fprintln(out, "• Random gossip reaches everyone in O(log n) rounds, and a larger fan-out shortens it further")
+}
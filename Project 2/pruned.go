@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// Marker for en entry der er blevet pruned væk
+const UnknownEntry = -1
+
+// PrunedVectorClock gemmer kun de K senest opdaterede entries (approksimativ causality).
+// Entries der er 0 koster ingen plads (sparse) og er altid præcise, så kun entries
+// større end 0 tæller med i K.
+type PrunedVectorClock struct {
+	vector    []int // UnknownEntry for entries der er pruned
+	updatedAt []int // Hvornår hver entry sidst blev opdateret (lokal tæller)
+	tick      int   // Lokal tæller der bruges til at finde de senest opdaterede entries
+	k         int   // Max antal kendte entries
+	processID int
+	mutex     sync.Mutex
+}
+
+// Opretter et nyt pruned Vector clock der højst kender k entries
+func NewPrunedVectorClock(numProcesses int, processID int, k int) *PrunedVectorClock {
+	return &PrunedVectorClock{
+		vector:    make([]int, numProcesses),
+		updatedAt: make([]int, numProcesses),
+		k:         k,
+		processID: processID,
+	}
+}
+
+// Markér en entry som netop opdateret
+func (pc *PrunedVectorClock) touch(i int) {
+	pc.tick++
+	pc.updatedAt[i] = pc.tick
+}
+
+// Fjerner de ældste entries indtil der højst er k gemte (egen entry beholdes altid)
+func (pc *PrunedVectorClock) prune() {
+	for {
+		known := 0
+		oldest := -1
+		for i, v := range pc.vector {
+			if v == UnknownEntry || v == 0 {
+				continue
+			}
+			known++
+			if i != pc.processID && (oldest == -1 || pc.updatedAt[i] < pc.updatedAt[oldest]) {
+				oldest = i
+			}
+		}
+		if known <= pc.k || oldest == -1 {
+			return
+		}
+		pc.vector[oldest] = UnknownEntry
+	}
+}
+
+// Lokal operation eller send: increment egen entry
+func (pc *PrunedVectorClock) LocalEvent() []int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	pc.vector[pc.processID]++
+	pc.touch(pc.processID)
+	return copyVector(pc.vector)
+}
+
+// Send event, samme som LocalEvent
+func (pc *PrunedVectorClock) SendEvent() []int {
+	return pc.LocalEvent()
+}
+
+// Merge kendte entries fra den modtagne vector, increment egen og prune. En modtaget 0
+// siger intet nyt om en pruned entry, så den forbliver UnknownEntry; kun entries der
+// faktisk stiger tæller som opdateret.
+func (pc *PrunedVectorClock) ReceiveEvent(receivedVector []int) []int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	for i := 0; i < len(pc.vector); i++ {
+		if receivedVector[i] > max(pc.vector[i], 0) {
+			pc.vector[i] = receivedVector[i]
+			pc.touch(i)
+		}
+	}
+
+	pc.vector[pc.processID]++
+	pc.touch(pc.processID)
+	pc.prune()
+	return copyVector(pc.vector)
+}
+
+// Retuner et snapshot af aktuel vector (pruned entries er UnknownEntry)
+func (pc *PrunedVectorClock) GetVector() ClockSnapshot {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	return ClockSnapshot{vector: copyVector(pc.vector)}
+}
+
+// Sammenlign pruned vectors ved kun at se på entries der er kendt i begge.
+// Returnerer det samme som CompareVectors: -1, 1 eller 0 (concurrent/ukendt).
+func ComparePrunedVectors(v1, v2 []int) int {
+	if len(v1) != len(v2) {
+		panic("Vector clocks skal have samme længde!")
+	}
+
+	lessOrEqual := true
+	greaterOrEqual := true
+	compared := 0
+
+	for i := 0; i < len(v1); i++ {
+		if v1[i] == UnknownEntry || v2[i] == UnknownEntry {
+			continue
+		}
+		compared++
+		if v1[i] > v2[i] {
+			lessOrEqual = false
+		}
+		if v1[i] < v2[i] {
+			greaterOrEqual = false
+		}
+	}
+
+	if compared == 0 {
+		return 0
+	}
+	if lessOrEqual && !greaterOrEqual {
+		return -1
+	}
+	if greaterOrEqual && !lessOrEqual {
+		return 1
+	}
+	return 0
+}
+
+// Kører samme tilfældige workload (fra seed) med præcise og pruned vector clocks side om
+// side og returnerer antal event-par hvor den pruned relation er forkert, samt antal par
+func measurePruningErrors(numProcesses int, k int, concurrencyLevel float64, rounds int, seed int64) (int, int) {
+	rng := rand.New(rand.NewSource(seed))
+	exact := make([]*VectorClock, numProcesses)
+	pruned := make([]*PrunedVectorClock, numProcesses)
+	for i := 0; i < numProcesses; i++ {
+		exact[i] = NewVectorClock(numProcesses, i)
+		pruned[i] = NewPrunedVectorClock(numProcesses, i, k)
+	}
+
+	var exactStamps, prunedStamps [][]int
+	record := func(e, p []int) {
+		exactStamps = append(exactStamps, e)
+		prunedStamps = append(prunedStamps, p)
+	}
+
+	for r := 0; r < rounds; r++ {
+		for i := 0; i < numProcesses; i++ {
+			if rng.Float64() < concurrencyLevel {
+				record(exact[i].LocalEvent(), pruned[i].LocalEvent())
+				continue
+			}
+
+			target := rng.Intn(numProcesses)
+			if target == i {
+				continue
+			}
+			sentExact, sentPruned := exact[i].SendEvent(), pruned[i].SendEvent()
+			record(sentExact, sentPruned)
+			record(exact[target].ReceiveEvent(sentExact), pruned[target].ReceiveEvent(sentPruned))
+		}
+	}
+
+	errors, pairs := 0, 0
+	for i := 0; i < len(exactStamps); i++ {
+		for j := i + 1; j < len(exactStamps); j++ {
+			pairs++
+			if CompareVectors(exactStamps[i], exactStamps[j]) != ComparePrunedVectors(prunedStamps[i], prunedStamps[j]) {
+				errors++
+			}
+		}
+	}
+	return errors, pairs
+}
+
+// Printer hvor mange relationer pruned clocks tager fejl af for forskellige K
+func MeasurePruningErrors(out io.Writer, numProcesses int, concurrencyLevel float64, ks []int) {
+	fmt.Fprintln(out, "\nApproximate vector clocks (K most recently updated entries):")
+	fmt.Fprintf(out, "%-6s | %-18s | %-10s\n", "K", "Wrong relations", "Error rate")
+	fmt.Fprintln(out, "-------|--------------------|-----------")
+
+	for _, k := range ks {
+		errors, pairs := measurePruningErrors(numProcesses, k, concurrencyLevel, 50, 1)
+		rate := 0.0
+		if pairs > 0 {
+			rate = float64(errors) / float64(pairs) * 100
+		}
+		fmt.Fprintf(out, "%-6d | %-18d | %9.2f%%\n", k, errors, rate)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Fejl fra SendMessageSync og ReceiveMessage, så protokol kode kan skelne mellem dem ved retries
+var (
+	ErrQueueFull      = errors.New("target queue is full")
+	ErrMessageDropped = errors.New("message dropped before delivery")
+	ErrMalformedClock = errors.New("malformed clock header")
+	ErrProcessCrashed = errors.New("process has crashed")
+)
+
+// Kvittering for en leveret besked
+type DeliveryReceipt struct {
+	Target      string
+	SentAt      time.Time
+	DeliveredAt time.Time
+	Clock       ClockSnapshot // Modtagerens clock lige efter receive
+}
+
+// Hvor lang tid beskeden var undervejs (inkl. kø og processing delay)
+func (r DeliveryReceipt) Latency() time.Duration {
+	return r.DeliveredAt.Sub(r.SentAt)
+}
+
+// Svar fra modtageren på en synkron besked
+type deliveryResult struct {
+	receipt DeliveryReceipt
+	err     error
+}
+
+// Giver afsenderen besked om udfaldet. Kanalen har plads til ét svar, så modtageren
+// blokerer aldrig, heller ikke hvis afsenderen er holdt op med at vente.
+func (e Event) acknowledge(receipt DeliveryReceipt, err error) {
+	if e.receipt != nil {
+		e.receipt <- deliveryResult{receipt, err}
+	}
+}
+
+// Sender en besked og venter på at modtageren har leveret den. Returnerer ErrQueueFull
+// hvis target's kø er fuld (send eventet er logget, men beskeden kom aldrig i køen),
+// ErrMessageDropped hvis modtageren droppede den, ErrProcessCrashed hvis afsenderen eller
+// modtageren er crashet, fejlen fra afsenderens middleware hvis
+// kæden afviste beskeden, eller ctx.Err() hvis ctx udløber før
+// svaret kommer. I det sidste tilfælde kan beskeden stadig blive leveret senere, eller
+// være tabt ved en send omission, som afsenderen ikke kan se.
+func (p *Process) SendMessageSync(ctx context.Context, target *Process, message string) (DeliveryReceipt, error) {
+	if err := ctx.Err(); err != nil {
+		return DeliveryReceipt{}, err
+	}
+	if p.Crashed() {
+		return DeliveryReceipt{}, fmt.Errorf("send to %s: %w", target.Label(), ErrProcessCrashed)
+	}
+
+	header, id := p.recordSendEvent(target.Label(), message)
+	env := &Envelope{From: p, To: target, MessageID: id, Header: header, Payload: message}
+	if err := p.sendThrough(env); err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("send to %s: %w", target.Label(), err)
+	}
+	event := Event{
+		Type:       "receive",
+		ProcessID:  p.ID,
+		Message:    env.Header + "|" + env.Payload,
+		SenderName: p.Label(),
+		SentAt:     time.Now(),
+		MessageID:  id,
+		receipt:    make(chan deliveryResult, 1),
+	}
+
+	if p.Omission.omitSend(p.faultRand) {
+		// Afsenderen ved ikke at beskeden aldrig kom afsted, så den venter til ctx udløber
+		p.Counters.recordSend(len(header), len(message))
+		p.omitted.send.Add(1)
+	} else {
+		target.inFlight.Add(1)
+		select {
+		case target.MessageQueue <- event:
+			p.Counters.recordSend(len(header), len(message))
+		default:
+			target.inFlight.Add(-1)
+			return DeliveryReceipt{}, fmt.Errorf("send to %s: %w", target.Label(), ErrQueueFull)
+		}
+	}
+
+	select {
+	case result := <-event.receipt:
+		if result.err != nil {
+			return DeliveryReceipt{}, fmt.Errorf("send to %s: %w", target.Label(), result.err)
+		}
+		return result.receipt, nil
+	case <-ctx.Done():
+		return DeliveryReceipt{}, fmt.Errorf("send to %s: %w", target.Label(), ctx.Err())
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Versionen af optagelsesformatet
+const RecordingFormat = "dissy-recording/1"
+
+// Et event som scheduleren udførte det. Leveringer peger på beskeden med dens ID, så en
+// replay leverer præcis de samme beskeder i samme rækkefølge, også dubletter.
+type RecordedEvent struct {
+	AtNs      int64  `json:"at_ns"`                // Simuleret tid fra start
+	Kind      string `json:"kind"`                 // "local", "send", "deliver", "crash" eller "recover"
+	Process   int    `json:"process"`              // Processen eventet sker på (modtageren for deliver)
+	To        *int   `json:"to,omitempty"`         // Modtageren for send events
+	MessageID string `json:"message_id,omitempty"` // Beskeden der leveres (og for sends fra en live optagelse, den sendte)
+	Message   string `json:"message,omitempty"`
+}
+
+// En optaget kørsel: alle events scheduleren udførte i den rækkefølge de skete, med
+// payloads og leveringsrækkefølge. Latency, faults og seedets tiebreaks er allerede
+// afgjort i den, så en replay ikke bruger RNG'en. Interleaving er et fingerprint af
+// processernes eventsekvenser uden clock værdier, så en replay med et andet ur kan
+// vise at den fik samme interleaving.
+type Recording struct {
+	Format       string          `json:"format"`
+	Processes    int             `json:"processes"`
+	Names        []string        `json:"names,omitempty"`
+	Clock        string          `json:"clock"` // Urtypen i den optagne kørsel
+	Seed         int64           `json:"seed"`
+	PersistClock bool            `json:"persist_clock,omitempty"`
+	Start        time.Time       `json:"start"` // Simuleret starttid (for HLC)
+	Interleaving string          `json:"interleaving"`
+	Events       []RecordedEvent `json:"events"`
+}
+
+// Udfylder resten af optagelsen efter kørslen af sim
+func (t *Recording) finish(sim *Simulation, clockType string, seed int64) {
+	t.Format, t.Processes, t.Clock, t.Seed = RecordingFormat, len(sim.Processes), clockType, seed
+	t.Names = nil
+	names := make([]string, len(sim.Processes))
+	for i, p := range sim.Processes {
+		if names[i] = p.Name; p.Name != "" {
+			t.Names = names
+		}
+		t.PersistClock = t.PersistClock || p.PersistClock
+	}
+	t.Interleaving = InterleavingFingerprint(sim)
+}
+
+// Tjekker at optagelsen kan afspilles
+func (t *Recording) Validate() error {
+	if t.Format != RecordingFormat {
+		return fmt.Errorf("unsupported recording format %q (expected %s)", t.Format, RecordingFormat)
+	}
+	if t.Processes < 1 || len(t.Names) > t.Processes {
+		return fmt.Errorf("recording has %d processes and %d names", t.Processes, len(t.Names))
+	}
+	last := int64(0)
+	for i, e := range t.Events {
+		if e.Process < 0 || e.Process >= t.Processes {
+			return fmt.Errorf("event %d: process %d out of range", i, e.Process)
+		}
+		if e.AtNs < last {
+			return fmt.Errorf("event %d: time goes backwards", i)
+		}
+		last = e.AtNs
+		switch e.Kind {
+		case "local", "crash", "recover":
+		case "send":
+			if e.To == nil || *e.To < 0 || *e.To >= t.Processes {
+				return fmt.Errorf("event %d: send needs a target", i)
+			}
+		case "deliver":
+			if e.MessageID == "" {
+				return fmt.Errorf("event %d: deliver needs a message_id", i)
+			}
+		default:
+			return fmt.Errorf("event %d: unknown kind %q", i, e.Kind)
+		}
+	}
+	return nil
+}
+
+// Afspiller optagelsen med clockType (en af clockTypes). Events udføres i præcis den optagne
+// rækkefølge og til de optagne tider; kun urene er nye.
+func (t *Recording) Replay(clockType string) (*Simulation, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if !containsString(clockTypes, clockType) {
+		return nil, fmt.Errorf("unknown clock type %q", clockType)
+	}
+
+	sim := newSimulationOfType(t.Processes, clockType)
+	sim.Out = io.Discard
+	for i, name := range t.Names {
+		sim.Processes[i].Name = name
+	}
+	for _, p := range sim.Processes {
+		p.Failure = CrashRecovery
+		p.PersistClock = t.PersistClock
+	}
+	s := NewScheduler(sim, t.Seed)
+	s.FIFO = true
+	s.start = t.Start
+
+	var failed error
+	fail := func(i int, err error) {
+		if failed == nil {
+			failed = fmt.Errorf("event %d (%s): %w", i, t.Events[i].Kind, err)
+		}
+	}
+	inFlight := make(map[string]Event) // Sendte beskeder efter ID
+	for i, e := range t.Events {
+		i, e, p := i, e, sim.Processes[e.Process]
+		at := time.Duration(e.AtNs)
+		switch e.Kind {
+		case "local":
+			s.Local(at, p, e.Message)
+		case "send":
+			to := sim.Processes[*e.To]
+			s.At(at, func() {
+				p.SendMessage(to, e.Message)
+				select {
+				case event := <-to.MessageQueue:
+					// En live optagelses leveringer bruger applikationens ID'er, som ikke
+					// behøver følge send rækkefølgen
+					id := event.MessageID
+					if e.MessageID != "" {
+						id = e.MessageID
+					}
+					inFlight[id] = event
+				default:
+				}
+			})
+		case "deliver":
+			s.At(at, func() {
+				event, ok := inFlight[e.MessageID]
+				if !ok {
+					fail(i, fmt.Errorf("message %s was never sent", e.MessageID))
+					return
+				}
+				p.deliver(event)
+			})
+		case "crash":
+			s.Crash(at, p)
+		case "recover":
+			s.Recover(at, p, func(err error) { fail(i, err) })
+		}
+	}
+	s.Run()
+	return sim, failed
+}
+
+// Fingerprint af hver proces' sekvens af event typer og besked ID'er, uden clock værdier.
+// Ens for to kørsler med samme interleaving, uanset ur.
+func InterleavingFingerprint(sim *Simulation) string {
+	hash := sha256.New()
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			e := p.loggedEvent(i)
+			fmt.Fprintf(hash, "%d|%d|%s|%s\n", e.ProcessID, e.Index, e.Type, e.MessageID)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Skriver optagelsen som JSON
+func WriteRecording(w io.Writer, t *Recording) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// Læser en optagelse fra JSON
+func ReadRecording(r io.Reader) (*Recording, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var t Recording
+	if err := decoder.Decode(&t); err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Skriver optagelsen til path
+func writeRecordingFile(path string, t *Recording) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := WriteRecording(file, t); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// "replay [-clock type] <file>": afspiller en optagelse med ét ur og skriver logs,
+// eller uden -clock med alle ure og en tabel over om interleavingen blev genskabt
+func replayCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.SetOutput(out)
+	clockType := flags.String("clock", "", "clock type to replay with (all if empty)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: replay [-clock Lamport|Vector|HLC] <recording file>")
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	t, err := ReadRecording(file)
+	if err != nil {
+		return err
+	}
+
+	if *clockType != "" {
+		sim, err := t.Replay(*clockType)
+		if err != nil {
+			return err
+		}
+		sim.Out = out
+		sim.PrintLogs()
+		return nil
+	}
+
+	fmt.Fprintf(out, "Recorded with %s: %d scheduled events, interleaving %s\n\n", t.Clock, len(t.Events), t.Interleaving)
+	fmt.Fprintf(out, "%-8s | %-8s | %-16s | %s\n", "Clock", "Events", "Interleaving", "Identical")
+	fmt.Fprintln(out, "---------|----------|------------------|----------")
+	for _, clockType := range clockTypes {
+		sim, err := t.Replay(clockType)
+		if err != nil {
+			return err
+		}
+		events := 0
+		for _, p := range sim.Processes {
+			events += len(p.EventLog)
+		}
+		interleaving := InterleavingFingerprint(sim)
+		fmt.Fprintf(out, "%-8s | %-8d | %-16s | %v\n", clockType, events, interleaving, interleaving == t.Interleaving)
+	}
+	return nil
+}
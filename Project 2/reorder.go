@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Hvad en modtager kan udlede om en ankommet besked
+const (
+	ArrivalNew       = "new"       // Bringer events modtageren ikke kendte
+	ArrivalDuplicate = "duplicate" // Samme send event er leveret før
+	ArrivalStale     = "stale"     // Modtageren kender allerede alt beskeden bringer (fx via en anden proces)
+)
+
+// Klassificerer ankomne beskeder ud fra vector clocks. Afsenderens entry i vectoren
+// identificerer send eventet, så en gentagelse er en duplikat; en vector modtageren
+// allerede dominerer bringer ingen ny viden.
+type ArrivalClassifier struct {
+	seen map[[2]int]bool // (afsender, afsenderens entry) for leverede beskeder
+}
+
+// Opretter en tom classifier
+func NewArrivalClassifier() *ArrivalClassifier {
+	return &ArrivalClassifier{seen: make(map[[2]int]bool)}
+}
+
+// Klassificerer en besked fra sender med vectoren received, når modtageren står på local
+func (c *ArrivalClassifier) Classify(sender int, local []int, received []int) string {
+	key := [2]int{sender, received[sender]}
+	if c.seen[key] {
+		return ArrivalDuplicate
+	}
+	c.seen[key] = true
+	if relation := Compare(received, local); relation == Before || relation == Equal {
+		return ArrivalStale
+	}
+	return ArrivalNew
+}
+
+// Optælling af ankomster per kategori, og hvad en Lamport modtager ville have gættet
+type arrivalCounts struct {
+	total, lamportOld int
+}
+
+// DemonstrateReorderingFaults kører en replikering over et netværk der omrokerer og
+// duplikerer beskeder, og sammenligner hvad vector og Lamport clocks kan sige om hver ankomst
+func DemonstrateReorderingFaults(out io.Writer) {
+	faults := TransportFaults{Reorder: 0.3, ReorderDelay: 25 * time.Millisecond, Duplicate: 0.2}
+	fmt.Fprintln(out, "\n=== REORDERING AND DUPLICATION FAULTS ===")
+	fmt.Fprintln(out, "P0 replicates writes to P1-P3, P1 relays what it has seen to P2 and P3.")
+	fmt.Fprintf(out, "Network: %.0f%% of deliveries delayed %v (reordered), %.0f%% duplicated\n\n",
+		faults.Reorder*100, faults.ReorderDelay, faults.Duplicate*100)
+
+	// Vector og Lamport tikker på præcis de samme events
+	const n, rounds = 4, 20
+	sim := NewSimulationWithClocks(n, func(id int, n int) LogicalClock {
+		return NewMultiClock(NewVectorClock(n, id), NewLamportClock())
+	})
+	counts := make(map[string]*arrivalCounts)
+	for _, kind := range []string{ArrivalDuplicate, ArrivalStale, ArrivalNew} {
+		counts[kind] = &arrivalCounts{}
+	}
+	for _, p := range sim.Processes {
+		classifier := NewArrivalClassifier()
+		p.OnDeliver = func(p *Process, event Event) bool {
+			parts := splitMessage(event.Message)
+			received, err := parseClockHeaderChecked(parts[0])
+			if err != nil {
+				return true // ReceiveMessage afviser headeren
+			}
+			local := p.Clock.Now()
+			kind := classifier.Classify(event.ProcessID, local.Vector(), received.Vector())
+			counts[kind].total++
+			// Lamport modtageren kan kun se om tiden er bagud for dens egen
+			if received.Stamps()[0].Time() <= local.Stamps()[0].Time() {
+				counts[kind].lamportOld++
+			}
+			return true
+		}
+	}
+
+	s := NewScheduler(sim, 1)
+	s.Faults = faults
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	for r := 0; r < rounds; r++ {
+		at := time.Duration(r) * 10 * time.Millisecond
+		s.Local(at, p0, fmt.Sprintf("write x=%d", r))
+		for _, q := range sim.Processes[1:] {
+			s.Send(at, p0, q, fmt.Sprintf("x=%d", r))
+		}
+		s.Send(at+5*time.Millisecond, p1, sim.Processes[2], "relay")
+		s.Send(at+5*time.Millisecond, p1, sim.Processes[3], "relay")
+		s.Local(at+7*time.Millisecond, sim.Processes[3], "local work")
+	}
+	s.Run()
+
+	fmt.Fprintf(out, "%-22s | %-8s | %-22s | %-22s\n", "Vector clock verdict", "Arrivals", "Lamport: T <= local", "Lamport: T > local")
+	fmt.Fprintln(out, "-----------------------|----------|------------------------|-----------------------")
+	for _, kind := range []string{ArrivalDuplicate, ArrivalStale, ArrivalNew} {
+		c := counts[kind]
+		fmt.Fprintf(out, "%-22s | %-8d | %-22d | %-22d\n", kind, c.total, c.lamportOld, c.total-c.lamportOld)
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• The sender's own vector entry names the send event, so a duplicate is recognized exactly")
+	fmt.Fprintln(out, "• A message the receiver's vector already dominates is stale: its writes arrived earlier via P1's relay")
+	fmt.Fprintf(out, "• Lamport's \"T <= local\" test also flags %d of %d new messages as old, since a busy receiver runs ahead\n",
+		counts[ArrivalNew].lamportOld, counts[ArrivalNew].total)
+	fmt.Fprintln(out, "• Lamport plus a per-sender sequence number can catch duplicates, but never indirect staleness")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metadata for en arkiveret kørsel (gemmes som meta.json i kørslens mappe)
+type RunMetadata struct {
+	ID       string    `json:"id"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Args     []string  `json:"args"`
+	Files    []string  `json:"files"`
+}
+
+// En kørsel under arkivering: runs/<id>/ med meta.json og de filer kørslen skriver
+type Run struct {
+	Dir      string
+	Metadata RunMetadata
+}
+
+// Opretter en ny kørsels-mappe under root med et tidsbaseret ID
+func CreateRun(root string, args []string) (*Run, error) {
+	started := time.Now()
+	id := started.Format("20060102-150405")
+	dir := filepath.Join(root, id)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", started.Format("20060102-150405"), n)
+		dir = filepath.Join(root, id)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	run := &Run{
+		Dir:      dir,
+		Metadata: RunMetadata{ID: id, Started: started, Args: args, Files: make([]string, 0)},
+	}
+	return run, run.writeMetadata()
+}
+
+// Stien til en fil i kørslens mappe; filen registreres i metadata
+func (r *Run) Path(name string) string {
+	r.Metadata.Files = append(r.Metadata.Files, name)
+	return filepath.Join(r.Dir, name)
+}
+
+// Afslutter kørslen og gemmer metadata
+func (r *Run) Finish() error {
+	r.Metadata.Finished = time.Now()
+	return r.writeMetadata()
+}
+
+func (r *Run) writeMetadata() error {
+	data, err := json.MarshalIndent(r.Metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, "meta.json"), data, 0o644)
+}
+
+// Læser metadata for kørslen id
+func LoadRun(root string, id string) (RunMetadata, error) {
+	var meta RunMetadata
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return meta, fmt.Errorf("invalid run id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(root, id, "meta.json"))
+	if err != nil {
+		return meta, fmt.Errorf("run %s not found: %w", id, err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("run %s has corrupt metadata: %w", id, err)
+	}
+	return meta, nil
+}
+
+// Retuner alle arkiverede kørsler, ældste først
+func ListRuns(root string) ([]RunMetadata, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]RunMetadata, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if meta, err := LoadRun(root, entry.Name()); err == nil {
+			runs = append(runs, meta)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs, nil
+}
+
+// Sletter en kørsel (kun mapper med meta.json, så andre mapper ikke slettes ved en fejl)
+func DeleteRun(root string, id string) error {
+	if _, err := LoadRun(root, id); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(root, id))
+}
+
+// "runs list", "runs show <id>" og "runs delete <id>"
+func runsCommand(root string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: runs list | runs show <id> | runs delete <id>")
+	}
+
+	switch args[0] {
+	case "list":
+		runs, err := ListRuns(root)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			fmt.Fprintf(out, "No runs in %s\n", root)
+			return nil
+		}
+		fmt.Fprintf(out, "%-20s | %-19s | %-10s | %-5s | %s\n", "ID", "Started", "Duration", "Files", "Args")
+		fmt.Fprintln(out, "---------------------|---------------------|------------|-------|-----")
+		for _, r := range runs {
+			duration := "-"
+			if !r.Finished.IsZero() {
+				duration = r.Finished.Sub(r.Started).Round(time.Millisecond).String()
+			}
+			fmt.Fprintf(out, "%-20s | %-19s | %-10s | %-5d | %s\n",
+				r.ID, r.Started.Format("2006-01-02 15:04:05"), duration, len(r.Files), strings.Join(r.Args, " "))
+		}
+		return nil
+
+	case "show":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: runs show <id>")
+		}
+		meta, err := LoadRun(root, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Run:      %s\n", meta.ID)
+		fmt.Fprintf(out, "Started:  %s\n", meta.Started.Format(time.RFC3339))
+		if !meta.Finished.IsZero() {
+			fmt.Fprintf(out, "Finished: %s\n", meta.Finished.Format(time.RFC3339))
+		}
+		fmt.Fprintf(out, "Args:     %s\n", strings.Join(meta.Args, " "))
+		fmt.Fprintln(out, "Files:")
+		for _, name := range meta.Files {
+			size := "missing"
+			if info, err := os.Stat(filepath.Join(root, meta.ID, name)); err == nil {
+				size = fmt.Sprintf("%d bytes", info.Size())
+			}
+			fmt.Fprintf(out, "  %-20s %s\n", name, size)
+		}
+		return nil
+
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: runs delete <id>")
+		}
+		if err := DeleteRun(root, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted run %s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown runs command %q (use list, show or delete)", args[0])
+}
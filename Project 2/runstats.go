@@ -0,0 +1,87 @@
+package main
+
+// Hjælpefunktioner til at opsummere en kørsel, så tests kan checke egenskaber ved
+// den uden selv at parse EventLog
+
+// Antal par af events (på tværs af alle processer) der er concurrent. Kræver vector
+// clocks, da Lamport ikke kan afgøre concurrency; returnerer 0 for andre ure.
+func CountConcurrentPairs(sim *Simulation) int {
+	if !sim.UsesVectorClock() {
+		return 0
+	}
+	vectors := make([][]int, 0)
+	for _, p := range sim.Processes {
+		vectors = append(vectors, p.EventVectors...)
+	}
+
+	concurrent := 0
+	for i := 0; i < len(vectors); i++ {
+		for j := i + 1; j < len(vectors); j++ {
+			if compareJoined(vectors[i], vectors[j]) == Concurrent {
+				concurrent++
+			}
+		}
+	}
+	return concurrent
+}
+
+// Den største clock værdi i nogen proces' log: største Lamport tid eller vector entry
+func MaxClockEntry(sim *Simulation) int {
+	highest := 0
+	for _, p := range sim.Processes {
+		for _, t := range p.EventTimestamps {
+			highest = max(highest, t)
+		}
+		for _, v := range p.EventVectors {
+			for _, entry := range v {
+				highest = max(highest, entry)
+			}
+		}
+	}
+	return highest
+}
+
+// Antal beskeder sendt fra proces from og modtaget af proces to
+func MessagesBetween(sim *Simulation, from int, to int) int {
+	count := 0
+	for _, edge := range MessageEdges(sim) {
+		if edge.Send.ProcessID == from && edge.Receive.ProcessID == to {
+			count++
+		}
+	}
+	return count
+}
+
+// Længden af den længste causale kæde af events (a → b → ... ), målt i events. Findes ud
+// fra loggenes rækkefølge og besked ID'erne, så den virker for alle slags ure.
+func CausalDepth(sim *Simulation) int {
+	sendOf := make(map[[2]int][2]int) // Receive eventet → det tilhørende send event
+	for _, edge := range MessageEdges(sim) {
+		sendOf[[2]int{edge.Receive.ProcessID, edge.Receive.Index}] = [2]int{edge.Send.ProcessID, edge.Send.Index}
+	}
+
+	depths := make(map[[2]int]int)
+	var depth func(event [2]int) int
+	depth = func(event [2]int) int {
+		if d, ok := depths[event]; ok {
+			return d
+		}
+		d := 1
+		if event[1] > 0 {
+			d = depth([2]int{event[0], event[1] - 1}) + 1
+		}
+		if send, ok := sendOf[event]; ok {
+			d = max(d, depth(send)+1)
+		}
+		depths[event] = d
+		return d
+	}
+
+	deepest := 0
+	for _, p := range sim.Processes {
+		if len(p.EventLog) > 0 {
+			deepest = max(deepest, depth([2]int{p.ID, len(p.EventLog) - 1}))
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Assertions i scenarie filer tjekkes når scenariet når deres step, altså efter alle
+// steps før dem og de leveringer der er sket til den tid:
+//
+//	assert P0 clock[1] >= 5            entry 1 i P0's vector clock (==, !=, <, <=, >, >=)
+//	assert P0 clock >= 5               P0's Lamport tid (den logiske tæller med HLC)
+//	assert P2 delivered M3 before M4   P2 har fået M3, og M4 (hvis den er kommet) efter M3
+//	assert P2 delivered M3             P2 har fået M3
+//
+// M<k> er beskeden fra filens k'te send step (eller et besked ID som P0-1). En assertion
+// der ikke holder stopper ikke kørslen, men Run returnerer den første som fejl.
+
+// Beskederne scenariets send steps har sendt, så assertions kan nævne dem som M<k>
+type scenarioMessages struct {
+	steps int               // Send steps planlagt indtil nu
+	ids   map[string]string // M<k> → besked ID, for de send steps der er udført
+	last  string            // ID fra det seneste send event (tomt hvis afsenderen var crashet)
+}
+
+// Navnet på det næste send step
+func (m *scenarioMessages) next() string {
+	m.steps++
+	return "M" + strconv.Itoa(m.steps)
+}
+
+// Besked ID'et for name (M<k> eller et ID)
+func (m *scenarioMessages) id(name string) string {
+	if id, ok := m.ids[name]; ok {
+		return id
+	}
+	return name
+}
+
+// Tjekker et "assert ..." step og retuner funktionen der evaluerer det under kørslen
+func parseScenarioAssertion(sim *Simulation, fields []string, messages *scenarioMessages) (func() error, error) {
+	usage := fmt.Errorf("usage: assert <process> clock[i] <op> <n> | assert <process> delivered <message> [before <message>]")
+	if len(fields) < 4 {
+		return nil, usage
+	}
+	p := sim.ProcessByName(fields[1])
+	if p == nil {
+		return nil, fmt.Errorf("no process %q", fields[1])
+	}
+
+	if fields[2] == "delivered" {
+		if len(fields) != 4 && (len(fields) != 6 || fields[4] != "before") {
+			return nil, usage
+		}
+		first, second := fields[3], ""
+		if len(fields) == 6 {
+			second = fields[5]
+		}
+		for _, name := range []string{first, second} {
+			if k, ok := strings.CutPrefix(name, "M"); ok {
+				if n, err := strconv.Atoi(k); err == nil && (n < 1 || n > messages.steps) {
+					return nil, fmt.Errorf("%s is not one of the %d send steps before the assertion", name, messages.steps)
+				}
+			}
+		}
+		return func() error {
+			at := deliveryIndex(p, messages.id(first))
+			if at < 0 {
+				return fmt.Errorf("%s has not delivered %s", p.Label(), first)
+			}
+			if later := deliveryIndex(p, messages.id(second)); second != "" && later >= 0 && later < at {
+				return fmt.Errorf("%s delivered %s (event %d) before %s (event %d)", p.Label(), second, later+1, first, at+1)
+			}
+			return nil
+		}, nil
+	}
+
+	if len(fields) != 5 {
+		return nil, usage
+	}
+	entry := -1
+	if index, ok := strings.CutPrefix(fields[2], "clock["); ok && strings.HasSuffix(index, "]") {
+		n, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+		if err != nil || n < 0 || n >= len(sim.Processes) {
+			return nil, fmt.Errorf("no clock entry %s in a clock with %d processes", fields[2], len(sim.Processes))
+		}
+		entry = n
+	} else if fields[2] != "clock" {
+		return nil, usage
+	}
+	compare, ok := clockComparisons[fields[3]]
+	if !ok {
+		return nil, fmt.Errorf("unknown comparison %q", fields[3])
+	}
+	want, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("expected a number, got %q", fields[4])
+	}
+	return func() error {
+		clock := p.Clock.Now()
+		var got int
+		switch {
+		case entry >= 0 && !clock.IsVector():
+			return fmt.Errorf("%s has no vector clock to read %s from", p.Label(), fields[2])
+		case entry >= 0:
+			got = clock.At(entry)
+		case clock.IsVector():
+			return fmt.Errorf("%s has a vector clock; use clock[i]", p.Label())
+		case clock.IsHybrid():
+			got = clock.Logical()
+		default:
+			got = clock.Time()
+		}
+		if !compare(got, want) {
+			return fmt.Errorf("%s %s is %d, expected %s %d", p.Label(), fields[2], got, fields[3], want)
+		}
+		return nil
+	}, nil
+}
+
+// Sammenligningerne en clock assertion kan bruge
+var clockComparisons = map[string]func(a int, b int) bool{
+	"==": func(a int, b int) bool { return a == b },
+	"!=": func(a int, b int) bool { return a != b },
+	"<":  func(a int, b int) bool { return a < b },
+	"<=": func(a int, b int) bool { return a <= b },
+	">":  func(a int, b int) bool { return a > b },
+	">=": func(a int, b int) bool { return a >= b },
+}
+
+// Positionen i p's EventLog hvor beskeden id blev modtaget, eller -1
+func deliveryIndex(p *Process, id string) int {
+	for i, messageID := range p.EventMessageIDs {
+		if messageID == id && id != "" && p.EventTypes[i] == "receive" {
+			return i
+		}
+	}
+	return -1
+}
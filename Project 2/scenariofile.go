@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Et scenarie beskrevet i en fil i stedet for Go kode. Hvert step er én linje:
+//
+//	P0 local [besked]          lokalt event
+//	P1 send P2 [besked]        besked (leveres efter netværkets latency)
+//	wait 10ms / at 50ms        flyt tiden frem relativt / til et bestemt tidspunkt
+//	latency 1ms 10ms           netværkets latency interval for resten af scenariet
+//	loss 0.2                   sandsynlighed for at en besked tabes (loss 0 slår det fra)
+//	partition P0 P1 | P2       beskeder mellem grupperne tabes; resten er en gruppe for sig
+//	heal                       netværket er helt igen
+//	P1 crash / P1 recover      crash-recovery (uret genindlæses hvis persist_clock er sat)
+//	say [tekst]                skriv en linje til output
+//	hook <navn>                kald en registreret ScenarioHook
+//	assert <betingelse>        tjek et ur eller en leveringsrækkefølge (se scenarioassert.go)
+//
+// Steps på samme tid udføres i filens rækkefølge. Processer kan nævnes som P<id> eller
+// ved navn fra names. Parametre og løkker er beskrevet i scenariotemplate.go.
+type ScenarioFile struct {
+	Name         string   `json:"name,omitempty"`
+	Params       []string `json:"params,omitempty"` // "navn=standardværdi", brugt som ${navn}
+	Processes    int      `json:"processes"`
+	Names        []string `json:"names,omitempty"`
+	Clock        string   `json:"clock,omitempty"` // En af clockTypes (Vector hvis tom)
+	Seed         int64    `json:"seed,omitempty"`
+	PersistClock bool     `json:"persist_clock,omitempty"`
+	Steps        []string `json:"steps"`
+
+	Hooks []EventHook `json:"-"` // Registreres på simulationen før den kører (fx fra watch)
+}
+
+// Læser et scenarie fra JSON eller fra den YAML delmængde ParseScenarioYAML forstår,
+// med parametrenes standardværdier
+func ParseScenarioFile(data []byte) (*ScenarioFile, error) {
+	return ParseScenarioTemplate(data, nil)
+}
+
+// Læser filen uden at tjekke den
+func parseScenario(data []byte) (*ScenarioFile, error) {
+	var f ScenarioFile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&f); err != nil {
+			return nil, fmt.Errorf("parse scenario: %w", err)
+		}
+	} else if err := parseScenarioYAML(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Fejl hvis scenariet ikke kan køres
+func (f *ScenarioFile) validate() error {
+	if f.Processes < 1 {
+		return fmt.Errorf("scenario needs at least one process, has %d", f.Processes)
+	}
+	if len(f.Names) > f.Processes {
+		return fmt.Errorf("scenario has %d names for %d processes", len(f.Names), f.Processes)
+	}
+	for _, field := range append([]string{f.Name, f.Clock}, f.Names...) {
+		if match := placeholderPattern.FindString(field); match != "" {
+			return fmt.Errorf("%w in %s (loop variables only work in steps)", errUndefinedName, match)
+		}
+	}
+	return nil
+}
+
+// Læser den YAML delmængde scenarie filer bruger: "nøgle: værdi" linjer, lister som
+// "- element" linjer under nøglen eller som [a, b], og kommentarer på egne linjer
+func parseScenarioYAML(data []byte, f *ScenarioFile) error {
+	var list *[]string // Listen som "- " linjer lige nu hører til
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			if list == nil {
+				return fmt.Errorf("line %d: list item outside a list", lineNo)
+			}
+			*list = append(*list, yamlScalar(item))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"key: value\", got %q", lineNo, line)
+		}
+		value = strings.TrimSpace(value)
+		list = nil
+		var err error
+		switch strings.TrimSpace(key) {
+		case "name":
+			f.Name = yamlScalar(value)
+		case "params":
+			f.Params, list = yamlInlineList(value), &f.Params
+		case "processes":
+			f.Processes, err = strconv.Atoi(value)
+		case "clock":
+			f.Clock = yamlScalar(value)
+		case "seed":
+			f.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "persist_clock":
+			f.PersistClock, err = strconv.ParseBool(value)
+		case "names":
+			f.Names, list = yamlInlineList(value), &f.Names
+		case "steps":
+			f.Steps, list = yamlInlineList(value), &f.Steps
+		default:
+			return fmt.Errorf("line %d: unknown key %q", lineNo, key)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %v", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// En YAML værdi uden citationstegn
+func yamlScalar(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return strings.Trim(value, "'")
+}
+
+// En liste skrevet som [a, b, c] (tom hvis værdien er tom, så "- " linjer kan følge)
+func yamlInlineList(value string) []string {
+	inner, ok := strings.CutPrefix(value, "[")
+	if !ok {
+		return nil
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(strings.TrimSuffix(inner, "]"), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, yamlScalar(item))
+		}
+	}
+	return items
+}
+
+// Kører scenariet med clockType (eller filens clock hvis tom) og skriver til out.
+// Alle steps tjekkes før noget køres, så en fejl i filen ikke giver en halv kørsel.
+func (f *ScenarioFile) Run(clockType string, out io.Writer) (*Simulation, error) {
+	return f.run(clockType, out, nil)
+}
+
+// Som Run, men optager også kørslen som en Recording der kan afspilles med et andet ur
+func (f *ScenarioFile) Record(clockType string, out io.Writer) (*Simulation, *Recording, error) {
+	recording := &Recording{}
+	sim, err := f.run(clockType, out, recording)
+	return sim, recording, err
+}
+
+func (f *ScenarioFile) run(clockType string, out io.Writer, recording *Recording) (*Simulation, error) {
+	if clockType == "" {
+		clockType = f.Clock
+	}
+	if clockType == "" {
+		clockType = "Vector"
+	}
+	if !containsString(clockTypes, clockType) {
+		return nil, fmt.Errorf("unknown clock type %q (use %s)", clockType, strings.Join(clockTypes, ", "))
+	}
+
+	sim := newSimulationOfType(f.Processes, clockType)
+	sim.Out = out
+	for i, name := range f.Names {
+		sim.Processes[i].Name = name
+	}
+	for _, p := range sim.Processes {
+		p.Failure = CrashRecovery
+		p.PersistClock = f.PersistClock
+	}
+	for _, hook := range f.Hooks {
+		sim.AddEventHook(hook)
+	}
+	messages := &scenarioMessages{ids: make(map[string]string)}
+	sim.AddEventHook(func(event StampedEvent) {
+		if event.Type == "send" {
+			messages.last = event.MessageID
+		}
+	})
+	s := NewScheduler(sim, f.Seed)
+	s.FIFO = true // Steps på samme tid sker i filens rækkefølge
+	s.Recording = recording
+
+	var failed error
+	fail := func(step int, err error) {
+		if failed == nil {
+			failed = fmt.Errorf("step %d (%s): %w", step+1, f.Steps[step], err)
+		}
+	}
+	at := time.Duration(0)
+	for i, step := range f.Steps {
+		if err := f.schedule(s, sim, &at, i, step, fail, messages); err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, step, err)
+		}
+	}
+	s.Run()
+	if recording != nil {
+		recording.finish(sim, clockType, f.Seed)
+	}
+	return sim, failed
+}
+
+// Planlægger ét step på scheduleren. at er scenariets nuværende tid.
+func (f *ScenarioFile) schedule(s *Scheduler, sim *Simulation, at *time.Duration, i int, step string, fail func(int, error), messages *scenarioMessages) error {
+	fields := strings.Fields(step)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty step")
+	}
+	rest := func(n int) string { return strings.Join(fields[min(n, len(fields)):], " ") }
+
+	switch fields[0] {
+	case "wait", "at":
+		if len(fields) != 2 {
+			return fmt.Errorf("usage: %s <duration>", fields[0])
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return err
+		}
+		if fields[0] == "wait" {
+			d += *at
+		}
+		if d < *at {
+			return fmt.Errorf("time %v is before the current time %v", d, *at)
+		}
+		*at = d
+		return nil
+	case "latency":
+		if len(fields) != 3 {
+			return fmt.Errorf("usage: latency <min> <max>")
+		}
+		low, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return err
+		}
+		high, err := time.ParseDuration(fields[2])
+		if err != nil || high < low {
+			return fmt.Errorf("invalid latency interval %s-%s", fields[1], fields[2])
+		}
+		s.At(*at, func() { s.Latency = uniformLatency(low, high) })
+		return nil
+	case "loss":
+		if len(fields) != 2 {
+			return fmt.Errorf("usage: loss <probability>")
+		}
+		loss, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || loss < 0 || loss > 1 {
+			return fmt.Errorf("loss %q is not a probability between 0 and 1", fields[1])
+		}
+		s.At(*at, func() { s.Faults.Loss = loss })
+		return nil
+	case "partition":
+		groups := make([][]*Process, 1)
+		for _, name := range fields[1:] {
+			if name == "|" {
+				groups = append(groups, nil)
+				continue
+			}
+			p := sim.ProcessByName(name)
+			if p == nil {
+				return fmt.Errorf("no process %q", name)
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], p)
+		}
+		if len(groups) < 2 {
+			return fmt.Errorf("usage: partition <process> ... | <process> ...")
+		}
+		s.Partition(*at, groups)
+		return nil
+	case "heal":
+		if len(fields) != 1 {
+			return fmt.Errorf("usage: heal")
+		}
+		s.Heal(*at)
+		return nil
+	case "say":
+		s.Println(*at, rest(1))
+		return nil
+	case "hook":
+		hook, ok := lookupScenarioHook(rest(1))
+		if !ok {
+			return fmt.Errorf("no hook registered as %q", rest(1))
+		}
+		s.At(*at, func() {
+			if err := hook(sim); err != nil {
+				fail(i, err)
+			}
+		})
+		return nil
+	case "assert":
+		check, err := parseScenarioAssertion(sim, fields, messages)
+		if err != nil {
+			return err
+		}
+		s.At(*at, func() {
+			if err := check(); err != nil {
+				fail(i, err)
+			}
+		})
+		return nil
+	}
+
+	p := sim.ProcessByName(fields[0])
+	if p == nil || len(fields) < 2 {
+		return fmt.Errorf("expected \"<process> local|send|crash|recover ...\"")
+	}
+	switch fields[1] {
+	case "local":
+		s.Local(*at, p, rest(2))
+	case "send":
+		if len(fields) < 3 || sim.ProcessByName(fields[2]) == nil {
+			return fmt.Errorf("usage: <process> send <process> [message]")
+		}
+		s.Send(*at, p, sim.ProcessByName(fields[2]), rest(3))
+		name := messages.next()
+		s.At(*at, func() { messages.ids[name], messages.last = messages.last, "" })
+	case "crash":
+		s.Crash(*at, p)
+	case "recover":
+		s.Recover(*at, p, func(err error) { fail(i, err) })
+	default:
+		return fmt.Errorf("unknown action %q", fields[1])
+	}
+	return nil
+}
+
+// Latency jævnt fordelt i [low, high] i hele millisekunder
+func uniformLatency(low time.Duration, high time.Duration) func(r *rand.Rand) time.Duration {
+	return func(r *rand.Rand) time.Duration {
+		steps := int64((high - low) / time.Millisecond)
+		return low + time.Duration(r.Int63n(steps+1))*time.Millisecond
+	}
+}
+
+// Er s i list?
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// "scenario run [-clock type] [-record file] [-set name=value ...] <file>" og "scenario hooks"
+func scenarioCommand(args []string, out io.Writer) error {
+	usage := fmt.Errorf("usage: scenario run [-clock Lamport|Vector|HLC] [-record file] [-set name=value ...] <file> | scenario hooks")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "run":
+		flags := flag.NewFlagSet("scenario run", flag.ContinueOnError)
+		flags.SetOutput(out)
+		clockType := flags.String("clock", "", "clock type (overrides the file's clock)")
+		record := flags.String("record", "", "write a recording of the run to this file (see replay)")
+		params := paramFlag{}
+		flags.Var(params, "set", "set a scenario parameter, as name=value (repeatable)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return usage
+		}
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		f, err := ParseScenarioTemplate(data, params)
+		if err != nil {
+			return err
+		}
+		if f.Name != "" {
+			fmt.Fprintf(out, "=== %s ===\n", f.Name)
+		}
+		sim, recording, err := f.Record(*clockType, out)
+		if err != nil {
+			return err
+		}
+		sim.PrintLogs()
+		if *record != "" {
+			if err := writeRecordingFile(*record, recording); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Recorded %d events to %s\n", len(recording.Events), *record)
+		}
+		return nil
+
+	case "hooks":
+		for _, name := range ScenarioHookNames() {
+			fmt.Fprintln(out, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown scenario command %q (use run or hooks)", args[0])
+}
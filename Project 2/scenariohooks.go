@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// Go kode et scenarie kan kalde med "hook <navn>", fx en assertion eller en ændring af
+// en proces' tilstand. En fejl stopper scenariet.
+type ScenarioHook func(sim *Simulation) error
+
+// Registrerede hooks efter navn. Simulationer kan køre samtidig (fx i experiment), så
+// registreringen er beskyttet af en mutex.
+var scenarioHooks = struct {
+	mu    sync.RWMutex
+	hooks map[string]ScenarioHook
+}{hooks: map[string]ScenarioHook{
+	"print-logs": func(sim *Simulation) error {
+		sim.PrintLogs()
+		return nil
+	},
+	"check-clock-condition": func(sim *Simulation) error {
+		return ClockConditionVerifier{}.Check(sim)
+	},
+}}
+
+// Registrerer en hook så scenarie filer kan kalde den med "hook <navn>"
+func RegisterScenarioHook(name string, hook ScenarioHook) {
+	scenarioHooks.mu.Lock()
+	defer scenarioHooks.mu.Unlock()
+	scenarioHooks.hooks[name] = hook
+}
+
+// Hooken registreret som name
+func lookupScenarioHook(name string) (ScenarioHook, bool) {
+	scenarioHooks.mu.RLock()
+	defer scenarioHooks.mu.RUnlock()
+	hook, ok := scenarioHooks.hooks[name]
+	return hook, ok
+}
+
+// Navnene på de registrerede hooks, sorteret
+func ScenarioHookNames() []string {
+	scenarioHooks.mu.RLock()
+	defer scenarioHooks.mu.RUnlock()
+	names := make([]string, 0, len(scenarioHooks.hooks))
+	for name := range scenarioHooks.hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
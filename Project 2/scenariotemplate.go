@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scenarie filer kan være skabeloner. Parametre erklæres med standardværdier
+//
+//	params: [n=4, rounds=3]
+//
+// og bruges som ${n} overalt i filen, også i processes og seed. Steps kan gentages:
+//
+//	- repeat ${rounds} r        steps frem til "end" gentages med r = 0, 1, ...
+//	- P0 send P1 round ${r}
+//	- end
+//
+// ${...} er et navn, et tal eller tal og navne forbundet med + - * / %, regnet fra
+// venstre mod højre (${r+1%n} er (r+1) mod n). Standardværdierne kan overskrives når
+// filen læses, så én fil giver en hel familie af scenarier.
+
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Et ${...} der bruger et navn som hverken er en parameter eller en løkke variabel
+var errUndefinedName = errors.New("undefined parameter")
+
+// Højeste antal steps en skabelon må folde ud til
+const maxExpandedSteps = 100000
+
+// Læser en scenarie skabelon; overrides erstatter parametrenes standardværdier
+func ParseScenarioTemplate(data []byte, overrides map[string]string) (*ScenarioFile, error) {
+	// Find parametrene med alle ${...} sat til 0, så filen kan læses før værdierne kendes
+	declared, err := parseScenario(placeholderPattern.ReplaceAll(data, []byte("0")))
+	if err != nil {
+		return nil, err
+	}
+	params, err := scenarioParams(declared.Params, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// Udtryk der kun bruger parametre sættes ind i hele filen; resten hører til løkker
+	var substituteErr error
+	expanded := placeholderPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		value, err := evalPlaceholder(string(match[2:len(match)-1]), params)
+		if err != nil {
+			if !errors.Is(err, errUndefinedName) && substituteErr == nil {
+				substituteErr = err
+			}
+			return match
+		}
+		return []byte(value)
+	})
+	if substituteErr != nil {
+		return nil, substituteErr
+	}
+
+	f, err := parseScenario(expanded)
+	if err != nil {
+		return nil, err
+	}
+	if f.Steps, err = expandSteps(f.Steps, params, 0); err != nil {
+		return nil, err
+	}
+	return f, f.validate()
+}
+
+// Parametrenes værdier: standardværdierne fra filen, erstattet af overrides
+func scenarioParams(declared []string, overrides map[string]string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, param := range declared {
+		name, value, ok := strings.Cut(param, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !isParamName(name) {
+			return nil, fmt.Errorf("malformed parameter %q (use name=default)", param)
+		}
+		params[name] = strings.TrimSpace(value)
+	}
+	for name, value := range overrides {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("scenario has no parameter %q", name)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// Er name et gyldigt parameter navn (bogstaver, cifre og _, ikke først et ciffer)?
+func isParamName(name string) bool {
+	for i, ch := range name {
+		letter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !letter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// Regner et ${...} udtryk ud med vars. Et navn alene giver dets værdi som den er.
+func evalPlaceholder(expr string, vars map[string]string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if value, ok := vars[expr]; ok {
+		return value, nil
+	}
+
+	operand := func(text string) (int, error) {
+		text = strings.TrimSpace(text)
+		if n, err := strconv.Atoi(text); err == nil {
+			return n, nil
+		}
+		value, ok := vars[text]
+		if !ok {
+			if isParamName(text) {
+				return 0, fmt.Errorf("%w %q in ${%s}", errUndefinedName, text, expr)
+			}
+			return 0, fmt.Errorf("malformed expression ${%s}", expr)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("parameter %s=%q in ${%s} is not a number", text, value, expr)
+		}
+		return n, nil
+	}
+
+	start := strings.IndexAny(expr[min(1, len(expr)):], "+-*/%") + min(1, len(expr))
+	if start < 1 {
+		n, err := operand(expr)
+		return strconv.Itoa(n), err
+	}
+	result, err := operand(expr[:start])
+	for err == nil && start < len(expr) {
+		op := expr[start]
+		rest := expr[start+1:]
+		end := strings.IndexAny(rest, "+-*/%")
+		if end < 0 {
+			end = len(rest)
+		}
+		var n int
+		if n, err = operand(rest[:end]); err != nil {
+			break
+		}
+		switch op {
+		case '+':
+			result += n
+		case '-':
+			result -= n
+		case '*':
+			result *= n
+		case '/', '%':
+			if n == 0 {
+				return "", fmt.Errorf("division by zero in ${%s}", expr)
+			}
+			if op == '/' {
+				result /= n
+			} else {
+				result %= n
+			}
+		}
+		start += 1 + end
+	}
+	return strconv.Itoa(result), err
+}
+
+// Folder "repeat <antal> [variabel]" ... "end" ud og sætter de resterende ${...} ind
+// med vars (parametrene og de omsluttende løkkers variabler)
+func expandSteps(steps []string, vars map[string]string, produced int) ([]string, error) {
+	out := make([]string, 0, len(steps))
+	for i := 0; i < len(steps); i++ {
+		fields := strings.Fields(steps[i])
+		if len(fields) > 0 && fields[0] == "end" {
+			return nil, fmt.Errorf("\"end\" without \"repeat\"")
+		}
+		if len(fields) == 0 || fields[0] != "repeat" {
+			step, err := substituteStep(steps[i], vars)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, step)
+			continue
+		}
+
+		if len(fields) < 2 || len(fields) > 3 || (len(fields) == 3 && !isParamName(fields[2])) {
+			return nil, fmt.Errorf("usage: repeat <count> [variable], got %q", steps[i])
+		}
+		if _, taken := vars[fields[len(fields)-1]]; len(fields) == 3 && taken {
+			return nil, fmt.Errorf("loop variable %q is already a parameter or loop variable", fields[2])
+		}
+		countText, err := substituteStep(fields[1], vars)
+		if err != nil {
+			return nil, err
+		}
+		count, err := strconv.Atoi(countText)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("repeat count %q is not a number of times", countText)
+		}
+		end, depth := -1, 0
+		for j := i + 1; j < len(steps) && end < 0; j++ {
+			switch first, _, _ := strings.Cut(strings.TrimSpace(steps[j]), " "); first {
+			case "repeat":
+				depth++
+			case "end":
+				if depth == 0 {
+					end = j
+				}
+				depth--
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("%q has no matching \"end\"", steps[i])
+		}
+
+		for k := 0; k < count; k++ {
+			loopVars := vars
+			if len(fields) == 3 {
+				loopVars = make(map[string]string, len(vars)+1)
+				for name, value := range vars {
+					loopVars[name] = value
+				}
+				loopVars[fields[2]] = strconv.Itoa(k)
+			}
+			body, err := expandSteps(steps[i+1:end], loopVars, produced+len(out))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, body...)
+			if produced+len(out) > maxExpandedSteps {
+				return nil, fmt.Errorf("scenario expands to more than %d steps", maxExpandedSteps)
+			}
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// Sætter alle ${...} i step ind med vars
+func substituteStep(step string, vars map[string]string) (string, error) {
+	var failed error
+	result := placeholderPattern.ReplaceAllStringFunc(step, func(match string) string {
+		value, err := evalPlaceholder(match[2:len(match)-1], vars)
+		if err != nil && failed == nil {
+			failed = err
+		}
+		return value
+	})
+	return result, failed
+}
+
+// -set name=value flag der kan gives flere gange
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name+"="+p[name])
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (p paramFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	if !ok || !isParamName(name) {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	p[name] = v
+	return nil
+}
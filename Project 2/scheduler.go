@@ -0,0 +1,319 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Et planlagt event i den simulerede tid
+type scheduledEvent struct {
+	at     time.Duration
+	order  int64 // Tilfældig tiebreak for events på samme tid (fra schedulerens seed)
+	seq    int   // Rækkefølge de blev planlagt i, hvis tiebreaket også er ens
+	action func()
+}
+
+// Prioritetskø sorteret efter (tid, tiebreak, seq)
+type scheduledQueue []scheduledEvent
+
+func (q scheduledQueue) Len() int { return len(q) }
+func (q scheduledQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	if q[i].order != q[j].order {
+		return q[i].order < q[j].order
+	}
+	return q[i].seq < q[j].seq
+}
+func (q scheduledQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *scheduledQueue) Push(x interface{}) { *q = append(*q, x.(scheduledEvent)) }
+func (q *scheduledQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// Hvordan en Scheduler forholder sig til rigtig tid
+type TimeMode int
+
+const (
+	VirtualTime TimeMode = iota // Spring direkte til næste event, så kørslen kun tager den tid events tager
+	RealTime                    // Vent til eventets simulerede tid er nået på væggens ur (til demoer man følger med i)
+)
+
+// Print funktion
+func (m TimeMode) String() string {
+	if m == RealTime {
+		return "real time"
+	}
+	return "virtual time"
+}
+
+// Scheduler er en deterministisk discrete-event motor for en simulation. Alle events
+// (lokale events, sends og leveringer) udføres i én goroutine i rækkefølge efter
+// simuleret tid, og events på samme tid ordnes af en seeded RNG, ligesom netværkets
+// latency. Samme seed giver derfor altid samme interleaving og samme logs.
+type Scheduler struct {
+	sim       *Simulation
+	Rand      *rand.Rand
+	Now       time.Duration // Simuleret tid siden start
+	Latency   func(r *rand.Rand) time.Duration
+	Faults    TransportFaults
+	FIFO      bool       // Events på samme tid udføres i den rækkefølge de blev planlagt, ikke efter seedet
+	Mode      TimeMode   // Virtuel eller rigtig tid (fra simulationens TimeMode)
+	Recording *Recording // Optager lokale events, sends, leveringer, crashes og recoveries når sat
+	// Tid en proces bruger på hver besked; ankomne beskeder venter i processens inbox
+	// imens (0 = leveres ved ankomst)
+	ServiceTime time.Duration
+	Priority    func(event Event) int // Beskeden med højest prioritet tages først fra inboxen (nil = ankomstrækkefølge)
+	// Giv en besked prioriteten fra de beskeder der causally afhænger af den, så en vigtig
+	// broadcast ikke venter i causal bufferen på en uvigtig bagest i inboxen
+	InheritPriority bool
+	start           time.Time
+	queue           scheduledQueue
+	seq             int
+	inbox           map[int][]Event // Beskeder der er nået frem men ikke taget i behandling, efter proces
+	busy            map[int]bool    // Processer der er i gang med en besked
+	partition       map[int]int     // Hver proces' side af en partition (nil = netværket er helt)
+	Lost            int             // Beskeder tabt på netværket (Faults.Loss eller en partition)
+}
+
+// Opretter en scheduler for sim med den givne seed. Beskeder er undervejs 1-10 ms, og
+// HLC ure læser den simulerede tid i stedet for time.Now. Mode kommer fra sim.TimeMode.
+// Processernes omission faults og ProcessingDelay seedes også, så de følger seedet.
+func NewScheduler(sim *Simulation, seed int64) *Scheduler {
+	s := &Scheduler{
+		sim:  sim,
+		Rand: rand.New(rand.NewSource(seed)),
+		Latency: func(r *rand.Rand) time.Duration {
+			return time.Duration(1+r.Intn(10)) * time.Millisecond
+		},
+		Mode:  sim.TimeMode,
+		start: time.Now(),
+		inbox: make(map[int][]Event),
+		busy:  make(map[int]bool),
+	}
+	for _, p := range sim.Processes {
+		if hlc, ok := p.Clock.(*HybridLogicalClock); ok {
+			hlc.Physical = s.Clock
+		}
+	}
+	sim.seedFaults(seed)
+	return s
+}
+
+// Den simulerede tid som et klokkeslæt
+func (s *Scheduler) Clock() time.Time {
+	return s.start.Add(s.Now)
+}
+
+// Planlægger action til den simulerede tid at
+func (s *Scheduler) At(at time.Duration, action func()) {
+	s.seq++
+	order := int64(0)
+	if !s.FIFO {
+		order = s.Rand.Int63()
+	}
+	heap.Push(&s.queue, scheduledEvent{at: at, order: order, seq: s.seq, action: action})
+}
+
+// Planlægger et lokalt event på processen p
+func (s *Scheduler) Local(at time.Duration, p *Process, message string) {
+	s.At(at, func() {
+		s.record(RecordedEvent{Kind: "local", Process: p.ID, Message: message})
+		p.HandleLocalEvent(message)
+	})
+}
+
+// Omrokering og duplikering af beskeder på netværket
+type TransportFaults struct {
+	Reorder      float64       // Sandsynlighed for at en levering forsinkes ReorderDelay ekstra
+	ReorderDelay time.Duration // Så senere beskeder kan nå frem før den
+	Duplicate    float64       // Sandsynlighed for at en besked leveres to gange
+	Loss         float64       // Sandsynlighed for at en besked tabes på netværket
+}
+
+// Planlægger en besked fra from til to. Send eventet sker til tiden at, og leveringen
+// efter netværkets latency (medmindre beskeden tabes af en omission fault eller middleware).
+// Med Faults kan leveringen blive forsinket forbi senere beskeder, eller ske to gange.
+func (s *Scheduler) Send(at time.Duration, from *Process, to *Process, message string) {
+	s.At(at, func() {
+		s.record(RecordedEvent{Kind: "send", Process: from.ID, To: &to.ID, Message: message})
+		from.SendMessage(to, message)
+		s.forwardQueued()
+	})
+}
+
+// Sender beskeder der ligger i processernes køer ud på netværket, fx dem en Behavior
+// sendte, så de leveres efter latency i stedet for at blive liggende
+func (s *Scheduler) forwardQueued() {
+	for _, p := range s.sim.Processes {
+		for queued := true; queued; {
+			select {
+			case event := <-p.MessageQueue:
+				if s.lost(event.ProcessID, p.ID) {
+					s.Lost++
+					p.inFlight.Add(-1)
+					event.acknowledge(DeliveryReceipt{}, ErrMessageDropped)
+					continue
+				}
+				s.deliverLater(p, event)
+				if s.Faults.Duplicate > 0 && s.Rand.Float64() < s.Faults.Duplicate {
+					p.inFlight.Add(1)
+					s.deliverLater(p, event)
+				}
+			default:
+				queued = false
+			}
+		}
+	}
+}
+
+// Tabes en besked fra from til to på netværket? Beskeder på tværs af en partition tabes
+// altid, ellers med sandsynligheden Faults.Loss.
+func (s *Scheduler) lost(from int, to int) bool {
+	if s.partition != nil && s.partition[from] != s.partition[to] {
+		return true
+	}
+	return s.Faults.Loss > 0 && s.Rand.Float64() < s.Faults.Loss
+}
+
+// Planlægger en partition af netværket til tiden at: beskeder mellem processer i
+// forskellige grupper tabes indtil Heal. Processer uden gruppe er en gruppe for sig.
+func (s *Scheduler) Partition(at time.Duration, groups [][]*Process) {
+	s.At(at, func() {
+		s.partition = make(map[int]int)
+		for side, group := range groups {
+			for _, p := range group {
+				s.partition[p.ID] = side + 1
+			}
+		}
+	})
+}
+
+// Planlægger at en partition heles til tiden at
+func (s *Scheduler) Heal(at time.Duration) {
+	s.At(at, func() { s.partition = nil })
+}
+
+// Kalder OnTick på alle processer med en Behavior hver every indtil until
+func (s *Scheduler) Tick(every time.Duration, until time.Duration) {
+	for at := every; at <= until; at += every {
+		s.At(at, func() {
+			for _, p := range s.sim.Processes {
+				if p.Behavior != nil && !p.Crashed() {
+					p.Behavior.OnTick(p)
+				}
+			}
+			s.forwardQueued()
+		})
+	}
+}
+
+// Planlægger levering af event efter netværkets latency, evt. med en omrokering
+func (s *Scheduler) deliverLater(to *Process, event Event) {
+	delay := s.Latency(s.Rand)
+	if s.Faults.Reorder > 0 && s.Rand.Float64() < s.Faults.Reorder {
+		delay += s.Faults.ReorderDelay
+	}
+	s.At(s.Now+delay, func() {
+		if s.ServiceTime > 0 {
+			s.inbox[to.ID] = append(s.inbox[to.ID], event)
+			s.serveNext(to)
+			return
+		}
+		s.record(RecordedEvent{Kind: "deliver", Process: to.ID, MessageID: event.MessageID})
+		to.deliver(event)
+		s.forwardQueued()
+	})
+}
+
+// Planlægger et crash af p
+func (s *Scheduler) Crash(at time.Duration, p *Process) {
+	s.At(at, func() {
+		s.record(RecordedEvent{Kind: "crash", Process: p.ID})
+		p.Crash()
+	})
+}
+
+// Planlægger at p kommer tilbage efter et crash. En fejl fra Recover gives til onError.
+func (s *Scheduler) Recover(at time.Duration, p *Process, onError func(error)) {
+	s.At(at, func() {
+		s.record(RecordedEvent{Kind: "recover", Process: p.ID})
+		if err := p.Recover(); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// Tilføjer e til Recording med den nuværende simulerede tid
+func (s *Scheduler) record(e RecordedEvent) {
+	if s.Recording != nil {
+		e.AtNs = int64(s.Now)
+		s.Recording.Events = append(s.Recording.Events, e)
+	}
+}
+
+// Skriver en linje til simulationens output til tiden at
+func (s *Scheduler) Println(at time.Duration, line string) {
+	s.At(at, func() { fmt.Fprintln(s.sim.Out, line) })
+}
+
+// Udfører alle planlagte events, inklusive dem de planlægger, og returnerer antal events
+func (s *Scheduler) Run() int {
+	executed := 0
+	if s.Recording != nil {
+		s.Recording.Start = s.start
+	}
+	for s.queue.Len() > 0 {
+		e := heap.Pop(&s.queue).(scheduledEvent)
+		s.wait(e.at)
+		s.Now = e.at
+		e.action()
+		executed++
+	}
+	return executed
+}
+
+// Udfører det næste planlagte event (fx én levering) og retuner false hvis der ikke er flere
+func (s *Scheduler) Step() bool {
+	if s.queue.Len() == 0 {
+		return false
+	}
+	e := heap.Pop(&s.queue).(scheduledEvent)
+	s.wait(e.at)
+	s.Now = e.at
+	e.action()
+	return true
+}
+
+// Som Run, men stopper ved den simulerede tid until; senere events bliver i køen
+func (s *Scheduler) RunUntil(until time.Duration) int {
+	executed := 0
+	for s.queue.Len() > 0 && s.queue[0].at <= until {
+		e := heap.Pop(&s.queue).(scheduledEvent)
+		s.wait(e.at)
+		s.Now = e.at
+		e.action()
+		executed++
+	}
+	s.Now = max(s.Now, until)
+	return executed
+}
+
+// I RealTime venter til den simulerede tid at er nået på væggens ur, regnet fra da
+// scheduleren blev oprettet. Events på samme tid venter ikke på hinanden.
+func (s *Scheduler) wait(at time.Duration) {
+	if s.Mode == RealTime {
+		time.Sleep(time.Until(s.start.Add(at)))
+	}
+}
+
+// Antal planlagte events der ikke er udført endnu
+func (s *Scheduler) Pending() int {
+	return s.queue.Len()
+}
@@ -2,136 +2,260 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // Event struct initialization
 type Event struct {
-	Type      string 
-	ProcessID int    
-	TargetID  int    
-	Message   string 
+	Type       string 
+	ProcessID  int    
+	TargetID   int    
+	Message    string 
+	SenderName string // Afsenderens label (navn eller P<id>)
+	SentAt     time.Time // Hvornår beskeden blev lagt i køen
+	MessageID  string    // Unikt ID der følger beskeden fra send til receive
+	receipt    chan deliveryResult // Sat af SendMessageSync, får svar når beskeden er leveret eller droppet
+	causal     []int               // Afsenderens broadcast vector når causal delivery er slået til
+	linkSeq    int                 // Sekvensnummer på linket fra afsender til modtager når det er FIFO (0 = uden)
+}
+
+// Retuner afsenderens label, med P<id> som fallback
+func (e Event) Sender() string {
+	if e.SenderName != "" {
+		return e.SenderName
+	}
+	return fmt.Sprintf("P%d", e.ProcessID)
 }
 
 // Process struct initialization 
 type Process struct {
 	ID              int
+	Name            string // Valgfrit læsbart navn, fx "frontend" eller "db-1"
+	UID             string // Stabilt ID på tværs af kørsler og noder (navnet eller et UUID), se MembershipTable
 	LamportClock    *LamportClock
 	VectorClock     *VectorClock
 	EventLog        []string   
 	EventVectors    [][]int    // Gemmer vector clock 
 	EventTimestamps []int      // Gemmer Lamport timestamp
+	EventTypes      []string   // "local", "send" eller "receive" for hver entry i EventLog
+	EventMessageIDs []string   // Besked ID for send og receive events ("" for lokale events)
+	EventStamps     [][]ClockSnapshot // Multi-clock: de andre ures aflæsning for hver entry i EventLog
+	EventWallTimes  []time.Time       // Wall clock tid for hver entry i EventLog når WallClock er sat
 	MessageQueue    chan Event 
-	UseVectorClock  bool       
+	Clock           LogicalClock    // Uret processen bruger (LamportClock, VectorClock eller et eget)
+	Counters        MessageCounters // Antal beskeder og bytes sendt/modtaget
+	HashChain       bool            // Kæd event loggen sammen med hashes (tamper evidence)
+	EventHashes     []string        // Hash for hver entry i EventLog når HashChain er slået til
+	vectorFrom      int             // Index i EventLog hvor vector clocks blev slået til (0 = fra start)
+	ProcessingDelay DelayModel      // Kunstig forsinkelse før hver besked håndteres (nil = ingen)
+	runLoops        atomic.Int32    // Antal kørende Run goroutines (mere end 1 er en leak)
+	inFlight        atomic.Int64    // Beskeder sendt til processen som deliver ikke er færdig med endnu (se WaitUntilIdle)
+	Checkpoints     []Checkpoint    // Gemte checkpoints tagget med vector clock
+	CheckpointEvery int             // Tag automatisk et checkpoint for hver N events (0 = aldrig)
+	messagesSent    int             // Bruges til at lave unikke besked ID'er
+	Omission        OmissionFaults  // Sandsynlighed for at processen taber beskeder ved send/receive
+	omitted         omissionCounters
+	DiscardEvents   bool            // Gem ikke events og clock snapshots (måler ren clock overhead i benchmarks)
+	Middleware      []MessageMiddleware // Led på beskedstien (komprimering, kryptering, metrics, fejl) i send rækkefølge
+	WallClock       func() time.Time    // Processens fysiske ur (nil = gem ikke wall clock tid for events)
+	Failure         FailureModel        // Hvad et crash betyder: fail-stop (standard) eller crash-recovery
+	PersistClock    bool                // Skriv uret til stabilt lager efter hvert event, så Recover kan genindlæse det
+	crashed         atomic.Bool
+	persistedClock  ClockSnapshot       // Den seneste værdi skrevet til stabilt lager
+	removed         bool                // Fjernet med RemoveProcess
+	widthBeforeJoin int                 // Vector længden før AddProcess første gang voksede uret (0 = aldrig vokset)
+	sim             *Simulation         // Simulationen processen er med i (nil for en løs proces), bruges af Broadcast
+	causalBuffer    *CausalBuffer       // Holder broadcasts tilbage til det de afhænger af er leveret (nil = leveres ved ankomst)
+	heldBack        map[[2]int]Event    // Beskederne causalBuffer holder tilbage, efter (afsender, broadcast nummer)
+	fifoSent        map[int]int         // Sidste sekvensnummer sendt på hvert FIFO link, efter modtager
+	fifoDelivered   map[int]int         // Sidste sekvensnummer leveret fra hvert FIFO link, efter afsender
+	fifoHeld        map[[2]int]Event    // Beskeder der er nået frem før en tidligere på samme link, efter (afsender, nummer)
+
+	// Lifecycle hooks (alle er valgfrie)
+	OnStart   func(p *Process)                    // Kaldes når processens goroutine starter
+	OnStop    func(p *Process)                    // Kaldes når processen stoppes
+	OnDeliver func(p *Process, event Event) bool // Kaldes før levering, returner false for at droppe beskeden
+	OnEvent   func(p *Process, event LoggedEvent) // Kaldes når et event er logget (fx til online analyse)
+
+	Behavior Behavior // Applikationslogik der selv reagerer på beskeder og ticks (nil = drives udefra)
+	watchers clockWatchers
+	eventHooks []EventHook // Kaldes for hvert event; den første er event loggen (logEvent)
+	faultRand  *rand.Rand  // Til omission faults og ProcessingDelay, seedet af Simulation.Seed og NewScheduler
 }
 
 // Opretter en ny proces
 func NewProcess(id int, numProcesses int, useVectorClock bool) *Process {
-	return &Process{
+	p := &Process{
 		ID:              id,
 		LamportClock:    NewLamportClock(),
 		VectorClock:     NewVectorClock(numProcesses, id),
 		EventLog:        make([]string, 0),
 		EventVectors:    make([][]int, 0),      
 		EventTimestamps: make([]int, 0),        
+		EventTypes:      make([]string, 0),
+		EventMessageIDs: make([]string, 0),
 		MessageQueue:    make(chan Event, 100), 
-		UseVectorClock:  useVectorClock,
+		eventHooks:      []EventHook{logEvent},
+		faultRand:       newFaultRand(time.Now().UnixNano() + int64(id)),
+	}
+	p.Clock = p.LamportClock
+	if useVectorClock {
+		p.Clock = p.VectorClock
+	}
+	return p
+}
+
+// Retuner processens navn, eller P<id> hvis den ikke har et
+func (p *Process) Label() string {
+	if p.Name != "" {
+		return p.Name
 	}
+	return fmt.Sprintf("P%d", p.ID)
 }
 
 // Håndterer en lokal operation
 func (p *Process) HandleLocalEvent(message string) {
-	if p.UseVectorClock {
-		vector := p.VectorClock.LocalEvent()
-		p.EventVectors = append(p.EventVectors, copyVector(vector)) 
-		logMsg := fmt.Sprintf("P%d: Local event %s at %s",
-			p.ID, FormatVector(vector), message)
-		p.EventLog = append(p.EventLog, logMsg)
+	if p.Crashed() {
+		return
+	}
+	clock := p.Clock.Tick()
+	p.clockChanged(clock)
+	p.stamp(StampedEvent{Type: "local", Clock: clock, Payload: message})
+}
+
+// Bruger processen vector clocks? (en proces der er migreret fra Lamport gør)
+func (p *Process) UsesVectorClock() bool {
+	return p.Clock.Now().IsVector()
+}
+
+// Gemmer clock værdien for et nyt event i EventVectors eller EventTimestamps
+func (p *Process) recordClock(clock ClockSnapshot) {
+	if clock.IsVector() {
+		p.EventVectors = append(p.EventVectors, clock.vector)
 	} else {
-		timestamp := p.LamportClock.LocalEvent()
-		p.EventTimestamps = append(p.EventTimestamps, timestamp) 
-		logMsg := fmt.Sprintf("P%d: Local event T%d: %s",
-			p.ID, timestamp, message)
-		p.EventLog = append(p.EventLog, logMsg)
+		p.EventTimestamps = append(p.EventTimestamps, clock.time)
+	}
+	if clock.stamps != nil {
+		p.EventStamps = append(p.EventStamps, clock.stamps)
 	}
 }
 
 // Sender en besked
 func (p *Process) SendMessage(target *Process, message string) {
-	if p.UseVectorClock {
-		vector := p.VectorClock.SendEvent()
-		p.EventVectors = append(p.EventVectors, copyVector(vector)) 
-		logMsg := fmt.Sprintf("P%d: Send to P%d at %s: %s",
-			p.ID, target.ID, FormatVector(vector), message)
-		p.EventLog = append(p.EventLog, logMsg)
-
-		// Send beskeden til target's queue
-		target.MessageQueue <- Event{
-			Type:      "receive",
-			ProcessID: p.ID,
-			Message:   fmt.Sprintf("%s|%s", FormatVector(vector), message),
-		}
-	} else {
-		timestamp := p.LamportClock.SendEvent()
-		p.EventTimestamps = append(p.EventTimestamps, timestamp) 
-		logMsg := fmt.Sprintf("P%d: Send to P%d at T%d: %s",
-			p.ID, target.ID, timestamp, message)
-		p.EventLog = append(p.EventLog, logMsg)
-
-		// Send beskeden til target's queue
-		target.MessageQueue <- Event{
-			Type:      "receive",
-			ProcessID: p.ID,
-			Message:   fmt.Sprintf("%d|%s", timestamp, message),
-		}
+	if p.Crashed() {
+		return
 	}
+	header, id := p.recordSendEvent(target.Label(), message)
+
+	// Send beskeden til target's queue
+	p.send(target, id, header, message, nil)
 }
 
-// Håndterer modtaget af en besked
-func (p *Process) ReceiveMessage(event Event) {
-	var logMsg string
-	
-	if p.UseVectorClock {
-		// Parse vector fra beskeden
-		var receivedVector []int
+// Tikker clocken for et send event til to (modtagerens label), logger det og returnerer
+// clock headeren og et nyt besked ID
+func (p *Process) recordSendEvent(to string, message string) (string, string) {
+	p.messagesSent++
+	id := fmt.Sprintf("P%d-%d", p.ID, p.messagesSent)
 
-		// Extract vector fra besked
-		parts := splitMessage(event.Message)
-		if len(parts) == 2 {
-			receivedVector = parseVector(parts[0])
-		} else {
-			receivedVector = make([]int, len(p.VectorClock.vector))
-		}
+	clock := p.Clock.Send()
+	p.clockChanged(clock)
+	p.stamp(StampedEvent{Type: "send", Clock: clock, MessageID: id, Peer: to, Payload: message})
+	return encodeClockHeader(clock), id
+}
 
-		// Gem tid før receive 
-		beforeVector := p.VectorClock.GetVector()
-		vector := p.VectorClock.ReceiveEvent(receivedVector)
-		p.EventVectors = append(p.EventVectors, copyVector(vector))
+// Tilføjer en linje til event loggen, og til hash kæden hvis den er slået til
+func (p *Process) appendLog(eventType string, messageID string, logMsg string) {
+	p.EventLog = append(p.EventLog, logMsg)
+	p.EventTypes = append(p.EventTypes, eventType)
+	p.EventMessageIDs = append(p.EventMessageIDs, messageID)
+	if p.WallClock != nil {
+		p.EventWallTimes = append(p.EventWallTimes, p.WallClock())
+	}
+	if p.HashChain {
+		i := len(p.EventLog) - 1
+		p.EventHashes = append(p.EventHashes, hashEntry(p.previousHash(i), p.clockAt(i).String(), logMsg))
+	}
+	if p.CheckpointEvery > 0 && p.UsesVectorClock() && len(p.EventLog)%p.CheckpointEvery == 0 {
+		p.TakeCheckpoint()
+	}
+	if p.PersistClock {
+		p.persistedClock = p.Clock.Now()
+	}
+	if p.OnEvent != nil {
+		p.OnEvent(p, p.loggedEvent(len(p.EventLog)-1))
+	}
+}
 
-		// Synkronisering
-		logMsg = fmt.Sprintf("P%d: Receive from P%d (received %s, was %s → synchronized to %s): %s",
-			p.ID, event.ProcessID, FormatVector(receivedVector),
-			FormatVector(beforeVector), FormatVector(vector), parts[1])
-	} else {
-		// Parse lamport timestamp fra beskeden
-		parts := splitMessage(event.Message)
-		receivedTime := 0
-		if len(parts) == 2 {
-			fmt.Sscanf(parts[0], "%d", &receivedTime)
-		}
+// Lægger en besked med clock header i target's queue og tæller beskeder og bytes
+func (p *Process) send(target *Process, messageID string, header string, message string, causal []int) {
+	p.Counters.recordSend(len(header), len(message))
+	linkSeq := 0
+	if _, fifo := p.fifoSent[target.ID]; fifo {
+		p.fifoSent[target.ID]++
+		linkSeq = p.fifoSent[target.ID]
+	}
+	if p.Omission.omitSend(p.faultRand) {
+		p.omitted.send.Add(1)
+		return
+	}
+	env := &Envelope{From: p, To: target, MessageID: messageID, Header: header, Payload: message}
+	if p.sendThrough(env) != nil {
+		return
+	}
+	target.inFlight.Add(1)
+	target.MessageQueue <- Event{
+		Type:       "receive",
+		ProcessID:  p.ID,
+		Message:    env.Header + "|" + env.Payload,
+		SenderName: p.Label(),
+		SentAt:     time.Now(),
+		MessageID:  messageID,
+		causal:     causal,
+		linkSeq:    linkSeq,
+	}
+}
 
-		// Gem tid før receive 
-		beforeTime := p.LamportClock.GetTime()
-		timestamp := p.LamportClock.ReceiveEvent(receivedTime)
-		p.EventTimestamps = append(p.EventTimestamps, timestamp) // Gem timestamp efter receive
+// Håndterer modtaget af en besked. En besked med en ugyldig clock header (fx en vector
+// med forkert længde) afvises med ErrMalformedClock uden at røre uret eller loggen.
+func (p *Process) ReceiveMessage(event Event) error {
+	p.Counters.recordReceive()
+
+	// Parse clock headeren fra beskeden (uden header er der ingen clock at synkronisere med)
+	var received ClockSnapshot
+	payload := event.Message
+	if parts := splitMessage(event.Message); len(parts) == 2 {
+		var err error
+		received, err = parseClockHeaderChecked(parts[0])
+		if err == nil {
+			received = p.padJoinedVector(received)
+			err = checkReceivedClock(p.Clock.Now(), received)
+		}
+		if err != nil {
+			p.Counters.recordRejected()
+			return fmt.Errorf("%s: receive %s from %s: %w", p.Label(), event.MessageID, event.Sender(), err)
+		}
+		payload = parts[1]
+	}
 
-		// Synkronisering
-		logMsg = fmt.Sprintf("P%d: Receive from P%d (received T%d, was T%d → synchronized to T%d): %s",
-			p.ID, event.ProcessID, receivedTime, beforeTime, timestamp, parts[1])
+	// Gem tid før receive (en kopi af hele vectoren, så kun hvis nogen skal bruge den)
+	var before ClockSnapshot
+	sender := ""
+	if p.observesEvents() {
+		before, sender = p.Clock.Now(), event.Sender()
 	}
+	clock := p.Clock.Receive(received)
+	p.clockChanged(clock)
 
-	p.EventLog = append(p.EventLog, logMsg)
+	// Synkronisering
+	p.stamp(StampedEvent{Type: "receive", Clock: clock, MessageID: event.MessageID, Peer: sender,
+		Payload: payload, Received: received, Before: before})
+	return nil
 }
 
 // Splitter en besked
@@ -144,40 +268,111 @@ func splitMessage(message string) []string {
 	return []string{message}
 }
 
-// Parser vector fra string til slice
-func parseVector(vectorStr string) []int {
-	vectorStr = vectorStr[1 : len(vectorStr)-1]
-	parts := make([]string, 0)
-	current := ""
-	for _, ch := range vectorStr {
-		if ch == ',' {
-			parts = append(parts, current)
-			current = ""
-		} else {
-			current += string(ch)
+// Parser vector fra string til slice. Afviser alt FormatVector ikke kunne have lavet:
+// manglende klammer, entries der ikke er tal, og negative entries (bortset fra
+// UnknownEntry, som pruned vector clocks sender for entries de har glemt).
+func parseVector(vectorStr string) ([]int, error) {
+	if len(vectorStr) < 2 || vectorStr[0] != '[' || vectorStr[len(vectorStr)-1] != ']' {
+		return nil, fmt.Errorf("malformed vector %q", vectorStr)
+	}
+	result := make([]int, 0)
+	if body := vectorStr[1 : len(vectorStr)-1]; body != "" {
+		for _, part := range strings.Split(body, ",") {
+			v, err := strconv.Atoi(part)
+			if err != nil || v < UnknownEntry {
+				return nil, fmt.Errorf("bad vector entry %q in %q", part, vectorStr)
+			}
+			result = append(result, v)
 		}
 	}
-	if current != "" {
-		parts = append(parts, current)
+	return result, nil
+}
+
+// Leverer en besked (efter evt. processing delay), medmindre processen er crashet, eller
+// en receive omission, middleware kæden, OnDeliver hooken eller en ugyldig clock header
+// opsnapper den
+func (p *Process) deliver(event Event) {
+	defer p.inFlight.Add(-1)
+	if p.ProcessingDelay != nil {
+		time.Sleep(p.ProcessingDelay(p.faultRand))
+	}
+	if p.Crashed() {
+		event.acknowledge(DeliveryReceipt{}, ErrProcessCrashed)
+		return
+	}
+	if p.Omission.omitReceive(p.faultRand) {
+		p.omitted.receive.Add(1)
+		event.acknowledge(DeliveryReceipt{}, ErrMessageDropped)
+		return
 	}
+	if len(p.Middleware) > 0 {
+		if parts := splitMessage(event.Message); len(parts) == 2 {
+			env := &Envelope{To: p, MessageID: event.MessageID, Header: parts[0], Payload: parts[1]}
+			if err := p.receiveThrough(env); err != nil {
+				event.acknowledge(DeliveryReceipt{}, err)
+				return
+			}
+			event.Message = env.Header + "|" + env.Payload
+		}
+	}
+	if p.OnDeliver != nil && !p.OnDeliver(p, event) {
+		event.acknowledge(DeliveryReceipt{}, ErrMessageDropped)
+		return
+	}
+	if event.linkSeq > 0 {
+		p.deliverFIFO(event)
+		return
+	}
+	p.release(event)
+}
 
-	// Convert til ints
-	result := make([]int, len(parts))
-	for i, part := range parts {
-		fmt.Sscanf(part, "%d", &result[i])
+// Leverer en besked der er i orden på sit link: broadcasts går gennem causal bufferen,
+// alt andet modtages med det samme
+func (p *Process) release(event Event) {
+	if p.causalBuffer != nil && event.causal != nil {
+		p.deliverCausally(event)
+		return
 	}
+	p.accept(event)
+}
 
-	return result
+// Modtager en besked der er klar til at blive leveret: opdaterer uret, svarer en evt.
+// SendMessageSync og giver payloaden til processens Behavior
+func (p *Process) accept(event Event) {
+	if err := p.ReceiveMessage(event); err != nil {
+		event.acknowledge(DeliveryReceipt{}, err)
+		return
+	}
+	if event.receipt != nil {
+		event.acknowledge(DeliveryReceipt{
+			Target:      p.Label(),
+			SentAt:      event.SentAt,
+			DeliveredAt: time.Now(),
+			Clock:       p.Clock.Now(),
+		}, nil)
+	}
+	if p.Behavior != nil {
+		parts := splitMessage(event.Message)
+		p.Behavior.OnReceive(p, event.ProcessID, parts[len(parts)-1])
+	}
 }
 
 // Starter processen og lytter efter beskeder
 func (p *Process) Run(done chan bool) {
+	p.runLoops.Add(1)
 	go func() {
+		defer p.runLoops.Add(-1)
+		if p.OnStart != nil {
+			p.OnStart(p)
+		}
 		for {
 			select {
 			case event := <-p.MessageQueue:
-				p.ReceiveMessage(event)
+				p.deliver(event)
 			case <-done:
+				if p.OnStop != nil {
+					p.OnStop(p)
+				}
 				return
 			case <-time.After(100 * time.Millisecond):
 				continue
@@ -188,137 +383,186 @@ func (p *Process) Run(done chan bool) {
 
 // Simulation struct initilization
 type Simulation struct {
-	Processes      []*Process
-	UseVectorClock bool
+	Processes []*Process
+	Rand      *rand.Rand // Simulationens egen RNG, bruges kun fra den goroutine der driver workloaden
+	Out       io.Writer  // Hvor logs og rapporter skrives (stdout som standard)
+	newClock  func(id int, numProcesses int) LogicalClock // Ur til processer fra AddProcess (nil = Lamport eller vector som de andre)
+	Membership *MembershipTable // Processernes stabile ID'er og deres plads i vectors
+	TimeMode   TimeMode         // Om schedulere til simulationen kører i virtuel tid (standard) eller rigtig tid
+	eventHooks []EventHook      // Hooks fra AddEventHook, som også gives til processer fra AddProcess
+	faultSeed  int64            // Seedet processernes fault RNG er afledt af (se seedFaults)
 }
 
 // Ny simulation
 func NewSimulation(numProcesses int, useVectorClock bool) *Simulation {
 	processes := make([]*Process, numProcesses)
+	membership := NewMembershipTable()
 	for i := 0; i < numProcesses; i++ {
 		processes[i] = NewProcess(i, numProcesses, useVectorClock)
+		processes[i].UID = newProcessUID()
+		membership.Join(processes[i].UID)
 	}
 
-	return &Simulation{
-		Processes:      processes,
-		UseVectorClock: useVectorClock,
+	sim := &Simulation{
+		Processes: processes,
+		Rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		Out:       os.Stdout,
+		Membership: membership,
+	}
+	for _, p := range processes {
+		p.sim = sim
 	}
+	sim.seedFaults(time.Now().UnixNano())
+	return sim
 }
 
-// Kører scenario 
-func (sim *Simulation) RunScenario() {
-	// Start alle processer
-	done := make(chan bool)
+// Ny simulation hvor hver proces får det ur newClock laver, fx en egen LogicalClock
+func NewSimulationWithClocks(numProcesses int, newClock func(id int, numProcesses int) LogicalClock) *Simulation {
+	sim := NewSimulation(numProcesses, false)
 	for _, p := range sim.Processes {
-		p.Run(done)
+		p.Clock = newClock(p.ID, numProcesses)
 	}
+	sim.newClock = newClock
+	return sim
+}
 
-	time.Sleep(50 * time.Millisecond)
+// Bruger simulationen vector clocks?
+func (sim *Simulation) UsesVectorClock() bool {
+	return len(sim.Processes) > 0 && sim.Processes[0].UsesVectorClock()
+}
 
-	// Scenario: En række events der viser causal relationships
-	fmt.Println("\n=== Running Scenario ===")
+// Giver simulationen en fast seed så workloads kan genskabes
+func (sim *Simulation) Seed(seed int64) {
+	sim.Rand = rand.New(rand.NewSource(seed))
+	sim.seedFaults(seed)
+}
 
-	// Begynd med events på alle processer 
-	fmt.Println("Phase 1: Initial local events (processer arbejder uafhængigt)")
-	sim.Processes[0].HandleLocalEvent("Initialize P0")
-	sim.Processes[1].HandleLocalEvent("Initialize P1")
-	sim.Processes[2].HandleLocalEvent("Initialize P2")
-	time.Sleep(10 * time.Millisecond)
+// Ny simulation hvor processerne har navne (én proces per navn)
+func NewNamedSimulation(names []string, useVectorClock bool) *Simulation {
+	sim := NewSimulation(len(names), useVectorClock)
+	sim.Membership = NewMembershipTable(names...)
+	for i, name := range names {
+		sim.Processes[i].Name = name
+		sim.Processes[i].UID = name
+	}
+	return sim
+}
 
-	// Flere initial events
-	sim.Processes[1].HandleLocalEvent("P1 local work")
-	sim.Processes[2].HandleLocalEvent("P2 local work")
-	time.Sleep(10 * time.Millisecond)
+// Finder en proces ud fra navn eller P<id> (nil hvis den ikke findes)
+func (sim *Simulation) ProcessByName(name string) *Process {
+	for _, p := range sim.Processes {
+		if p.Name == name || fmt.Sprintf("P%d", p.ID) == name {
+			return p
+		}
+	}
+	return nil
+}
 
-	// Kommunikation begynder
-	fmt.Println("Phase 2: Communication starts")
-	sim.Processes[0].HandleLocalEvent("Event A")
-	time.Sleep(10 * time.Millisecond)
+// Kører scenario på en deterministisk scheduler (samme seed via sim.Seed giver samme logs)
+// og retuner hvor lang simuleret tid det tog
+func (sim *Simulation) RunScenario() time.Duration {
+	fmt.Fprintln(sim.Out, "\n=== Running Scenario ===")
+	s := sim.scheduleScenario()
+	s.Run()
 
-	// P0 sender til P1
-	sim.Processes[0].SendMessage(sim.Processes[1], "Message from P0")
-	time.Sleep(20 * time.Millisecond)
+	// Print event logs
+	sim.PrintLogs()
+	sim.QueryClocks().Fprint(sim.Out)
+	return s.Now
+}
 
-	// P1 har et lokalt event EFTER at have modtaget
-	sim.Processes[1].HandleLocalEvent("Event B")
-	time.Sleep(10 * time.Millisecond)
+// Planlægger demo scenariet på en ny scheduler uden at køre det
+func (sim *Simulation) scheduleScenario() *Scheduler {
+	s := NewScheduler(sim, sim.Rand.Int63())
+	ms := time.Millisecond
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
 
-	// P1 sender til P2
-	sim.Processes[1].SendMessage(sim.Processes[2], "Message from P1")
-	time.Sleep(20 * time.Millisecond)
+	// Scenario: En række events der viser causal relationships
 
-	// P2 har et lokalt event EFTER at have modtaget
-	sim.Processes[2].HandleLocalEvent("Event C")
-	time.Sleep(10 * time.Millisecond)
+	// Begynd med events på alle processer
+	s.Println(0, "Phase 1: Initial local events (processer arbejder uafhængigt)")
+	s.Local(0, p0, "Initialize P0")
+	s.Local(0, p1, "Initialize P1")
+	s.Local(0, p2, "Initialize P2")
 
-	// P2 sender til P0 (skaber en cycle)
-	sim.Processes[2].SendMessage(sim.Processes[0], "Message from P2")
-	time.Sleep(30 * time.Millisecond)
+	// Flere initial events
+	s.Local(10*ms, p1, "P1 local work")
+	s.Local(10*ms, p2, "P2 local work")
 
-	// P0 og P2 har concurrent local events
-	go sim.Processes[0].HandleLocalEvent("Event D")
-	go sim.Processes[2].HandleLocalEvent("Event E")
-	time.Sleep(20 * time.Millisecond)
+	// Kommunikation begynder
+	s.Println(20*ms, "Phase 2: Communication starts")
+	s.Local(20*ms, p0, "Event A")
 
-	// Stop alle processer
-	close(done)
-	time.Sleep(50 * time.Millisecond)
+	// P0 sender til P1, og P1 har et lokalt event EFTER at have modtaget
+	s.Send(30*ms, p0, p1, "Message from P0")
+	s.Local(50*ms, p1, "Event B")
 
-	// Print event logs
-	sim.PrintLogs()
+	// P1 sender til P2, og P2 har et lokalt event EFTER at have modtaget
+	s.Send(60*ms, p1, p2, "Message from P1")
+	s.Local(80*ms, p2, "Event C")
+
+	// P2 sender til P0 (skaber en cycle)
+	s.Send(90*ms, p2, p0, "Message from P2")
+
+	// P0 og P2 har concurrent local events på samme simulerede tid (seed'en afgør rækkefølgen)
+	s.Local(120*ms, p0, "Event D")
+	s.Local(120*ms, p2, "Event E")
+	return s
 }
 
 // Printer event logs fra alle processer
 func (sim *Simulation) PrintLogs() {
-	fmt.Println("\n=== Event Logs ===")
+	fmt.Fprintln(sim.Out, "\n=== Event Logs ===")
 	for _, p := range sim.Processes {
-		fmt.Printf("\nProcess %d:\n", p.ID)
+		fmt.Fprintf(sim.Out, "\n%s:\n", processHeader(p))
 		for _, log := range p.EventLog {
-			fmt.Println("  " + log)
+			fmt.Fprintln(sim.Out, "  " + log)
 		}
 	}
 }
 
+// Overskrift for en proces i log output, fx "Process 1 (frontend)"
+func processHeader(p *Process) string {
+	if p.Name != "" {
+		return fmt.Sprintf("Process %d (%s)", p.ID, p.Name)
+	}
+	return fmt.Sprintf("Process %d", p.ID)
+}
+
 // Returnerer clock type (Lamport eller vector )
 func (sim *Simulation) GetClockType() string {
-	if sim.UseVectorClock {
+	if sim.UsesVectorClock() {
 		return "Vector Clock"
 	}
+	if len(sim.Processes) > 0 && sim.Processes[0].Clock.Now().IsHybrid() {
+		return "Hybrid Logical Clock"
+	}
 	return "Lamport Clock"
 }
 
-// Kør scenario med concurrency
+// Kør scenario med concurrency på en deterministisk scheduler
 func (sim *Simulation) RunConcurrentScenario() {
-	// Start alle processer
-	done := make(chan bool)
-	for _, p := range sim.Processes {
-		p.Run(done)
-	}
-	time.Sleep(10 * time.Millisecond)
+	s := NewScheduler(sim, sim.Rand.Int63())
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
 
-	// P1 og P2 laver lokale events 
+	// P1 og P2 laver lokale events
 	for i := 0; i < 5; i++ {
-		sim.Processes[1].HandleLocalEvent(fmt.Sprintf("Work-%d", i+1))
-		sim.Processes[2].HandleLocalEvent(fmt.Sprintf("Work-%d", i+1))
+		at := time.Duration(i) * time.Millisecond
+		s.Local(at, p1, fmt.Sprintf("Work-%d", i+1))
+		s.Local(at, p2, fmt.Sprintf("Work-%d", i+1))
 	}
 
 	// Send beskeder samtidigt til P0
-	sim.Processes[1].SendMessage(sim.Processes[0], "Data from P1")
-	sim.Processes[2].SendMessage(sim.Processes[0], "Data from P2")
-
-	// Vent på at beskeder modtages
-	time.Sleep(50 * time.Millisecond)
-
-	// Stop processer
-	close(done)
-	time.Sleep(20 * time.Millisecond)
+	s.Send(5*time.Millisecond, p1, p0, "Data from P1")
+	s.Send(5*time.Millisecond, p2, p0, "Data from P2")
+	s.Run()
 }
 
 // Printer de sidste n events fra hver proces
 func (sim *Simulation) PrintRecentLogs(n int) {
-	fmt.Println("\n=== Event Logs (Recent) ===")
+	fmt.Fprintln(sim.Out, "\n=== Event Logs (Recent) ===")
 	for _, p := range sim.Processes {
-		fmt.Printf("\nProcess %d:\n", p.ID)
+		fmt.Fprintf(sim.Out, "\n%s:\n", processHeader(p))
 
 		startIdx := 0
 		if len(p.EventLog) > n {
@@ -326,68 +570,30 @@ func (sim *Simulation) PrintRecentLogs(n int) {
 		}
 
 		for i := startIdx; i < len(p.EventLog); i++ {
-			fmt.Println("  " + p.EventLog[i])
+			fmt.Fprintln(sim.Out, "  " + p.EventLog[i])
 		}
 	}
 }
 
-// DemonstrateConcurrentMessages viser hvordan Lamport og Vector clocks håndterer
+// DemonstrateConcurrentMessages viser hvordan Lamport, Vector og HLC håndterer
 // concurrent message arrival - en kritisk situation hvor to beskeder sendes samtidigt
-func DemonstrateConcurrentMessages() {
-	fmt.Println("\nScenario:")
-	fmt.Println("  • 3 processer: P0, P1, P2")
-	fmt.Println("  • P1 og P2 udfører hver 5 local events")
-	fmt.Println("  • Derefter sender både P1 og P2 en besked til P0 SAMTIDIGT")
-	fmt.Println("  • Vi observerer hvordan hver clock type håndterer dette")
-
-	// Lamport Clock
-	fmt.Println("\n" + strings.Repeat("═", 64))
-	fmt.Println("Part 1: Lamport Clock")
-	fmt.Println(strings.Repeat("═", 64))
-
-	lamportSim := NewSimulation(3, false)
-
-	fmt.Println("\nPhase 1: Setup - P1 and P2 perform local events")
-	fmt.Println("Phase 2: Concurrent message sending")
-	fmt.Println("P1 og P2 sender SAMTIDIGT beskeder til P0")
-
-	lamportSim.RunConcurrentScenario()
-	lamportSim.PrintRecentLogs(3)
-
-	fmt.Println("\n=== Analysis ===")
-	fmt.Println("Observation: Begge beskeder sendes med timestamp T=6")
-	fmt.Println("Problem: Lamport clock kan ikke skelne mellem:")
-	fmt.Println("  1. M1 happened-before M2")
-	fmt.Println("  2. M2 happened-before M1")
-	fmt.Println("  3. M1 and M2 are concurrent (korrekt svar)")
-	fmt.Println("Konsekvens: Må bruge tie-breaker (fx process ID) for ordering")
-
-	// Vector Clock
-	fmt.Println("\n" + strings.Repeat("═", 64))
-	fmt.Println("Part 2: Vector Clock")
-	fmt.Println(strings.Repeat("═", 64))
-
-	vectorSim := NewSimulation(3, true)
-
-	fmt.Println("\nPhase 1: Setup - P1 and P2 perform local events")
-	fmt.Println("Phase 2: Concurrent message sending")
-	fmt.Println("P1 og P2 sender SAMTIDIGT beskeder til P0")
-
-	vectorSim.RunConcurrentScenario()
-	vectorSim.PrintRecentLogs(3)
-
-	fmt.Println("\n=== Analysis ===")
-	fmt.Println("Observation: Vector clocks viser:")
-	fmt.Println("  • P1's besked: [0,6,0] - kun P1 har kørt events")
-	fmt.Println("  • P2's besked: [0,0,6] - kun P2 har kørt events")
-	fmt.Println("Konklusion: Ingen af vektorene dominerer den anden")
-	fmt.Println("Resultat: Vector clock detekterer korrekt at beskederne er CONCURRENT")
-
-	fmt.Println("\n" + strings.Repeat("═", 64))
-	fmt.Println("Key Takeaway:")
-	fmt.Println("  Lamport: Kan ikke detektere concurrency → kræver tie-breaker")
-	fmt.Println("  Vector:  Detekterer concurrency præcist → ordner kun ved causality")
-	fmt.Println(strings.Repeat("═", 64))
+func DemonstrateConcurrentMessages(out io.Writer) {
+	fmt.Fprintln(out, "\nScenario:")
+	fmt.Fprintln(out, "  • 3 processer: P0, P1, P2")
+	fmt.Fprintln(out, "  • P1 og P2 udfører hver 5 local events")
+	fmt.Fprintln(out, "  • Derefter sender både P1 og P2 en besked til P0 SAMTIDIGT")
+	fmt.Fprintln(out, "  • Vi observerer hvordan hver clock type håndterer dette")
+
+	for _, clockType := range clockTypes {
+		ConcurrencyDemo{ClockType: clockType, Narration: NarrationNormal, Out: out, Seed: 1}.Run()
+	}
+
+	fmt.Fprintln(out, "\n" + strings.Repeat("═", 64))
+	fmt.Fprintln(out, "Key Takeaway:")
+	fmt.Fprintln(out, "  Lamport: Kan ikke detektere concurrency → kræver tie-breaker")
+	fmt.Fprintln(out, "  HLC:     Ordner efter (fysisk tid, tæller) → samme begrænsning som Lamport")
+	fmt.Fprintln(out, "  Vector:  Detekterer concurrency præcist → ordner kun ved causality")
+	fmt.Fprintln(out, strings.Repeat("═", 64))
 }
 
 // Retuner kopi af vector
@@ -0,0 +1,3147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tester at lifecycle hooks kaldes og at OnDeliver kan droppe beskeder
+func TestProcessLifecycleHooks(t *testing.T) {
+	sim := NewSimulation(2, false)
+	receiver := sim.Processes[1]
+
+	var mutex sync.Mutex
+	calls := make([]string, 0)
+	record := func(call string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, call)
+	}
+
+	stopped := make(chan bool)
+	receiver.OnStart = func(p *Process) { record("start") }
+	receiver.OnStop = func(p *Process) {
+		record("stop")
+		close(stopped)
+	}
+	deliveries := 0
+	receiver.OnDeliver = func(p *Process, event Event) bool {
+		record("deliver")
+		deliveries++
+		return deliveries == 1 // Drop alt efter første levering
+	}
+
+	done := make(chan bool)
+	receiver.Run(done)
+	sim.Processes[0].SendMessage(receiver, "first")
+	sim.Processes[0].SendMessage(receiver, "second")
+	if err := sim.WaitUntilIdle(); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	<-stopped
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expected := []string{"start", "deliver", "deliver", "stop"}
+	if len(calls) != len(expected) {
+		t.Fatalf("Forventede hooks %v, fik %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Fatalf("Forventede hooks %v, fik %v", expected, calls)
+		}
+	}
+	if len(receiver.EventLog) != 1 {
+		t.Errorf("Kun første besked skulle leveres, men loggen har %d events", len(receiver.EventLog))
+	}
+}
+
+// Tester at procesnavne bruges i loggen
+func TestNamedProcesses(t *testing.T) {
+	sim := NewNamedSimulation([]string{"frontend", "db-1"}, false)
+	frontend := sim.ProcessByName("frontend")
+	db := sim.ProcessByName("db-1")
+	if frontend == nil || db == nil || sim.ProcessByName("P1") != db {
+		t.Fatalf("ProcessByName skulle finde processer via navn og label")
+	}
+
+	frontend.SendMessage(db, "query")
+	db.ReceiveMessage(<-db.MessageQueue)
+
+	expected := "db-1: Receive from frontend (received T1, was T0 → synchronized to T2): query"
+	if db.EventLog[0] != expected {
+		t.Errorf("Forventede log %q, fik %q", expected, db.EventLog[0])
+	}
+}
+
+// Tester skew og divergens i cluster rapporten
+func TestQueryClocks(t *testing.T) {
+	lamportSim := NewSimulation(3, false)
+	lamportSim.Processes[0].HandleLocalEvent("a")
+	lamportSim.Processes[0].HandleLocalEvent("b")
+	lamportSim.Processes[2].HandleLocalEvent("c")
+	if skew := lamportSim.QueryClocks().MaxSkew; skew != 2 {
+		t.Errorf("Forventede Lamport skew 2, fik %d", skew)
+	}
+
+	vectorSim := NewSimulation(3, true)
+	vectorSim.Processes[0].HandleLocalEvent("a")
+	vectorSim.Processes[1].HandleLocalEvent("b")
+	vectorSim.Processes[1].HandleLocalEvent("c")
+	report := vectorSim.QueryClocks()
+	if report.Divergence[0][1] != 3 || report.MaxDiverged != [2]int{0, 1} {
+		t.Errorf("Forventede divergens 3 mellem P0 og P1, fik %v", report.Divergence)
+	}
+}
+
+// Tester at beskeder og bytes tælles
+func TestMessageStats(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Processes[0].SendMessage(sim.Processes[1], "hello")
+	sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+
+	stats := sim.MessageStats()
+	if stats.Sent != 1 || stats.Received != 1 {
+		t.Errorf("Forventede 1 sendt og 1 modtaget, fik %+v", stats)
+	}
+	if stats.HeaderBytes != int64(len("[1,0]")) || stats.PayloadBytes != int64(len("hello")) {
+		t.Errorf("Forkerte byte tællere: %+v", stats)
+	}
+	if msgs, _ := stats.PerOperation(2); msgs != 0.5 {
+		t.Errorf("Forventede 0.5 beskeder per operation, fik %.2f", msgs)
+	}
+
+	// En broadcast er leveret når alle andre har den
+	broadcast := NewSimulation(3, true)
+	broadcast.Out = io.Discard
+	broadcast.Processes[0].Broadcast("a")
+	broadcast.Processes[1].ReceiveMessage(<-broadcast.Processes[1].MessageQueue)
+	if n := DeliveredBroadcasts(broadcast); n != 0 {
+		t.Errorf("Forventede 0 leverede broadcasts før P2 har den, fik %d", n)
+	}
+	broadcast.Processes[2].ReceiveMessage(<-broadcast.Processes[2].MessageQueue)
+	if n := DeliveredBroadcasts(broadcast); n != 1 {
+		t.Errorf("Forventede 1 leveret broadcast, fik %d", n)
+	}
+
+	room := runChatConversation(true)
+	if stats, n := room.MessageStats(), room.DeliveredMulticasts(); stats.Sent != 6 || stats.Received != 6 || n != 3 {
+		t.Errorf("Forventede 6 beskeder for 3 leverede multicasts, fik %+v og %d", stats, n)
+	}
+
+	var out bytes.Buffer
+	PrintMessageStats(&out, "Chat", room.MessageStats(), 3, "delivered multicast")
+	if !strings.Contains(out.String(), "per delivered multicast: 2.00 messages") {
+		t.Errorf("Uventet output: %s", out.String())
+	}
+}
+
+// Tester at hash kæden opdager ændringer i loggen
+func TestHashChain(t *testing.T) {
+	sim := NewSimulation(2, false)
+	sim.EnableHashChain()
+	sim.Processes[0].HandleLocalEvent("a")
+	sim.Processes[0].SendMessage(sim.Processes[1], "b")
+	sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+
+	if err := sim.VerifyHashChains(); err != nil {
+		t.Fatalf("Uændret log skulle verificere, fik %v", err)
+	}
+
+	sim.Processes[0].EventTimestamps[0] = 7
+	if err := sim.Processes[0].VerifyHashChain(); err == nil {
+		t.Errorf("Ændret timestamp skulle give en fejl")
+	}
+}
+
+// Tester syslog og CEF eksport af clock felter
+func TestExportLogs(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Processes[0].SendMessage(sim.Processes[1], "a=b")
+	sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+
+	var syslog, cef strings.Builder
+	if err := ExportSyslog(&syslog, sim); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportCEF(&cef, sim); err != nil {
+		t.Fatal(err)
+	}
+
+	syslogLines := strings.Split(strings.TrimSpace(syslog.String()), "\n")
+	if len(syslogLines) != 2 || !strings.Contains(syslogLines[1], `vector_clock="[1,1\]"`) {
+		t.Errorf("Uventet syslog output:\n%s", syslog.String())
+	}
+	if !strings.Contains(cef.String(), `cs1Label=vector_clock cs1=[1,0] cs2Label=message_id cs2=P0-1 msg=P0: Send to P1 at [1,0]: a\=b`) {
+		t.Errorf("Uventet CEF output:\n%s", cef.String())
+	}
+}
+
+// Tester causality matrixen for en simpel kæde P0 → P1
+func TestCausalityMatrix(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Processes[0].HandleLocalEvent("a")
+	sim.Processes[0].SendMessage(sim.Processes[1], "b")
+	sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+
+	matrix := CausalityMatrix(sim)
+	// P0: a, send. P1: receive. a → send, a → receive, send → receive
+	if matrix[0][0] != 1 || matrix[0][1] != 2 || matrix[1][0] != 0 || matrix[1][1] != 0 {
+		t.Errorf("Uventet matrix %v", matrix)
+	}
+}
+
+// Tester staleness: P1 ved ikke noget om P0's events før den modtager en besked
+func TestSampleStaleness(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Processes[0].HandleLocalEvent("a")
+	sim.Processes[0].HandleLocalEvent("b")
+
+	if s := sim.SampleStaleness(); s[1][0] != 2 || s[0][1] != 0 {
+		t.Errorf("P1 skulle være 2 events bagud om P0, fik %v", s)
+	}
+
+	sim.Processes[0].SendMessage(sim.Processes[1], "sync")
+	sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+	if s := sim.SampleStaleness(); s[1][0] != 0 || s[0][1] != 1 {
+		t.Errorf("Efter sync skulle P1 være opdateret og P0 1 bagud, fik %v", s)
+	}
+
+	// P0 læst før dens events men P1 efter sync: skævheden må ikke give negativ staleness
+	sim.Processes[0].Clock = NewVectorClock(2, 0)
+	if s := sim.SampleStaleness(); s[1][0] != 0 {
+		t.Errorf("Staleness skulle rundes op til 0, fik %v", s)
+	}
+}
+
+// Tester at migration kræver en tom kø og at epochs ordner events på tværs af skiftet
+func TestMigrateToVectorClocks(t *testing.T) {
+	sim := NewSimulation(2, false)
+	sim.EnableHashChain()
+	sim.Processes[0].SendMessage(sim.Processes[1], "old")
+	if err := sim.MigrateToVectorClocks(); err == nil {
+		t.Fatalf("Migration med uleverede beskeder skulle fejle")
+	}
+
+	sim.deliverPending()
+	if err := sim.MigrateToVectorClocks(); err != nil {
+		t.Fatalf("Migration efter barrier fejlede: %v", err)
+	}
+	sim.Processes[1].HandleLocalEvent("new")
+
+	p1 := sim.Processes[1]
+	before, after := p1.epochTimestampAt(0), p1.epochTimestampAt(1)
+	if before.Epoch != 0 || after.Epoch != 1 || before.Compare(after) != -1 {
+		t.Errorf("Forventede %s før %s", before, after)
+	}
+	if after.Clock.String() != "[0,1]" {
+		t.Errorf("Første vector event skulle være [0,1], fik %s", after.Clock)
+	}
+	if err := sim.VerifyHashChains(); err != nil {
+		t.Errorf("Hash kæden skulle stadig verificere efter migration: %v", err)
+	}
+}
+
+// Tester at concurrent og forældede skrivninger afvises
+func TestVersionedResource(t *testing.T) {
+	resource := NewVersionedResource(2, "v0")
+	server := httptest.NewServer(resource)
+	defer server.Close()
+
+	alice := &ETagClient{ID: 0, URL: server.URL, Counters: &MessageCounters{}, vector: make([]int, 2)}
+	bob := &ETagClient{ID: 1, URL: server.URL, vector: make([]int, 2)}
+	alice.Get()
+	bob.Get()
+
+	if status, _ := alice.Put("a"); status != http.StatusOK {
+		t.Errorf("Alice's første skrivning skulle accepteres, fik %d", status)
+	}
+	// GET og PUT med svar; ETag'en i GET svaret, If-Match og ETag'en i PUT svaret er headere
+	if stats := alice.Counters.Snapshot(); stats.Sent != 4 || stats.Received != 2 || stats.HeaderBytes != 3*int64(len(`"[1,0]"`)) {
+		t.Errorf("Forkerte tællere for Alice: %+v", stats)
+	}
+	if status, _ := bob.Put("b"); status != http.StatusConflict {
+		t.Errorf("Bob's concurrent skrivning skulle give 409, fik %d", status)
+	}
+	if FormatVector(bob.vector) != "[0,0]" {
+		t.Errorf("En afvist skrivning må ikke tælles i Bob's vector, fik %s", FormatVector(bob.vector))
+	}
+	bob.Get()
+	if status, _ := bob.Put("b"); status != http.StatusOK {
+		t.Errorf("Bob's skrivning efter merge skulle accepteres, fik %d", status)
+	}
+
+	stale := &ETagClient{ID: 0, URL: server.URL, vector: []int{0, 0}}
+	if status, _ := stale.Put("old"); status != http.StatusPreconditionFailed {
+		t.Errorf("Forældet skrivning skulle give 412, fik %d", status)
+	}
+	if value, _ := resource.Current(); value != "b" {
+		t.Errorf("Forventede værdien b, fik %q", value)
+	}
+
+	// Manglende If-Match giver 428, en ugyldig 400
+	for ifMatch, want := range map[string]int{
+		"":          http.StatusPreconditionRequired,
+		`"[1,a]"`:   http.StatusBadRequest,
+		`[1,1]`:     http.StatusBadRequest,
+		`""[1,1]"`:  http.StatusBadRequest,
+		`"[1,1"`:    http.StatusBadRequest,
+		`"[1,1,1]"`: http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("bad"))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("If-Match %q skulle give %d, fik %d", ifMatch, want, resp.StatusCode)
+		}
+	}
+	if value, _ := resource.Current(); value != "b" {
+		t.Errorf("En ugyldig skrivning må ikke ændre værdien, fik %q", value)
+	}
+}
+
+func TestConflictResolvers(t *testing.T) {
+	current := Version{Value: "milk,eggs", Vector: []int{2, 0}, Writer: 0}
+	incoming := Version{Value: "milk,bread", Vector: []int{1, 1}, Writer: 1}
+
+	if _, err := (RejectResolver{}).Resolve(current, incoming); err == nil {
+		t.Error("Reject skulle returnere en fejl")
+	}
+	if versions, _ := (MultiValueResolver{}).Resolve(current, incoming); len(versions) != 2 {
+		t.Errorf("Multi-value skulle bevare 2 versioner, fik %d", len(versions))
+	}
+
+	merged, _ := MergeResolver{Merge: mergeSets}.Resolve(current, incoming)
+	if merged[0].Value != "bread,eggs,milk" || FormatVector(merged[0].Vector) != "[2,1]" {
+		t.Errorf("Forkert merge: %q %v", merged[0].Value, merged[0].Vector)
+	}
+
+	// Lige mange events: højeste writer ID vinder
+	lww, _ := LWWResolver{}.Resolve(current, incoming)
+	if lww[0].Value != "milk,bread" || FormatVector(lww[0].Vector) != "[2,1]" {
+		t.Errorf("Forkert LWW vinder: %q %v", lww[0].Value, lww[0].Vector)
+	}
+
+	if _, err := ResolverByName("coin-flip"); err == nil {
+		t.Error("Ukendt strategi skulle give en fejl")
+	}
+}
+
+func TestVersionedResourceResolver(t *testing.T) {
+	resource := NewVersionedResource(2, "milk")
+	resource.Resolver = MultiValueResolver{}
+	server := httptest.NewServer(resource)
+	defer server.Close()
+
+	alice := &ETagClient{ID: 0, URL: server.URL, vector: make([]int, 2)}
+	bob := &ETagClient{ID: 1, URL: server.URL, vector: make([]int, 2)}
+	alice.Get()
+	bob.Get()
+	alice.Put("a")
+	if status, _ := bob.Put("b"); status != http.StatusOK {
+		t.Errorf("Multi-value skulle acceptere concurrent skrivning, fik %d", status)
+	}
+	if siblings := resource.Siblings(); len(siblings) != 2 {
+		t.Errorf("Forventede 2 siblings, fik %d", len(siblings))
+	}
+	if _, etag := resource.Current(); etag != `"[1,1]"` {
+		t.Errorf("Forventede ETag [1,1], fik %s", etag)
+	}
+}
+
+func TestHistoryExport(t *testing.T) {
+	resource := NewVersionedResource(2, "v0")
+	server := httptest.NewServer(resource)
+	defer server.Close()
+
+	history := NewHistory()
+	alice := &ETagClient{ID: 0, URL: server.URL, History: history, vector: make([]int, 2)}
+	bob := &ETagClient{ID: 1, URL: server.URL, History: history, vector: make([]int, 2)}
+	alice.Get()
+	bob.Get()
+	alice.Put("a")
+	bob.Put("b") // concurrent: afvises
+
+	if verdicts := resource.Verdicts(history); !verdicts[0].Held || verdicts[1].Held || !strings.Contains(verdicts[1].Detail, "client 1") {
+		t.Errorf("Forventede ingen lost update og at Bob aldrig fik en skrivning igennem, fik %+v", verdicts)
+	}
+	resource.stored = append(resource.stored, Version{Value: "b", Vector: []int{0, 1}, Writer: 1})
+	if verdicts := resource.Verdicts(history); verdicts[0].Held {
+		t.Errorf("En version der ikke indeholder den forrige er en lost update, fik %+v", verdicts)
+	}
+
+	ops := history.Operations()
+	if len(ops) != 4 {
+		t.Fatalf("Forventede 4 operationer, fik %d", len(ops))
+	}
+	if !ops[2].OK || ops[3].OK {
+		t.Errorf("Alice's skrivning skulle lykkes og Bob's fejle: %+v %+v", ops[2], ops[3])
+	}
+	for _, op := range ops {
+		if op.Return < op.Call {
+			t.Errorf("Return før call: %+v", op)
+		}
+	}
+
+	var knossos, porcupine strings.Builder
+	WriteKnossosJSON(&knossos, ops)
+	WritePorcupineJSON(&porcupine, ops)
+	if strings.Count(knossos.String(), `"type": "invoke"`) != 4 || !strings.Contains(knossos.String(), `"type": "fail"`) {
+		t.Errorf("Forkert knossos historik:\n%s", knossos.String())
+	}
+	if !strings.Contains(porcupine.String(), `"client_id": 1`) || !strings.Contains(porcupine.String(), `"return":`) {
+		t.Errorf("Forkert porcupine historik:\n%s", porcupine.String())
+	}
+
+	// En skrivning hvor svaret går tabt har ukendt udfald, ikke "fail"
+	server.Close()
+	if _, err := alice.Put("lost"); err == nil {
+		t.Fatal("Forventede en transportfejl mod en lukket server")
+	}
+	lost := history.Operations()[4]
+	if !lost.Unknown || lost.OK {
+		t.Errorf("Skrivningen skulle have ukendt udfald: %+v", lost)
+	}
+	knossos.Reset()
+	porcupine.Reset()
+	WriteKnossosJSON(&knossos, []Operation{lost})
+	WritePorcupineJSON(&porcupine, []Operation{lost})
+	if !strings.Contains(knossos.String(), `"type": "info"`) || strings.Contains(knossos.String(), `"type": "fail"`) {
+		t.Errorf("Ukendt udfald skulle eksporteres som info:\n%s", knossos.String())
+	}
+	if !strings.Contains(porcupine.String(), `"return": 9223372036854775807`) {
+		t.Errorf("Ukendt udfald skulle have return tiden uendelig:\n%s", porcupine.String())
+	}
+}
+
+func TestDelayModels(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	if d := FixedDelay(3 * time.Millisecond)(r); d != 3*time.Millisecond {
+		t.Errorf("FixedDelay gav %v", d)
+	}
+	uniform := UniformDelay(time.Millisecond, 2*time.Millisecond)
+	for i := 0; i < 100; i++ {
+		if d := uniform(r); d < time.Millisecond || d > 2*time.Millisecond {
+			t.Fatalf("UniformDelay uden for intervallet: %v", d)
+		}
+	}
+	reversed := UniformDelay(2*time.Millisecond, time.Millisecond)
+	for i := 0; i < 100; i++ {
+		if d := reversed(r); d < time.Millisecond || d > 2*time.Millisecond {
+			t.Fatalf("UniformDelay med max < min uden for intervallet: %v", d)
+		}
+	}
+	if PauseDelay(0, time.Second)(r) != 0 || PauseDelay(1, time.Second)(r) != time.Second {
+		t.Error("PauseDelay med sandsynlighed 0 eller 1 gav forkert pause")
+	}
+
+	// Forsinkelsen skal ske før beskeden håndteres
+	sim := NewSimulation(2, true)
+	sim.Processes[1].ProcessingDelay = FixedDelay(5 * time.Millisecond)
+	sim.Processes[0].SendMessage(sim.Processes[1], "slow")
+	start := time.Now()
+	sim.deliverPending()
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("Levering tog kun %v, forventede mindst 5ms", elapsed)
+	}
+	if len(sim.Processes[1].EventLog) != 1 {
+		t.Errorf("Beskeden blev ikke leveret")
+	}
+}
+
+func TestSendMessageSync(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sender, receiver := sim.Processes[0], sim.Processes[1]
+	done := make(chan bool)
+	receiver.Run(done)
+	defer close(done)
+
+	receipt, err := sender.SendMessageSync(context.Background(), receiver, "hello")
+	if err != nil {
+		t.Fatalf("Uventet fejl: %v", err)
+	}
+	if receipt.Target != "P1" || receipt.Clock.String() != "[1,1]" || receipt.Latency() < 0 {
+		t.Errorf("Forkert kvittering: %+v", receipt)
+	}
+
+	receiver.OnDeliver = func(p *Process, event Event) bool { return false }
+	if _, err := sender.SendMessageSync(context.Background(), receiver, "lost"); !errors.Is(err, ErrMessageDropped) {
+		t.Errorf("Forventede ErrMessageDropped, fik %v", err)
+	}
+}
+
+func TestSendMessageSyncFailures(t *testing.T) {
+	sim := NewSimulation(2, false)
+	sender, receiver := sim.Processes[0], sim.Processes[1]
+
+	// Modtageren kører ikke: vi giver op når ctx udløber
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := sender.SendMessageSync(ctx, receiver, "waiting"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Forventede DeadlineExceeded, fik %v", err)
+	}
+
+	for len(receiver.MessageQueue) < cap(receiver.MessageQueue) {
+		sender.SendMessage(receiver, "fill")
+	}
+	before := sender.Counters.Snapshot().Sent
+	if _, err := sender.SendMessageSync(context.Background(), receiver, "overflow"); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Forventede ErrQueueFull, fik %v", err)
+	}
+	if sender.Counters.Snapshot().Sent != before {
+		t.Error("En besked der ikke kom i køen må ikke tælles som sendt")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	sim := NewSimulation(2, true)
+	done := make(chan bool)
+	sim.Processes[0].Run(done)
+	sim.Processes[0].Run(done) // leak: to loops på samme proces
+
+	sim.Processes[0].SendMessage(sim.Processes[1], "queued")
+	report := sim.Diagnostics()
+	if report.RunLoops != 2 || report.Undelivered != 1 || report.Sent != 1 {
+		t.Errorf("Forkert rapport: %+v", report)
+	}
+	if len(report.Warnings) != 2 {
+		t.Errorf("Forventede 2 advarsler (dobbelt Run, stoppet med kø), fik %v", report.Warnings)
+	}
+
+	recorder := httptest.NewRecorder()
+	sim.DiagnosticsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(recorder.Body.String(), `"undelivered": 1`) {
+		t.Errorf("Forkert JSON:\n%s", recorder.Body.String())
+	}
+
+	close(done)
+	deadline := time.Now().Add(time.Second)
+	for sim.Diagnostics().CheckStopped() != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := sim.Diagnostics().CheckStopped(); err != nil {
+		t.Errorf("Run loops stoppede ikke: %v", err)
+	}
+}
+
+func TestConcurrentSimulations(t *testing.T) {
+	outputs := make([]bytes.Buffer, 3)
+	var wg sync.WaitGroup
+	for i := range outputs {
+		sim := NewSimulation(3, i%2 == 1)
+		sim.Out = &outputs[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				p := sim.Processes[sim.Rand.Intn(3)]
+				p.HandleLocalEvent("work")
+				p.SendMessage(sim.Processes[(p.ID+1)%3], "msg")
+				sim.deliverPending()
+			}
+			sim.PrintLogs()
+			sim.QueryClocks().Fprint(sim.Out)
+		}()
+	}
+	wg.Wait()
+
+	for i := range outputs {
+		out := outputs[i].String()
+		if strings.Count(out, "=== Event Logs ===") != 1 || strings.Count(out, "=== Cluster Logical Time") != 1 {
+			t.Errorf("Simulation %d skrev ikke præcis én log og én rapport:\n%s", i, out)
+		}
+	}
+
+	// Samme seed giver samme workload
+	a, b := NewSimulation(4, true), NewSimulation(4, true)
+	a.Seed(42)
+	b.Seed(42)
+	for round := 0; round < 20; round++ {
+		if topologyTarget(a.Rand, "random", 0, round, 4) != topologyTarget(b.Rand, "random", 0, round, 4) {
+			t.Fatal("Samme seed gav forskellige targets")
+		}
+	}
+}
+
+func TestRecoveryLine(t *testing.T) {
+	sim := NewSimulation(2, true)
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+
+	p0.HandleLocalEvent("a")
+	p0.TakeCheckpoint() // [1,0]
+	p0.SendMessage(p1, "m")
+	sim.deliverPending()
+	p1.TakeCheckpoint() // [2,1]: har modtaget m
+
+	// P0 går ned og mister sendingen af m, så P1's checkpoint er en orphan
+	line, err := sim.RecoveryLine(p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line[0].Index != 1 || line[1].Index != 0 {
+		t.Errorf("Forventede P0@1 og P1@0, fik %v", line)
+	}
+	for _, v := range sim.RecoveryLineVerdicts(line, p0) {
+		if !v.Held {
+			t.Errorf("%s: %s skulle holde for recovery linen: %s", v.Kind, v.Property, v.Detail)
+		}
+	}
+	orphan := []Checkpoint{{ProcessID: 0, Index: 0, Clock: VectorSnapshot([]int{0, 0})}, p1.Checkpoints[0]}
+	if verdicts := sim.RecoveryLineVerdicts(orphan, p0); verdicts[0].Held || verdicts[1].Held {
+		t.Errorf("Forventede en orphan og at P0 var skubbet forbi sit checkpoint, fik %+v", verdicts)
+	}
+
+	lost := sim.RollbackTo(line)
+	if lost[0] != 1 || lost[1] != 1 || len(p1.EventLog) != 0 || p1.VectorClock.GetVector().String() != "[0,0]" {
+		t.Errorf("Forkert rollback: lost %v, P1 log %v, clock %s", lost, p1.EventLog, p1.VectorClock.GetVector())
+	}
+	if len(p1.Checkpoints) != 0 {
+		t.Errorf("Checkpoints efter recovery line skulle fjernes, fik %v", p1.Checkpoints)
+	}
+
+	if _, err := NewSimulation(2, false).RecoveryLine(nil); err == nil {
+		t.Error("Lamport simulation skulle give en fejl")
+	}
+}
+
+// Tester at rollback sætter det ur events tikker, og at checkpoints læser det ur, også
+// når processerne har egne ure fra NewSimulationWithClocks
+func TestRollbackClockInUse(t *testing.T) {
+	sim := NewSimulationWithClocks(2, func(id int, n int) LogicalClock { return NewVectorClock(n, id) })
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("a")
+	p0.HandleLocalEvent("b")
+	if cp := p0.TakeCheckpoint(); cp.Clock.String() != "[2,0]" {
+		t.Errorf("Forventede checkpoint [2,0], fik %s", cp.Clock)
+	}
+	p0.HandleLocalEvent("c")
+
+	sim.RollbackTo([]Checkpoint{{ProcessID: 0, Clock: VectorSnapshot([]int{0, 0})}, {ProcessID: 1, Clock: VectorSnapshot([]int{0, 0})}})
+	p0.HandleLocalEvent("d")
+	if p0.Clock.Now().String() != "[1,0]" || p0.EventVectors[0][0] != 1 {
+		t.Errorf("Forventede [1,0] efter rollback og et tick, fik %s", p0.Clock.Now())
+	}
+	if len(p1.EventLog) != 0 || p1.Clock.Now().String() != "[0,0]" {
+		t.Errorf("P1 skulle være uændret, fik %s", p1.Clock.Now())
+	}
+}
+
+func TestCausalBuffer(t *testing.T) {
+	alice, bob := NewCausalBuffer(3, 0), NewCausalBuffer(3, 1)
+	carol := NewCausalBuffer(3, 2)
+
+	question := alice.Stamp("question")
+	bob.Receive(question)
+	answer := bob.Stamp("answer")
+
+	// Svaret ankommer først og skal vente på spørgsmålet
+	if ready := carol.Receive(answer); len(ready) != 0 || carol.Pending() != 1 {
+		t.Fatalf("Svaret skulle bufferes, fik %v", ready)
+	}
+	ready := carol.Receive(question)
+	if len(ready) != 2 || ready[0].Body != "question" || ready[1].Body != "answer" {
+		t.Errorf("Forventede question før answer, fik %v", ready)
+	}
+	if FormatVector(carol.Delivered()) != "[1,1,0]" {
+		t.Errorf("Forkert leverings-vector: %v", carol.Delivered())
+	}
+}
+
+func TestCausalChat(t *testing.T) {
+	transcript := runChatConversation(true).Participants[2].Transcript()
+	question, answer := -1, -1
+	for i, line := range transcript {
+		if strings.Contains(line, "lunch") {
+			question = i
+		}
+		if strings.Contains(line, "pizza") {
+			answer = i
+		}
+	}
+	if question == -1 || answer < question {
+		t.Errorf("Carol skulle se spørgsmålet før svaret: %v", transcript)
+	}
+
+	// Safety holder kun med causal delivery; liveness holder i begge
+	for causal, safe := range map[bool]bool{true: true, false: false} {
+		verdicts := runChatConversation(causal).Verdicts()
+		if verdicts[0].Held != safe || !verdicts[1].Held {
+			t.Errorf("Causal delivery %t: forventede safety %t og liveness, fik %+v", causal, safe, verdicts)
+		}
+	}
+}
+
+func TestMessageEdges(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Processes[0].SendMessage(sim.Processes[1], "same payload")
+	sim.Processes[2].SendMessage(sim.Processes[1], "same payload")
+	sim.Processes[0].SendMessage(sim.Processes[2], "unreceived")
+	for i := 0; i < 2; i++ {
+		sim.Processes[1].ReceiveMessage(<-sim.Processes[1].MessageQueue)
+	}
+
+	edges := MessageEdges(sim)
+	if len(edges) != 2 {
+		t.Fatalf("Forventede 2 kanter, fik %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.Send.MessageID != edge.ID || edge.Receive.MessageID != edge.ID || edge.Receive.ProcessID != 1 {
+			t.Errorf("Forkert kant: %+v", edge)
+		}
+		if edge.Send.Clock.Compare(edge.Receive.Clock) != -1 {
+			t.Errorf("Send %s skulle være før receive %s", edge.Send.Clock, edge.Receive.Clock)
+		}
+	}
+	if edges[0].ID == edges[1].ID {
+		t.Error("Besked ID'er skal være unikke")
+	}
+
+	var out strings.Builder
+	ExportSyslog(&out, sim)
+	if !strings.Contains(out.String(), `msg_id="P0-1"`) {
+		t.Errorf("Syslog mangler msg_id:\n%s", out.String())
+	}
+}
+
+func TestRunsArchive(t *testing.T) {
+	root := t.TempDir()
+	run, err := CreateRun(root, []string{"-archive"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(run.Path("trace.log"), []byte("trace"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := ListRuns(root)
+	if err != nil || len(runs) != 1 || runs[0].ID != run.Metadata.ID || len(runs[0].Files) != 1 {
+		t.Fatalf("Forkert liste: %+v (%v)", runs, err)
+	}
+
+	var out strings.Builder
+	if err := runsCommand(root, []string{"show", run.Metadata.ID}, &out); err != nil || !strings.Contains(out.String(), "trace.log            5 bytes") {
+		t.Errorf("Forkert show output (%v):\n%s", err, out.String())
+	}
+	if err := runsCommand(root, []string{"delete", "../" + run.Metadata.ID}, &out); err == nil {
+		t.Error("Sti uden for runs mappen skulle afvises")
+	}
+	if err := runsCommand(root, []string{"delete", run.Metadata.ID}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if runs, _ := ListRuns(root); len(runs) != 0 {
+		t.Errorf("Kørslen blev ikke slettet: %+v", runs)
+	}
+}
+
+func TestStateAtCut(t *testing.T) {
+	sim := NewSimulation(3, true)
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p0.SendMessage(p1, "m1")
+	p2.HandleLocalEvent("unrelated")
+	sim.deliverPending()
+	p1.SendMessage(p2, "m2")
+	sim.deliverPending()
+
+	cut, err := sim.CutBeforeReceive("P1-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// P2's lokale event kom før modtagelsen og er derfor med
+	if len(cut) != 3 || cut[0] != 1 || cut[1] != 2 || cut[2] != 1 {
+		t.Errorf("Forventede cut [1 2 1], fik %v", cut)
+	}
+
+	state, err := sim.StateAt(cut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Processes[1].Clock.String() != "[1,2,0]" || state.Processes[2].Clock.String() != "[0,0,1]" {
+		t.Errorf("Forkerte clocks: %s %s", state.Processes[1].Clock, state.Processes[2].Clock)
+	}
+	if len(state.InTransit) != 1 || state.InTransit[0] != "P1-1" {
+		t.Errorf("Forventede P1-1 in transit, fik %v", state.InTransit)
+	}
+
+	if _, err := sim.StateAt(Cut{0, 1, 0}); err == nil {
+		t.Error("Receive uden send skulle give et inkonsistent cut")
+	}
+	if _, err := NewSimulation(2, false).CutBefore(p0, 0); err == nil {
+		t.Error("Lamport simulation skulle give en fejl")
+	}
+}
+
+func TestOmissionFaults(t *testing.T) {
+	sim := NewSimulation(2, true)
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+
+	p0.Omission = OmissionFaults{Send: 1}
+	p0.SendMessage(p1, "tabt")
+	sim.deliverPending()
+	if len(p1.EventLog) != 0 || len(p0.EventLog) != 1 {
+		t.Errorf("Send omission: P0 skulle logge send, P1 intet modtage (%d, %d events)", len(p0.EventLog), len(p1.EventLog))
+	}
+
+	p0.Omission = OmissionFaults{Receive: 1}
+	p0.SendMessage(p1, "ud")
+	p1.SendMessage(p0, "ind")
+	sim.deliverPending()
+	if len(p1.EventLog) != 2 || len(p0.EventLog) != 2 {
+		t.Errorf("Receive omission: P1 skulle modtage, P0 ikke (%d, %d events)", len(p0.EventLog), len(p1.EventLog))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan bool)
+	defer close(done)
+	p0.Run(done)
+	if _, err := p1.SendMessageSync(ctx, p0, "sync"); !errors.Is(err, ErrMessageDropped) {
+		t.Errorf("Forventede ErrMessageDropped, fik %v", err)
+	}
+	if sent, received := p0.Omissions(); sent != 1 || received != 2 {
+		t.Errorf("Forventede 1 send og 2 receive omissions, fik %d og %d", sent, received)
+	}
+}
+
+func TestTraceCompression(t *testing.T) {
+	if got := EncodeVectorDelta([]int{1, 0, 3, 0}, []int{1, 2, 3, 5}); got != "{1:2,3:5}" {
+		t.Errorf("Forkert delta: %s", got)
+	}
+	if _, err := DecodeVectorDelta([]int{0, 0}, "{7:1}"); err == nil {
+		t.Error("Index uden for vectoren skulle give en fejl")
+	}
+
+	lamportSim := NewNamedSimulation([]string{"db 1", "web"}, false)
+	vectorSim := NewNamedSimulation([]string{"db 1", "web", "cache"}, true)
+	for _, sim := range []*Simulation{lamportSim, vectorSim} {
+		db, web := sim.Processes[0], sim.Processes[1]
+		db.HandleLocalEvent("a=b]")
+		db.SendMessage(web, "query")
+		sim.deliverPending()
+		web.SendMessage(db, "result")
+		sim.deliverPending()
+	}
+
+	for _, format := range []string{"syslog", "cef"} {
+		var plain, compressed bytes.Buffer
+		if err := exportLogs(&plain, format, false, lamportSim, vectorSim); err != nil {
+			t.Fatal(err)
+		}
+		if err := exportLogs(&compressed, format, true, lamportSim, vectorSim); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(compressed.String(), "vector_delta") || compressed.Len() >= plain.Len() {
+			t.Errorf("%s: forventede komprimerede vectors (%d vs %d bytes)", format, compressed.Len(), plain.Len())
+		}
+
+		expected, err := ReadTraceClocks(&plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadTraceClocks(&compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 10 || len(got) != len(expected) {
+			t.Fatalf("%s: forventede 10 events, fik %d og %d", format, len(expected), len(got))
+		}
+		for i := range got {
+			if got[i].Label != expected[i].Label || got[i].Index != expected[i].Index || got[i].Clock.String() != expected[i].Clock.String() {
+				t.Errorf("%s linje %d: %+v, forventede %+v", format, i, got[i], expected[i])
+			}
+		}
+		if got[0].Label != "db 1" {
+			t.Errorf("%s: label blev ikke unescaped: %q", format, got[0].Label)
+		}
+	}
+
+	// Trace filer er ikke til at stole på: ugyldige vectors skal give en fejl, ikke panic
+	var plain bytes.Buffer
+	if err := exportLogs(&plain, "syslog", false, vectorSim); err != nil {
+		t.Fatal(err)
+	}
+	line, _, _ := strings.Cut(plain.String(), "\n")
+	valid := regexp.MustCompile(`vector_clock="[^"]*"`)
+	for _, value := range []string{`""`, `"[a,b]"`, `"[1,2"`, `"[1,-5,0]"`} {
+		if _, err := ReadTraceClocks(strings.NewReader(valid.ReplaceAllString(line, "vector_clock="+value))); err == nil {
+			t.Errorf("Forventede en fejl for vector_clock=%s", value)
+		}
+	}
+}
+
+func TestDiscardEvents(t *testing.T) {
+	for _, useVectorClock := range []bool{false, true} {
+		retained := NewSimulation(2, useVectorClock)
+		discarded := NewSimulation(2, useVectorClock)
+		for _, p := range discarded.Processes {
+			p.DiscardEvents = true
+		}
+		for _, sim := range []*Simulation{retained, discarded} {
+			sim.Processes[0].HandleLocalEvent("a")
+			sim.Processes[0].SendMessage(sim.Processes[1], "b")
+			sim.deliverPending()
+		}
+
+		for i, p := range discarded.Processes {
+			if len(p.EventLog) != 0 || len(p.EventVectors) != 0 || len(p.EventTimestamps) != 0 {
+				t.Errorf("P%d gemte events selvom DiscardEvents er slået til", i)
+			}
+			expected := retained.Processes[i].clockAt(len(retained.Processes[i].EventLog) - 1)
+			got := p.LamportClock.GetTime()
+			if useVectorClock {
+				got = p.VectorClock.GetVector()
+			}
+			if got.String() != expected.String() {
+				t.Errorf("P%d clock %s, forventede %s", i, got, expected)
+			}
+		}
+
+		done := make(chan bool)
+		discarded.Processes[1].Run(done)
+		receipt, err := discarded.Processes[0].SendMessageSync(context.Background(), discarded.Processes[1], "c")
+		close(done)
+		if err != nil || receipt.Clock.String() == "" {
+			t.Errorf("Sync send uden events fejlede: %v %+v", err, receipt)
+		}
+	}
+}
+
+func TestLogicalClockInterface(t *testing.T) {
+	var _ LogicalClock = NewLamportClock()
+	var _ LogicalClock = NewVectorClock(2, 0)
+
+	sim := NewSimulationWithClocks(3, func(id int, numProcesses int) LogicalClock {
+		return NewPrunedVectorClock(numProcesses, id, 1)
+	})
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p0.SendMessage(p1, "a")
+	sim.deliverPending()
+	p1.SendMessage(p2, "b")
+	sim.deliverPending()
+
+	if !sim.UsesVectorClock() || sim.GetClockType() != "Vector Clock" {
+		t.Error("Pruned clocks skulle give en vector simulation")
+	}
+	// Med k=1 glemmer P1 P0's entry, og P2 glemmer P1's efter sin egen er talt op
+	if got := p2.clockAt(0).String(); got != "[0,-1,1]" {
+		t.Errorf("Forventede [0,-1,1] hos P2, fik %s", got)
+	}
+	if !strings.Contains(p2.EventLog[0], "received [-1,2,0]") {
+		t.Errorf("Headeren blev ikke sendt som vector: %s", p2.EventLog[0])
+	}
+
+	lamport := NewSimulation(2, false)
+	lamport.Processes[0].HandleLocalEvent("x")
+	if lamport.UsesVectorClock() || lamport.Processes[0].EventLog[0] != "P0: Local event T1: x" {
+		t.Errorf("Forkert Lamport log: %s", lamport.Processes[0].EventLog[0])
+	}
+}
+
+func TestPartialReplication(t *testing.T) {
+	keys := []PartialKey{{Name: "cart", Replicas: []int{0, 2, 4}}}
+	store := NewPartialStore(5, keys)
+	if err := store.Write(1, "cart", "x"); err == nil {
+		t.Error("P1 er ikke replica og skulle afvises")
+	}
+	store.Write(0, "cart", "a")
+	store.Write(4, "cart", "b")
+	store.DeliverAll()
+
+	first, _ := store.Read(0, "cart")
+	for _, id := range keys[0].Replicas {
+		v, _ := store.Read(id, "cart")
+		if v.Value != first.Value || FormatVector(v.Vector) != "[1,0,1]" {
+			t.Errorf("P%d har %q %v, forventede %q [1,0,1]", id, v.Value, v.Vector, first.Value)
+		}
+	}
+	if store.Stats.Conflicts != 3 || store.Stats.Messages != 4 {
+		t.Errorf("Forventede 3 konflikter og 4 beskeder, fik %+v", store.Stats)
+	}
+
+	store.Write(2, "cart", "c")
+	store.DeliverAll()
+	if v, _ := store.Read(4, "cart"); v.Value != "c" || FormatVector(v.Vector) != "[1,1,1]" {
+		t.Errorf("Skrivning efter konflikten blev ikke replikeret: %+v", v)
+	}
+	if store.Stats.ScopedHeaderBytes >= store.Stats.FullHeaderBytes {
+		t.Errorf("Per-key headers skulle være mindre: %+v", store.Stats)
+	}
+}
+
+func TestClientSession(t *testing.T) {
+	store := NewPartialStore(2, []PartialKey{{Name: "k", Replicas: []int{0, 1}}})
+	client := NewClientSession("c")
+	if err := client.Write(store, 0, "k", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Read(store, 1, "k"); !errors.Is(err, ErrSessionStale) {
+		t.Errorf("Forventede ErrSessionStale fra P1 før replikering, fik %v", err)
+	}
+	if err := client.Write(store, 1, "k", "v2"); !errors.Is(err, ErrSessionStale) {
+		t.Errorf("Skrivning via en replica der er bagud skulle afvises, fik %v", err)
+	}
+
+	store.DeliverAll()
+	if value, err := client.Read(store, 1, "k"); err != nil || value != "v1" {
+		t.Errorf("Forventede v1 fra P1, fik %q (%v)", value, err)
+	}
+
+	resumed, err := ResumeClientSession("c", client.Token())
+	if err != nil || resumed.Token() != "k=[1,0]" {
+		t.Fatalf("Forkert token: %q (%v)", resumed.Token(), err)
+	}
+	if _, err := ResumeClientSession("c", "k[1,0]"); err == nil {
+		t.Error("Ugyldigt token skulle give en fejl")
+	}
+
+	// Et token med en vector af forkert længde må ikke få Read eller Write til at panic
+	three := NewPartialStore(3, []PartialKey{{Name: "cart", Replicas: []int{0, 1, 2}}})
+	if err := NewClientSession("w").Write(three, 0, "cart", "x"); err != nil {
+		t.Fatal(err)
+	}
+	short, err := ResumeClientSession("x", "cart=[1,0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := short.Read(three, 0, "cart"); err == nil || errors.Is(err, ErrSessionStale) {
+		t.Errorf("Forventede en fejl om vectorens længde fra Read, fik %v", err)
+	}
+	if err := short.Write(three, 0, "cart", "y"); err == nil || errors.Is(err, ErrSessionStale) {
+		t.Errorf("Forventede en fejl om vectorens længde fra Write, fik %v", err)
+	}
+}
+
+func TestHybridLogicalClock(t *testing.T) {
+	wall := time.UnixMilli(1000)
+	clock := NewHybridLogicalClock()
+	clock.Physical = func() time.Time { return wall }
+
+	if s := clock.Tick(); s.Physical() != 1000 || s.Logical() != 0 {
+		t.Errorf("Forventede 1000+0, fik %d+%d", s.Physical(), s.Logical())
+	}
+	if s := clock.Send(); s.Physical() != 1000 || s.Logical() != 1 {
+		t.Errorf("Samme ms skulle tælle logisk, fik %d+%d", s.Physical(), s.Logical())
+	}
+
+	// Besked fra en proces hvis ur er foran: wall følger afsenderen
+	if s := clock.Receive(HLCSnapshot(1500, 3)); s.Physical() != 1500 || s.Logical() != 4 {
+		t.Errorf("Forventede 1500+4 efter receive, fik %d+%d", s.Physical(), s.Logical())
+	}
+	wall = time.UnixMilli(2000)
+	if s := clock.Tick(); s.Physical() != 2000 || s.Logical() != 0 {
+		t.Errorf("Forventede 2000+0 når det fysiske ur indhenter, fik %d+%d", s.Physical(), s.Logical())
+	}
+
+	sim := NewHLCSimulation(2)
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.Clock.(*HybridLogicalClock).Physical = func() time.Time { return time.Now().Add(time.Hour) }
+	p0.SendMessage(p1, "hi")
+	sim.deliverPending()
+	sent, received := p0.clockAt(0), p1.clockAt(0)
+	if !sent.IsHybrid() || !sent.HappensBefore(received) {
+		t.Errorf("Send %v skulle komme før receive %v", sent, received)
+	}
+	if sim.GetClockType() != "Hybrid Logical Clock" {
+		t.Errorf("Forkert clock type: %s", sim.GetClockType())
+	}
+
+	var buf bytes.Buffer
+	if err := exportLogs(&buf, "syslog", true, sim); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadTraceClocks(&buf)
+	if err != nil || len(events) != 2 || events[1].Clock.Time() != received.Time() || !events[1].Clock.IsHybrid() {
+		t.Errorf("HLC tid gik tabt i trace filen: %+v (%v)", events, err)
+	}
+}
+
+func TestConflictRateSweep(t *testing.T) {
+	// Kun lokale events: alle par på tværs af processer er concurrent, og Lamport ser
+	// kun dem med samme tid (event k på hver proces har tid k)
+	point := measureConflictRate(3, 1, 5, 1)
+	if point.Pairs != 75 || point.ConcurrentPairs != 75 || point.LamportTies != 15 {
+		t.Errorf("Forventede 75 par, 75 concurrent og 15 ties, fik %+v", point)
+	}
+	if point.VectorRate() != 1 || point.SilentRate() != 0.8 {
+		t.Errorf("Forkerte rater: vector %.2f, silent %.2f", point.VectorRate(), point.SilentRate())
+	}
+
+	// Med beskeder er der færre konflikter, og Lamport ser aldrig flere end vector
+	messages := measureConflictRate(4, 0.2, 10, 1)
+	if messages.VectorRate() >= 1 || messages.LamportTies > messages.ConcurrentPairs {
+		t.Errorf("Uventet sweep punkt: %+v", messages)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteConflictRateCSV(&buf, []ConflictRatePoint{point}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "processes,concurrency,pairs,concurrent_pairs,vector_rate,lamport_rate,silent_rate\n3,1.00,75,75,1.0000,0.2000,0.8000\n"
+	if buf.String() != expected {
+		t.Errorf("Forkert CSV:\n%s", buf.String())
+	}
+}
+
+func TestOutputSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	sink, err := OpenOutputSink("tcp:" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := NewSimulation(2, false)
+	sim.Out = sink
+	sim.Processes[0].HandleLocalEvent("a")
+	sim.PrintLogs()
+	DemonstrateHashChain(sink)
+	sink.Close()
+	if got := <-received; !strings.Contains(got, "Local event") || !strings.Contains(got, "hash chain") {
+		t.Errorf("Forventede simulationens og demoens output på socketen, fik %q", got)
+	}
+
+	for _, spec := range []string{"udp:127.0.0.1:9", "file:", "stdout"} {
+		if _, err := OpenOutputSink(spec); err == nil {
+			t.Errorf("Forventede fejl for %q", spec)
+		}
+	}
+}
+
+func TestMultiClock(t *testing.T) {
+	sim := NewSimulationWithClocks(2, func(id int, numProcesses int) LogicalClock {
+		return NewMultiClock(NewVectorClock(numProcesses, id), NewLamportClock())
+	})
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("a")
+	p1.HandleLocalEvent("b")
+	p0.SendMessage(p1, "c")
+	sim.deliverPending()
+
+	if got := p1.clockAt(1).String(); got != "[2,2] / T3" {
+		t.Errorf("Forventede [2,2] / T3 for receive, fik %s", got)
+	}
+	header := encodeClockHeader(p0.clockAt(1))
+	if clock, err := parseClockHeaderChecked(header); header != "[2,0];2" || err != nil || clock.String() != "[2,0] / T2" {
+		t.Errorf("Forkert multi-clock header: %s", header)
+	}
+
+	agreements, err := CompareClockStamps(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := StampAgreement{Clock: "Lamport", CausalPairs: 2, CausalOrdered: 2, ConcurrentPairs: 2, ConcurrentOrdered: 1}
+	if len(agreements) != 1 || agreements[0] != expected {
+		t.Errorf("Forventede %+v, fik %+v", expected, agreements)
+	}
+	if _, err := CompareClockStamps(NewSimulation(2, true)); err == nil {
+		t.Error("Forventede fejl uden multi-clock")
+	}
+}
+
+func TestVersionVector(t *testing.T) {
+	a := NewVersionVector()
+	a.Increment("10.0.0.1:7000")
+	b := a.Copy()
+	b.Increment("db-1")
+	a.Increment("10.0.0.1:7000")
+
+	if a.Compare(b) != 0 || a.Equal(b) {
+		t.Errorf("Forventede concurrent: %s vs %s", a, b)
+	}
+	merged := a.Copy()
+	merged.Merge(b)
+	if merged.Compare(a) != 1 || b.Compare(merged) != -1 {
+		t.Errorf("Merge skulle dominere begge: %s", merged)
+	}
+	if !NewVersionVector().Equal(VersionVector{"x": 0}) {
+		t.Error("En entry på 0 skulle svare til en manglende entry")
+	}
+
+	parsed, err := ParseVersionVector(merged.String())
+	if err != nil || !parsed.Equal(merged) || merged.String() != "{10.0.0.1:7000:2,db-1:1}" {
+		t.Errorf("Forkert round trip: %s → %s (%v)", merged, parsed, err)
+	}
+	if _, err := ParseVersionVector("{db-1}"); err == nil {
+		t.Error("Forventede fejl for entry uden tæller")
+	}
+	if got := VersionVectorFromSlice([]int{2, 0, 1}, []string{"a", "b", "c"}).String(); got != "{a:2,c:1}" {
+		t.Errorf("Forventede {a:2,c:1}, fik %s", got)
+	}
+}
+
+func TestReplicaDivergence(t *testing.T) {
+	store := NewPartialStore(3, []PartialKey{{Name: "a", Replicas: []int{0, 1, 2}}, {Name: "b", Replicas: []int{1, 2}}})
+	store.Write(0, "a", "x")
+	store.Write(1, "b", "y")
+	store.DeliverAll()
+	if d := store.Divergence(); len(d) != 0 {
+		t.Errorf("Forventede konvergens, fik %+v", d)
+	}
+
+	store.Write(1, "a", "z")
+	d := store.Divergence()
+	if len(d) != 1 || d[0].Key != "a" || d[0].InFlight != 2 {
+		t.Fatalf("Forventede at a divergerer med 2 beskeder undervejs, fik %+v", d)
+	}
+	if d[0].Reasons[0] != "missing 1 write(s) by P1" || d[0].Reasons[1] != "" {
+		t.Errorf("Forkerte forklaringer: %+v", d[0].Reasons)
+	}
+
+	store.Resolver = RejectResolver{}
+	store.Write(0, "a", "w")
+	store.DeliverAll()
+	var buf bytes.Buffer
+	store.FprintDivergence(&buf)
+	if !strings.Contains(buf.String(), "will not converge") {
+		t.Errorf("En afvist konflikt skulle give permanent divergens:\n%s", buf.String())
+	}
+}
+
+func TestBloomClock(t *testing.T) {
+	if cells, hashes := BloomClockSize(100, 0.01); cells != 959 || hashes != 7 {
+		t.Errorf("Forventede 959 celler og 7 hashes, fik %d og %d", cells, hashes)
+	}
+
+	sim := NewSimulationWithClocks(2, func(id int, numProcesses int) LogicalClock {
+		return NewBloomClock(64, 3, id)
+	})
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("a")
+	p1.HandleLocalEvent("b")
+	p0.SendMessage(p1, "c")
+	sim.deliverPending()
+
+	if p1.clockAt(1).Len() != 64 || !p0.clockAt(1).HappensBefore(p1.clockAt(1)) {
+		t.Errorf("Send %v skulle komme før receive %v", p0.clockAt(1), p1.clockAt(1))
+	}
+	if !p0.clockAt(0).HappensBefore(p0.clockAt(1)) {
+		t.Error("Events på samme proces skulle være ordnet")
+	}
+
+	// Med et stort filter bliver concurrent events ikke forvekslet
+	errors, pairs := measureApproximationErrors(4, 0.5, 10, 1, func(id int) LogicalClock {
+		return NewBloomClock(4096, 3, id)
+	})
+	if errors != 0 || pairs == 0 {
+		t.Errorf("Forventede 0 fejl, fik %d af %d par", errors, pairs)
+	}
+}
+
+func TestBatchedClock(t *testing.T) {
+	clock := NewBatchedClock(NewLamportClock(), 3)
+	stamps := ""
+	for i := 0; i < 4; i++ {
+		stamps += clock.Tick().String() + " "
+	}
+	stamps += clock.Send().String() + " " + clock.Tick().String()
+	if stamps != "T1 T1 T1 T2 T3 T4" || clock.Increments != 4 {
+		t.Errorf("Forventede T1 T1 T1 T2 T3 T4 med 4 tik, fik %s med %d", stamps, clock.Increments)
+	}
+
+	if lost, causal, _ := measureBatchPrecision(3, 1, 0.8, 10, 1); lost != 0 || causal == 0 {
+		t.Errorf("Batch 1 skulle ikke miste par, mistede %d af %d", lost, causal)
+	}
+	if lost, _, _ := measureBatchPrecision(3, 4, 1, 10, 1); lost == 0 {
+		t.Error("Batch 4 med kun lokale events skulle miste par")
+	}
+}
+
+func TestPlausibleClock(t *testing.T) {
+	sim := NewSimulationWithClocks(4, func(id int, numProcesses int) LogicalClock {
+		return NewPlausibleClock(2, id)
+	})
+	p1, p3 := sim.Processes[1], sim.Processes[3]
+	p1.HandleLocalEvent("a")
+	p3.HandleLocalEvent("b")
+	if got := p3.clockAt(0).String(); got != "[0,1]" {
+		t.Errorf("P3 skulle tælle i entry 1, fik %s", got)
+	}
+	// P1 og P3 deler entry, så deres concurrent events ser ens ud
+	if p1.clockAt(0).Compare(p3.clockAt(0)) != 0 {
+		t.Error("Forventede at de concurrent events ikke blev ordnet")
+	}
+	p1.SendMessage(p3, "c")
+	sim.deliverPending()
+	if !p1.clockAt(1).HappensBefore(p3.clockAt(1)) {
+		t.Errorf("Send %v skulle komme før receive %v", p1.clockAt(1), p3.clockAt(1))
+	}
+
+	exact := func(id int) LogicalClock { return NewPlausibleClock(5, id) }
+	if errors, _ := measureApproximationErrors(5, 0.5, 10, 1, exact); errors != 0 {
+		t.Errorf("R = n skulle være præcist, fik %d fejl", errors)
+	}
+	single := func(id int) LogicalClock { return NewPlausibleClock(1, id) }
+	if errors, _ := measureApproximationErrors(5, 1, 10, 1, single); errors == 0 {
+		t.Error("R = 1 skulle ordne concurrent events")
+	}
+}
+
+func TestWalkthrough(t *testing.T) {
+	state := t.TempDir() + "/walkthrough.state"
+
+	// Svarer på to checkpoints (ét forkert) og stopper ved det tredje
+	var out bytes.Buffer
+	if err := RunWalkthrough(strings.NewReader("b\nb\nq\n"), &out, state); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Not quite: f is concurrent with i") {
+		t.Errorf("Forventede at f og i blev afsløret som concurrent:\n%s", out.String())
+	}
+	if step, correct, asked, _ := loadWalkthroughState(state); step != 2 || correct != 1 || asked != 2 {
+		t.Errorf("Forventede trin 2 med 1/2 rigtige, fik %d med %d/%d", step, correct, asked)
+	}
+
+	// Genoptager ved trin 3 og gennemfører
+	out.Reset()
+	if err := RunWalkthrough(strings.NewReader("c\nb\na\na\n"), &out, state); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Resuming at step 3") || !strings.Contains(out.String(), "5/6 predictions correct") {
+		t.Errorf("Forkert genoptagelse:\n%s", out.String())
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Error("Fremskridtet skulle slettes når walkthroughen er færdig")
+	}
+}
+
+func TestCausalDeliveryCost(t *testing.T) {
+	perfect := simulateCausalDelivery(3, 10, 2, CausalNetwork{RetransmitMs: 30}, rand.New(rand.NewSource(1)))
+	if perfect.Messages != 60 || perfect.Delayed != 0 || perfect.PeakMessages != 0 {
+		t.Errorf("Uden tab og omrokering skulle intet vente: %+v", perfect)
+	}
+
+	lossy := simulateCausalDelivery(3, 10, 2, CausalNetwork{LossRate: 0.2, ReorderRate: 0.3, ReorderMs: 10, RetransmitMs: 30}, rand.New(rand.NewSource(1)))
+	if lossy.Messages != 60 || lossy.Undelivered != 0 {
+		t.Errorf("Alle beskeder skulle leveres til sidst: %+v", lossy)
+	}
+	if lossy.Delayed == 0 || lossy.PeakBytes == 0 || lossy.MeanAddedMs() <= 0 {
+		t.Errorf("Forventede at tab gav ventetid i bufferen: %+v", lossy)
+	}
+}
+
+// Tester at middleware kæden komprimerer og krypterer på vejen og pakker ud i omvendt rækkefølge
+func TestMessageMiddleware(t *testing.T) {
+	sim := NewSimulation(8, true)
+	sim.Seed(1)
+	key := []byte("0123456789abcdef")
+	metrics := &MetricsMiddleware{}
+	for _, p := range sim.Processes {
+		encryption, err := NewEncryptionMiddleware(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Middleware = []MessageMiddleware{CompressionMiddleware{}, encryption, metrics}
+	}
+
+	sender, receiver := sim.Processes[0], sim.Processes[1]
+	sender.SendMessage(receiver, "hemmelig")
+	wire := <-receiver.MessageQueue
+	if strings.Contains(wire.Message, "hemmelig") || !strings.HasPrefix(wire.Message, "Z8{0:1}|") {
+		t.Errorf("Forventede komprimeret header og krypteret payload, fik %q", wire.Message)
+	}
+	receiver.deliver(wire)
+	if got := receiver.EventLog[0]; !strings.Contains(got, "hemmelig") || !strings.Contains(got, "[1,1,0,0,0,0,0,0]") {
+		t.Errorf("Forventede dekrypteret besked og synkroniseret clock, fik %q", got)
+	}
+	if metrics.Sent.Load() != 1 || metrics.Received.Load() != 1 {
+		t.Errorf("Forventede 1 sendt og 1 modtaget, fik %d og %d", metrics.Sent.Load(), metrics.Received.Load())
+	}
+
+	// En ændret header afvises af krypteringen, og beskeden droppes
+	sender.SendMessage(receiver, "igen")
+	wire = <-receiver.MessageQueue
+	wire.Message = strings.Replace(wire.Message, "{0:2}", "{0:9}", 1)
+	receiver.deliver(wire)
+	if len(receiver.EventLog) != 1 {
+		t.Error("En besked med manipuleret header skulle droppes")
+	}
+
+	// Fault injection dropper ved send
+	sender.Middleware = []MessageMiddleware{NewFaultMiddleware(1, 0, 1)}
+	if _, err := sender.SendMessageSync(context.Background(), receiver, "tabt"); !errors.Is(err, ErrMessageDropped) {
+		t.Errorf("Forventede ErrMessageDropped, fik %v", err)
+	}
+}
+
+// Tester at causale par med omvendt wall clock rækkefølge findes
+func TestWallClockAnomalies(t *testing.T) {
+	sim := NewSimulation(2, true)
+	clocks := NewSimulatedWallClocks(sim, []time.Duration{0, -50 * time.Millisecond})
+	sim.Processes[0].SendMessage(sim.Processes[1], "hej")
+	clocks.Advance(10 * time.Millisecond)
+	sim.deliverPending()
+
+	anomalies, causal, err := WallClockAnomalies(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if causal != 1 || len(anomalies) != 1 {
+		t.Fatalf("Forventede 1 causalt par med omvendt wall clock, fik %d af %d", len(anomalies), causal)
+	}
+	if a := anomalies[0]; a.Cause.Type != "send" || a.Effect.Type != "receive" || a.Inversion() != 40*time.Millisecond {
+		t.Errorf("Forventede at receive lå 40ms før send, fik %+v", a)
+	}
+
+	if _, _, err := WallClockAnomalies(NewSimulation(2, false)); err == nil {
+		t.Error("Forventede fejl uden vector clocks")
+	}
+}
+
+// Tester at beskeder med ugyldige vector headere afvises i stedet for at crashe processen
+func TestMalformedClockHeader(t *testing.T) {
+	sim := NewSimulation(3, true)
+	p := sim.Processes[1]
+	for _, message := range []string{"[1,2]|for kort", "[1,x,3]|ikke et tal", "[1,2,3|uafsluttet", "[1,-4,0]|negativ", "[]|tom"} {
+		err := p.ReceiveMessage(Event{ProcessID: 0, Message: message, MessageID: "P0-1"})
+		if !errors.Is(err, ErrMalformedClock) {
+			t.Errorf("Forventede ErrMalformedClock for %q, fik %v", message, err)
+		}
+	}
+	if len(p.EventLog) != 0 || p.Clock.Now().String() != "[0,0,0]" {
+		t.Errorf("Afviste beskeder må ikke ændre uret eller loggen: %v, %s", p.EventLog, p.Clock.Now())
+	}
+	if stats := p.Counters.Snapshot(); stats.Rejected != 5 || stats.Received != 5 {
+		t.Errorf("Forventede 5 modtagne og 5 afviste, fik %+v", stats)
+	}
+	if err := p.ReceiveMessage(Event{ProcessID: 0, Message: "-3|negativ", MessageID: "P0-2"}); !errors.Is(err, ErrMalformedClock) {
+		t.Errorf("Forventede ErrMalformedClock for negativ Lamport tid, fik %v", err)
+	}
+	for _, vector := range []string{"", "[", "[a,b]", "[1,,2]", "[1,-2]"} {
+		if _, err := parseVector(vector); err == nil {
+			t.Errorf("Forventede fejl for vector %q", vector)
+		}
+	}
+	if _, err := ResumeClientSession("c", "x=[a,b]"); err == nil {
+		t.Error("Forventede fejl for session token med ugyldig vector")
+	}
+
+	if _, err := CompareChecked([]int{1, 2}, []int{1, 2, 3}); err == nil {
+		t.Error("Forventede fejl ved forskellige længder")
+	}
+	if _, err := NewVectorClock(3, 0).ReceiveEventChecked([]int{1}); err == nil {
+		t.Error("Forventede fejl ved for kort vector")
+	}
+
+	// En gyldig besked efter de ugyldige leveres stadig
+	sim.Processes[0].SendMessage(p, "ok")
+	sim.deliverPending()
+	if len(p.EventLog) != 1 {
+		t.Error("Forventede at den gyldige besked blev leveret")
+	}
+}
+
+// Tester at GrowthMonitor måler vækstrate og advarer én gang tæt på overflow
+func TestGrowthMonitor(t *testing.T) {
+	sim := NewSimulation(2, false)
+	monitor := NewGrowthMonitor(4, 0.5)
+	hooks := 0
+	monitor.OnWarning = func(w GrowthWarning) { hooks++ }
+
+	start := time.Now()
+	if warnings := monitor.Observe(sim, start); len(warnings) != 0 {
+		t.Errorf("Forventede ingen advarsler ved start, fik %v", warnings)
+	}
+	for i := 0; i < 10; i++ {
+		sim.Processes[0].HandleLocalEvent("tick")
+	}
+	warnings := monitor.Observe(sim, start.Add(2*time.Second))
+	if len(warnings) != 1 {
+		t.Fatalf("Forventede 1 advarsel, fik %v", warnings)
+	}
+	if w := warnings[0]; w.Counter != 10 || w.Limit != 15 || w.Rate != 5 || w.Remaining != time.Second {
+		t.Errorf("Forkert advarsel: %+v", w)
+	}
+	monitor.Observe(sim, start.Add(3*time.Second))
+	if hooks != 1 {
+		t.Errorf("OnWarning skulle kun kaldes én gang, blev kaldt %d gange", hooks)
+	}
+
+	// HLC's logiske del har sin egen grænse uanset Bits
+	if _, limit := monitor.counter(HLCSnapshot(0, 3)); limit != 1<<hlcLogicalBits-1 {
+		t.Errorf("Forkert HLC grænse: %d", limit)
+	}
+}
+
+// Tester at scheduleren giver samme interleaving og logs for samme seed
+func TestSchedulerDeterminism(t *testing.T) {
+	run := func(seed int64) (string, string) {
+		sim := NewSimulation(3, true)
+		sim.Out = io.Discard
+		sim.Seed(seed)
+		sim.RunScenario()
+
+		// Rækkefølgen af events på samme simulerede tid afgøres af seed'en
+		s := NewScheduler(sim, seed)
+		order := ""
+		for i := 0; i < 5; i++ {
+			id := i
+			s.At(time.Millisecond, func() { order += fmt.Sprint(id) })
+		}
+		s.Run()
+		return fmt.Sprint(sim.Processes[0].EventLog, sim.Processes[1].EventLog, sim.Processes[2].EventLog), order
+	}
+
+	logs1, order1 := run(7)
+	logs2, order2 := run(7)
+	if logs1 != logs2 || order1 != order2 {
+		t.Errorf("Samme seed skulle give samme kørsel:\n%s\n%s", logs1, logs2)
+	}
+	differs := false
+	for seed := int64(1); seed < 10 && !differs; seed++ {
+		_, order := run(seed)
+		differs = order != order1
+	}
+	if !differs {
+		t.Error("Forventede at forskellige seeds kunne give forskellig rækkefølge")
+	}
+
+	// Omission faults trækker fra processernes seedede RNG, ikke den globale
+	faulty := func(seed int64) string {
+		sim := NewSimulation(3, true)
+		sim.Out = io.Discard
+		for _, p := range sim.Processes {
+			p.Omission = OmissionFaults{Send: 0.3, Receive: 0.3}
+		}
+		s := NewScheduler(sim, seed)
+		for i := 0; i < 30; i++ {
+			from, to := sim.Processes[i%3], sim.Processes[(i+1)%3]
+			s.Send(time.Duration(i)*time.Millisecond, from, to, fmt.Sprint("m", i))
+		}
+		s.Run()
+		omitted := ""
+		for _, p := range sim.Processes {
+			sent, received := p.Omissions()
+			omitted += fmt.Sprintf("%d/%d ", sent, received)
+		}
+		return omitted + RunFingerprint(sim)
+	}
+	if a, b := faulty(3), faulty(3); a != b {
+		t.Errorf("Samme seed skulle give samme omissions og logs, fik %s og %s", a, b)
+	}
+
+	// Hver besked er leveret før det næste trin i scenariet
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	sim.RunScenario()
+	if got := sim.Processes[1].Clock.Now().String(); got != "[3,5,0]" {
+		t.Errorf("Forventede P1 = [3,5,0], fik %s", got)
+	}
+}
+
+// Tester opsummeringsfunktionerne på standard scenariet
+func TestRunStats(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	sim.RunScenario()
+
+	if got := CountConcurrentPairs(sim); got != 24 {
+		t.Errorf("Forventede 24 concurrent par, fik %d", got)
+	}
+	if got := MaxClockEntry(sim); got != 6 {
+		t.Errorf("Forventede største entry 6 (P2's [3,5,6]), fik %d", got)
+	}
+	if MessagesBetween(sim, 0, 1) != 1 || MessagesBetween(sim, 1, 0) != 0 || MessagesBetween(sim, 2, 0) != 1 {
+		t.Error("Forkert antal beskeder mellem processerne")
+	}
+	// P0 → P1 → P2 → P0 kæden plus de lokale events omkring den
+	if got := CausalDepth(sim); got != 11 {
+		t.Errorf("Forventede causal dybde 11, fik %d", got)
+	}
+
+	// Lamport tiden for et event er længden af den længste kæde der ender i det
+	lamport := NewSimulation(3, false)
+	lamport.Out = io.Discard
+	lamport.RunScenario()
+	if CausalDepth(lamport) != MaxClockEntry(lamport) || CountConcurrentPairs(lamport) != 0 {
+		t.Errorf("Forventede dybde = største Lamport tid, fik %d og %d", CausalDepth(lamport), MaxClockEntry(lamport))
+	}
+}
+
+// Tester påstandene i concurrent message demoen for hver clock type
+func TestConcurrencyDemo(t *testing.T) {
+	for _, clockType := range clockTypes {
+		var out bytes.Buffer
+		result := ConcurrencyDemo{ClockType: clockType, Narration: NarrationQuiet, Out: &out, Seed: 1}.Run()
+		if (clockType == "Vector") != result.Detected {
+			t.Errorf("%s: forventede detected=%t, fik %t", clockType, clockType == "Vector", result.Detected)
+		}
+		if !strings.HasPrefix(out.String(), clockType+": P1 sent") {
+			t.Errorf("%s: uventet quiet output %q", clockType, out.String())
+		}
+	}
+
+	// "Begge beskeder sendes med timestamp T6" og "[0,6,0] og [0,0,6]"
+	var out bytes.Buffer
+	lamport := ConcurrencyDemo{ClockType: "Lamport", Narration: NarrationNormal, Out: &out}.Run()
+	if lamport.FromP1.Time() != 6 || lamport.FromP2.Time() != 6 || !strings.Contains(out.String(), "timestamp T6") {
+		t.Errorf("Forventede T6 for begge beskeder, fik %s og %s", lamport.FromP1, lamport.FromP2)
+	}
+	vector := ConcurrencyDemo{ClockType: "Vector", Narration: NarrationNormal, Out: io.Discard}.Run()
+	if vector.FromP1.String() != "[0,6,0]" || vector.FromP2.String() != "[0,0,6]" {
+		t.Errorf("Forventede [0,6,0] og [0,0,6], fik %s og %s", vector.FromP1, vector.FromP2)
+	}
+}
+
+// Tester at duplikater og forældede beskeder genkendes med vector clocks
+func TestArrivalClassifier(t *testing.T) {
+	c := NewArrivalClassifier()
+	if got := c.Classify(0, []int{0, 0, 0}, []int{1, 0, 0}); got != ArrivalNew {
+		t.Errorf("Forventede new, fik %s", got)
+	}
+	if got := c.Classify(0, []int{1, 0, 1}, []int{1, 0, 0}); got != ArrivalDuplicate {
+		t.Errorf("Forventede duplicate, fik %s", got)
+	}
+	// P0's andet event er allerede kendt via en anden proces
+	if got := c.Classify(0, []int{2, 1, 1}, []int{2, 0, 0}); got != ArrivalStale {
+		t.Errorf("Forventede stale, fik %s", got)
+	}
+
+	// Med duplikering leverer scheduleren nogle beskeder to gange
+	sim := NewSimulation(2, true)
+	deliveries := 0
+	sim.Processes[1].OnDeliver = func(p *Process, event Event) bool { deliveries++; return true }
+	s := NewScheduler(sim, 1)
+	s.Faults = TransportFaults{Duplicate: 1}
+	s.Send(0, sim.Processes[0], sim.Processes[1], "hej")
+	s.Run()
+	if deliveries != 2 {
+		t.Errorf("Forventede 2 leveringer, fik %d", deliveries)
+	}
+}
+
+// Tester at den online detector finder concurrent par inden for vinduet mens kørslen står på
+func TestConcurrencyDetector(t *testing.T) {
+	sim := NewSimulation(3, true)
+	detector := NewConcurrencyDetector(2)
+	live := 0
+	detector.OnConcurrent = func(pair ConcurrentPair) { live++ }
+	detector.Attach(sim)
+
+	sim.Processes[0].HandleLocalEvent("a") // [1,0,0]
+	sim.Processes[1].HandleLocalEvent("b") // [0,1,0] ∥ a
+	if live != 1 {
+		t.Fatalf("Forventede at a ∥ b blev fundet med det samme, fik %d", live)
+	}
+	sim.Processes[1].SendMessage(sim.Processes[0], "m") // [0,2,0] ∥ a
+	sim.deliverPending()                                // [2,2,0] efter begge
+	sim.Processes[2].HandleLocalEvent("c")              // [0,0,1] ∥ alt, men kun de 2 seneste er i vinduet
+
+	if got := len(detector.Found()); got != 4 {
+		t.Errorf("Forventede 4 par, fik %d: %v", got, detector.Found())
+	}
+	if total := CountConcurrentPairs(sim); total != 6 {
+		t.Errorf("Forventede 6 concurrent par i alt, fik %d", total)
+	}
+}
+
+// Tester at en besked hvis send event modtageren allerede kendte via en relay ikke er med i reduktionen
+func TestHappensBeforeReduction(t *testing.T) {
+	sim := NewSimulation(3, true)
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p0.SendMessage(p2, "m1") // Forsinkes, så relayen når frem først
+	m1 := <-p2.MessageQueue
+	p0.SendMessage(p1, "m2")
+	sim.deliverPending()
+	p1.SendMessage(p2, "m3")
+	sim.deliverPending()
+	p2.deliver(m1)
+
+	edges := HappensBeforeReduction(sim)
+	kinds := make(map[string]int)
+	for _, edge := range edges {
+		kinds[edge.Kind]++
+	}
+	// Program: én per proces. Beskeder: m2 og m3, mens m1 følger af m2 → m3
+	if kinds["program"] != 3 || kinds["message"] != 2 {
+		t.Errorf("Forventede 3 program og 2 message kanter, fik %v", kinds)
+	}
+
+	var list bytes.Buffer
+	if err := WriteHappensBeforeEdgeList(&list, sim); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(list.String(), "P1:1 P2:0 message\n") {
+		t.Errorf("Forventede relay kanten P1:1 → P2:0, fik:\n%s", list.String())
+	}
+
+	var graphml bytes.Buffer
+	if err := WriteHappensBeforeGraphML(&graphml, sim); err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Nodes []struct{} `xml:"graph>node"`
+		Edges []struct{} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(graphml.Bytes(), &parsed); err != nil {
+		t.Fatalf("GraphML kunne ikke parses: %v", err)
+	}
+	if len(parsed.Nodes) != 6 || len(parsed.Edges) != len(edges) {
+		t.Errorf("Forventede 6 noder og %d kanter, fik %d og %d", len(edges), len(parsed.Nodes), len(parsed.Edges))
+	}
+	if err := ExportHappensBeforeToFile(t.TempDir()+"/graph.dot", "dot", sim); err == nil {
+		t.Error("Forventede fejl for ukendt format")
+	}
+}
+
+// Tester at alle ure overholder clock betingelsen, og at verifieren opdager et ur der ikke gør
+func TestClockCondition(t *testing.T) {
+	verifier := ClockConditionVerifier{Strong: true}
+	for _, clockType := range clockTypes {
+		sim := newSimulationOfType(4, clockType)
+		sim.Seed(1)
+		runConcurrencyWorkload(sim, 10, 0.5)
+		if err := verifier.Check(sim); err != nil {
+			t.Errorf("%s: %v", clockType, err)
+		}
+	}
+
+	sim := NewSimulation(3, true)
+	sim.Processes[0].HandleLocalEvent("a")
+	sim.Processes[0].SendMessage(sim.Processes[1], "m")
+	sim.deliverPending()
+	sim.Processes[2].HandleLocalEvent("c")
+	if err := verifier.Check(sim); err != nil {
+		t.Fatalf("Forventede ingen brud, fik %v", err)
+	}
+
+	// Receive eventet glemmer afsenderens entry: brud på clock betingelsen
+	sim.Processes[1].EventVectors[0] = []int{0, 1, 0}
+	// P2's event påstår at kende P0's første event: brud på den stærke betingelse
+	sim.Processes[2].EventVectors[0] = []int{1, 0, 1}
+	conditions := make(map[string]int)
+	for _, violation := range verifier.Verify(sim) {
+		conditions[violation.Condition]++
+	}
+	if conditions["clock"] != 1 || conditions["strong"] != 1 {
+		t.Errorf("Forventede ét brud af hver slags, fik %v", conditions)
+	}
+	if got := len(ClockConditionVerifier{MaxViolations: 1}.Verify(sim)); got != 1 {
+		t.Errorf("Forventede at MaxViolations stoppede efter 1, fik %d", got)
+	}
+}
+
+// Tester at en crashet proces taber beskeder, og at Recover kun genindlæser uret når det er persisteret
+func TestCrashRecovery(t *testing.T) {
+	sim := NewSimulation(2, false)
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p1.HandleLocalEvent("a")
+	p1.HandleLocalEvent("b") // T2
+	p1.Crash()
+	p1.HandleLocalEvent("ignored")
+	p0.SendMessage(p1, "lost")
+	sim.deliverPending()
+	if len(p1.EventLog) != 2 {
+		t.Fatalf("Forventede at en crashet proces ikke logger events, fik %d", len(p1.EventLog))
+	}
+	if err := p1.Recover(); err == nil {
+		t.Error("Forventede at en fail-stop proces ikke kan genstarte")
+	}
+
+	// Crash-recovery uden persisteret ur: uret starter forfra og bryder clock betingelsen
+	p1.Failure = CrashRecovery
+	if err := p1.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	p1.HandleLocalEvent("c")
+	if got := p1.Clock.Now().Time(); got != 1 {
+		t.Errorf("Forventede T1 efter genstart uden persistering, fik T%d", got)
+	}
+	if violations := (ClockConditionVerifier{}).Verify(sim); len(violations) != 1 {
+		t.Errorf("Forventede ét brud på clock betingelsen, fik %v", violations)
+	}
+
+	// Med persisteret ur fortsætter uret hvor det slap
+	p1.PersistClock = true
+	p1.HandleLocalEvent("d") // T2
+	p1.Crash()
+	if err := p1.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p1.Clock.Now().Time(); got != 2 {
+		t.Errorf("Forventede T2 efter genstart med persistering, fik T%d", got)
+	}
+}
+
+// Tester at AddProcess vokser vector clocks uden at afvise beskeder fra før, og at en fjernet proces er ude
+func TestAddRemoveProcess(t *testing.T) {
+	sim := NewSimulation(2, true)
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.SendMessage(p1, "sendt før join")
+	p2, err := sim.AddProcess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.deliverPending()
+	if p2.ID != 2 || p1.Clock.Now().String() != "[1,1,0]" {
+		t.Errorf("Forventede P2 og [1,1,0] hos P1, fik P%d og %s", p2.ID, p1.Clock.Now())
+	}
+	if err := p1.ReceiveMessage(Event{ProcessID: 0, Message: "[1]|for kort", MessageID: "P0-9"}); !errors.Is(err, ErrMalformedClock) {
+		t.Errorf("Forventede at en vector kortere end før join stadig afvises, fik %v", err)
+	}
+
+	p1.SendMessage(p2, "hej")
+	sim.RemoveProcess(p0)
+	p2.SendMessage(p0, "tabt")
+	sim.deliverPending()
+	if len(p0.EventLog) != 1 || len(sim.Members()) != 2 {
+		t.Errorf("Forventede at P0 ikke modtog noget efter den blev fjernet (%d events, %d medlemmer)", len(p0.EventLog), len(sim.Members()))
+	}
+	if err := (ClockConditionVerifier{Strong: true}).Check(sim); err != nil {
+		t.Error(err)
+	}
+
+	pruned := NewSimulationWithClocks(2, func(id int, n int) LogicalClock { return NewPrunedVectorClock(n, id, 1) })
+	if _, err := pruned.AddProcess(); err == nil {
+		t.Error("Forventede fejl for et ur der ikke kan vokse")
+	}
+}
+
+// Tester at en workload fil giver samme kørsel efter en tur gennem JSON, og at fejl i filen afvises
+func TestWorkloadFile(t *testing.T) {
+	w := GenerateWorkload(3, 40, 0.5, 7)
+	var buf bytes.Buffer
+	if err := WriteWorkload(&buf, w); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadWorkload(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clockType := range clockTypes {
+		first, err := w.Run(clockType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := loaded.Run(clockType)
+		if RunFingerprint(first) != RunFingerprint(second) {
+			t.Errorf("%s: forventede samme fingerprint efter JSON", clockType)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"format":"dissy-workload/2","processes":2,"seed":1,"events":[]}`,
+		`{"format":"dissy-workload/1","processes":2,"seed":1,"events":[{"at_ms":0,"process":0,"kind":"send","to":0}]}`,
+		`{"format":"dissy-workload/1","processes":2,"seed":1,"evnets":[]}`,
+	} {
+		if _, err := ReadWorkload(strings.NewReader(bad)); err == nil {
+			t.Errorf("Forventede fejl for %s", bad)
+		}
+	}
+}
+
+// Tester scenarie filer: eksemplet kører med alle ure, JSON og YAML giver samme kørsel, og fejl findes før kørslen
+func TestScenarioFile(t *testing.T) {
+	data, err := os.ReadFile("scenarios/crash-recovery.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	example, err := ParseScenarioFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clockType := range clockTypes {
+		if _, err := example.Run(clockType, io.Discard); err != nil {
+			t.Errorf("%s: %v", clockType, err)
+		}
+	}
+
+	calls := 0
+	RegisterScenarioHook("test-count", func(sim *Simulation) error {
+		calls++
+		if got := len(sim.Processes[1].EventLog); got != 1 {
+			return fmt.Errorf("forventede at P1 havde modtaget beskeden, har %d events", got)
+		}
+		return nil
+	})
+	yaml := "processes: 2\nseed: 3\nsteps:\n  - P0 local a\n  - P0 send P1 hej\n  - wait 20ms\n  - hook test-count\n"
+	json := `{"processes": 2, "seed": 3, "steps": ["P0 local a", "P0 send P1 hej", "wait 20ms", "hook test-count"]}`
+	fingerprints := make([]string, 0)
+	for _, source := range []string{yaml, json} {
+		f, err := ParseScenarioFile([]byte(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim, err := f.Run("Lamport", io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fingerprints = append(fingerprints, RunFingerprint(sim))
+	}
+	if calls != 2 || fingerprints[0] != fingerprints[1] {
+		t.Errorf("Forventede 2 hook kald og ens kørsler, fik %d og %v", calls, fingerprints)
+	}
+
+	for _, bad := range []string{
+		"processes: 2\nsteps:\n  - P0 dance\n",
+		"processes: 2\nsteps:\n  - hook findes-ikke\n",
+		"processes: 2\nsteps:\n  - P0 send P7\n",
+		"processes: 2\nsteps:\n  - wait 10ms\n  - at 5ms\n",
+	} {
+		f, err := ParseScenarioFile([]byte(bad))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Run("", io.Discard); err == nil {
+			t.Errorf("Forventede fejl for %q", bad)
+		}
+	}
+	if _, err := ParseScenarioFile([]byte("processes: 2\nclocks: Vector\n")); err == nil {
+		t.Error("Forventede fejl for ukendt nøgle")
+	}
+}
+
+// Tester hook steps: en hook kan ændre en proces midt i scenariet, en fejl stopper kørslen
+// med step nummeret, og hooks kan registreres mens andre scenarier kører
+func TestScenarioHooks(t *testing.T) {
+	RegisterScenarioHook("test-local", func(sim *Simulation) error {
+		sim.Processes[0].HandleLocalEvent("fra hook")
+		return nil
+	})
+	RegisterScenarioHook("test-fail", func(sim *Simulation) error {
+		return fmt.Errorf("hook fejlede")
+	})
+	f, err := ParseScenarioFile([]byte("processes: 2\nsteps:\n  - hook test-local\n  - P0 send P1 x\n  - wait 10ms\n  - assert P1 clock[0] == 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, err := f.Run("Vector", io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log := sim.Processes[0].EventLog; len(log) != 2 || !strings.Contains(log[0], "fra hook") {
+		t.Errorf("Forventede hookens lokale event først i P0's log, fik %v", log)
+	}
+
+	f, err = ParseScenarioFile([]byte("processes: 1\nsteps:\n  - P0 local\n  - hook test-fail\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Run("", io.Discard); err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("Forventede at step 2 fejlede, fik %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterScenarioHook(fmt.Sprintf("test-concurrent-%d", i), func(sim *Simulation) error { return nil })
+		}()
+		go func() {
+			defer wg.Done()
+			f, err := ParseScenarioFile([]byte("processes: 1\nsteps:\n  - hook test-local\n"))
+			if err == nil {
+				_, err = f.Run("", io.Discard)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if names := ScenarioHookNames(); !sort.StringsAreSorted(names) || len(names) < 12 {
+		t.Errorf("Forventede mindst 12 sorterede hooks, fik %v", names)
+	}
+}
+
+// Tester loss, partition og heal steps: eksemplet holder sine assertions, tabte beskeder
+// afhænger kun af seedet, og en optagelse af kørslen kan afspilles
+func TestScenarioFaultSteps(t *testing.T) {
+	data, err := os.ReadFile("scenarios/partition.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseScenarioFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, recording, err := f.Record("", io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := f.Run("", io.Discard)
+	if err != nil || RunFingerprint(again) != RunFingerprint(sim) {
+		t.Errorf("Samme seed skulle tabe de samme beskeder (%v)", err)
+	}
+	replayed, err := recording.Replay("Vector")
+	if err != nil || RunFingerprint(replayed) != RunFingerprint(sim) {
+		t.Errorf("Optagelsen med tabte beskeder skulle kunne afspilles (%v)", err)
+	}
+	if delivered := len(MessageEdges(sim)); delivered >= 9 {
+		t.Errorf("Forventede at partitionen og loss tabte beskeder, %d af 9 kom frem", delivered)
+	}
+
+	lost := "processes: 3\nsteps:\n  - partition P0 P2 | P1\n  - P0 send P1 a\n  - P0 send P2 b\n  - wait 20ms\n  - heal\n  - P0 send P1 c\n  - wait 20ms\n  - loss 1\n  - P0 send P2 d\n  - wait 20ms\n"
+	f, err = ParseScenarioFile([]byte(lost + "  - assert P2 delivered M2\n  - assert P1 delivered M3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Run("", io.Discard); err != nil {
+		t.Errorf("P2 er på P0's side og P1 får beskeder efter heal: %v", err)
+	}
+	for _, missing := range []string{"P1 delivered M1", "P2 delivered M4"} {
+		f, err = ParseScenarioFile([]byte(lost + "  - assert " + missing + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Run("", io.Discard); err == nil {
+			t.Errorf("Forventede at beskeden var tabt: %s", missing)
+		}
+	}
+
+	for _, bad := range []string{"loss 2", "loss x", "partition P0", "partition P0 | P9", "heal now"} {
+		f, err := ParseScenarioFile([]byte("processes: 2\nsteps:\n  - " + bad + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Run("", io.Discard); err == nil {
+			t.Errorf("Forventede fejl for %q", bad)
+		}
+	}
+}
+
+// Tester at opdateringen går én nabo per hop i en ring og via hubben i en stjerne
+func TestKnowledgePropagation(t *testing.T) {
+	for _, a := range measureKnowledgePropagation("ring", 1, 5, 10, 1) {
+		if want := (a.Process - knowledgeOrigin + 5) % 5; a.Hops != want {
+			t.Errorf("Ring: forventede %d hops til P%d, fik %d", want, a.Process, a.Hops)
+		}
+	}
+	star := measureKnowledgePropagation("star", 1, 5, 10, 1)
+	for _, a := range star {
+		want := 2
+		switch a.Process {
+		case knowledgeOrigin:
+			want = 0
+		case 0:
+			want = 1
+		}
+		if a.Hops != want || a.Time < 0 {
+			t.Errorf("Stjerne: forventede %d hops til P%d, fik %d (tid %v)", want, a.Process, a.Hops, a.Time)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKnowledgePropagationCSV(&buf, star); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 6 || !strings.HasPrefix(buf.String(), "topology,fanout,process,hops,time_ms\n") {
+		t.Errorf("Uventet CSV:\n%s", buf.String())
+	}
+}
+
+// Tester at traces fra to kørsler merges uden at P0 i den ene bliver til P0 i den anden
+func TestMergeTraces(t *testing.T) {
+	a, b := NewSimulation(2, true), NewNamedSimulation([]string{"db", "cache"}, true)
+	for _, sim := range []*Simulation{a, b} {
+		sim.Out = io.Discard
+		sim.Processes[0].SendMessage(sim.Processes[1], "x")
+		sim.deliverPending()
+	}
+	if b.Processes[1].UID != "cache" || len(a.Processes[0].UID) != 36 || a.Processes[0].UID == a.Processes[1].UID {
+		t.Errorf("Uventede ID'er: %q %q %q", a.Processes[0].UID, a.Processes[1].UID, b.Processes[1].UID)
+	}
+	joined, err := a.AddProcess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position, ok := a.Membership.Position(joined.UID); !ok || position != joined.ID {
+		t.Errorf("Forventede position %d for ny proces, fik %d", joined.ID, position)
+	}
+
+	table, events := MergeTraces(a, b)
+	if table.Len() != 5 || len(events) != 4 {
+		t.Fatalf("Forventede 5 ID'er og 4 events, fik %d og %d", table.Len(), len(events))
+	}
+	for _, x := range events[:2] {
+		for _, y := range events[2:] {
+			if Compare(x.Clock.vector, y.Clock.vector) != Concurrent {
+				t.Errorf("%s#%d og %s#%d burde være concurrent: %s %s", x.Label, x.Index, y.Label, y.Index, x.Clock, y.Clock)
+			}
+		}
+	}
+	if Compare(events[2].Clock.vector, events[3].Clock.vector) != Before || events[3].Label != "cache" {
+		t.Errorf("Forventede db#0 før cache#0, fik %s %s", events[2].Clock, events[3].Clock)
+	}
+	if got := table.Translate([]int{0, 1}, b.Membership); got[table.Len()-1] != 1 {
+		t.Errorf("Forventede cache sidst i den fælles tabel, fik %v", got)
+	}
+}
+
+// Tester at en optaget kørsel afspilles med samme interleaving med alle ure
+func TestRecordReplay(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	s := NewScheduler(sim, 7)
+	s.Faults = TransportFaults{Reorder: 0.3, ReorderDelay: 20 * time.Millisecond, Duplicate: 0.2}
+	s.Recording = &Recording{}
+	for i := 0; i < 30; i++ {
+		at, p := time.Duration(i)*time.Millisecond, sim.Processes[i%3]
+		if i%2 == 0 {
+			s.Send(at, p, sim.Processes[(i+1)%3], fmt.Sprintf("m%d", i))
+		} else {
+			s.Local(at, p, fmt.Sprintf("e%d", i))
+		}
+	}
+	s.Crash(12*time.Millisecond, sim.Processes[2])
+	sim.Processes[2].Failure = CrashRecovery
+	s.Recover(18*time.Millisecond, sim.Processes[2], func(err error) { t.Error(err) })
+	s.Run()
+	s.Recording.finish(sim, "Vector", 7)
+
+	var buf bytes.Buffer
+	if err := WriteRecording(&buf, s.Recording); err != nil {
+		t.Fatal(err)
+	}
+	recording, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, clockType := range clockTypes {
+		replayed, err := recording.Replay(clockType)
+		if err != nil {
+			t.Fatalf("%s: %v", clockType, err)
+		}
+		if got := InterleavingFingerprint(replayed); got != recording.Interleaving {
+			t.Errorf("%s: forventede interleaving %s, fik %s", clockType, recording.Interleaving, got)
+		}
+		if clockType == "Vector" && RunFingerprint(replayed) != RunFingerprint(sim) {
+			t.Error("Forventede samme vector logs som den optagne kørsel")
+		}
+	}
+
+	recording.Events = append(recording.Events, RecordedEvent{AtNs: recording.Events[len(recording.Events)-1].AtNs, Kind: "deliver", MessageID: "P9-1"})
+	if _, err := recording.Replay("Lamport"); err == nil {
+		t.Error("Forventede fejl for levering af en besked der aldrig blev sendt")
+	}
+}
+
+// Tester at en live simulation optaget med RecordingMiddleware kan skrives, læses og
+// afspilles til de samme logs
+func TestRecordingMiddleware(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	recorder := NewRecordingMiddleware(sim)
+	done := make(chan bool)
+	for _, p := range sim.Processes {
+		p.Run(done)
+	}
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p0.HandleLocalEvent("start")
+	var wg sync.WaitGroup
+	for _, sender := range []*Process{p0, p2} {
+		sender := sender
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				sender.SendMessage(p1, fmt.Sprintf("%s-%d", sender.Label(), i))
+			}
+		}()
+	}
+	wg.Wait()
+	if err := sim.WaitUntilIdle(); err != nil {
+		t.Fatal(err)
+	}
+	p1.SendMessage(p2, "svar")
+	p2.HandleLocalEvent("slut")
+	if err := sim.WaitUntilIdle(); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+
+	var buf bytes.Buffer
+	if err := WriteRecording(&buf, recorder.Recording()); err != nil {
+		t.Fatal(err)
+	}
+	recording, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Events) != 24 || recording.Clock != "Vector" {
+		t.Errorf("Forventede 24 Vector events, fik %d %s", len(recording.Events), recording.Clock)
+	}
+	replayed, err := recording.Replay("Vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if RunFingerprint(replayed) != RunFingerprint(sim) {
+		t.Error("Forventede samme vector logs som den optagne live kørsel")
+	}
+}
+
+// Tester at en ny proces med state transfer starter efter alt donoren kendte
+func TestStateTransfer(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("write")
+	p0.SendMessage(p1, "x")
+	sim.deliverPending()
+	p1.HandleLocalEvent("only P1 knows")
+
+	joiner, transfer, err := sim.JoinWithStateTransfer(p1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Compare(transfer.Clock.vector, joiner.Clock.Now().vector) != Before {
+		t.Errorf("Forventede joiner efter donoren, fik %s og %s", transfer.Clock, joiner.Clock.Now())
+	}
+	// P1's lokale event og P1's svar kendes ikke af P0, så kun P0's to første events er stabile
+	if len(transfer.Stable) != 2 || transfer.Stable[0].Label != "P0" || transfer.Stable[1].Type != "send" {
+		t.Errorf("Uventede stabile events: %v", transfer.Stable)
+	}
+	if _, err := sim.TransferState(joiner, joiner, 1); err == nil {
+		t.Error("Forventede fejl for state transfer til sig selv")
+	}
+}
+
+// Tester at en Behavior driver processerne både med scheduleren og med deliverPending
+func TestProcessBehavior(t *testing.T) {
+	for _, clockType := range clockTypes {
+		sim := newSimulationOfType(3, clockType)
+		sim.Out = io.Discard
+		counters := NewReplicatedCounters(sim, 3)
+		s := NewScheduler(sim, 2)
+		s.Tick(10*time.Millisecond, 100*time.Millisecond)
+		s.Run()
+		for i, c := range counters {
+			if c.Value() != 9 {
+				t.Errorf("%s: forventede værdi 9 på P%d, fik %d", clockType, i, c.Value())
+			}
+		}
+		if err := (ClockConditionVerifier{}).Check(sim); err != nil {
+			t.Errorf("%s: %v", clockType, err)
+		}
+	}
+
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	counters := NewReplicatedCounters(sim, 1)
+	sim.Processes[0].Behavior.OnTick(sim.Processes[0])
+	sim.deliverPending()
+	if counters[1].Value() != 1 || sim.Processes[1].Clock.Now().At(0) != 2 {
+		t.Errorf("Forventede at P1 modtog P0's increment, fik værdi %d og ur %s", counters[1].Value(), sim.Processes[1].Clock.Now())
+	}
+}
+
+// Tester at et broadcast er ét send event som alle modtagere får samme header fra
+func TestBroadcastMulticast(t *testing.T) {
+	sim := NewSimulation(4, true)
+	sim.Out = io.Discard
+	p0, p1, p2, p3 := sim.Processes[0], sim.Processes[1], sim.Processes[2], sim.Processes[3]
+
+	p0.Broadcast("hello")
+	sim.deliverPending()
+	if len(p0.EventLog) != 1 || p0.Clock.Now().At(0) != 1 {
+		t.Errorf("Forventede ét send event på P0, fik %v", p0.EventLog)
+	}
+	edges := MessageEdges(sim)
+	if len(edges) != 3 {
+		t.Fatalf("Forventede 3 modtagere, fik %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.ID != edges[0].ID || edge.Send.Index != 0 || edge.Receive.Clock.At(0) != 1 {
+			t.Errorf("Uventet kant %v", edge)
+		}
+	}
+
+	p1.Multicast([]*Process{p1, p2, p2}, "only P2")
+	sim.deliverPending()
+	if len(p2.EventLog) != 2 || len(p3.EventLog) != 1 {
+		t.Errorf("Forventede at kun P2 fik multicastet, fik %d og %d events", len(p2.EventLog), len(p3.EventLog))
+	}
+	if err := (ClockConditionVerifier{Strong: true}).Check(sim); err != nil {
+		t.Error(err)
+	}
+}
+
+// Tester at causal delivery holder et broadcast tilbage til dets afhængigheder er leveret
+func TestCausalDelivery(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	sim.EnableCausalDelivery()
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+
+	p0.Broadcast("first")
+	p0.Broadcast("second")
+	first, second := <-p2.MessageQueue, <-p2.MessageQueue
+	sim.deliverPending()
+	p1.Broadcast("reply")
+
+	p2.deliver(<-p2.MessageQueue) // reply afhænger af begge P0's broadcasts
+	p2.deliver(second)
+	if p2.HeldBack() != 2 || len(p2.EventLog) != 0 {
+		t.Fatalf("Forventede 2 tilbageholdte beskeder og ingen events, fik %d og %v", p2.HeldBack(), p2.EventLog)
+	}
+	p2.deliver(first)
+	p2.deliver(first) // Dublet
+	if p2.HeldBack() != 0 || len(p2.EventLog) != 3 {
+		t.Fatalf("Forventede 3 leverede beskeder, fik %v", p2.EventLog)
+	}
+	for i, want := range []string{"P0-1", "P0-2", "P1-1"} {
+		if p2.EventMessageIDs[i] != want {
+			t.Errorf("Forventede %s som nr. %d, fik %s", want, i+1, p2.EventMessageIDs[i])
+		}
+	}
+
+	p1.SendMessage(p2, "direct")
+	sim.deliverPending()
+	if len(p2.EventLog) != 4 {
+		t.Error("Forventede at en almindelig besked leveres ved ankomst")
+	}
+}
+
+// Tester at søgningen finder en workload der er mindst lige så god som den tilfældige
+func TestStressSearch(t *testing.T) {
+	p0, p1 := 0, 1
+	w := &Workload{Format: WorkloadFormat, Processes: 2, Seed: 1, Events: []WorkloadEvent{
+		{AtMs: 0, Process: 0, Kind: "send", To: &p1},
+		{AtMs: 20, Process: 1, Kind: "send", To: &p0},
+		{AtMs: 40, Process: 0, Kind: "local"},
+	}}
+	coverage, err := MeasureWorkloadCoverage(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// P0 send → P1 receive → P1 send → P0 receive → P0 local
+	if coverage != (WorkloadCoverage{ConcurrentPairs: 0, ChainDepth: 5, PeakInFlight: 1}) {
+		t.Errorf("Uventet coverage: %+v", coverage)
+	}
+
+	for _, objective := range fuzzObjectives {
+		search, err := GenerateStressWorkload(3, 20, objective, 100, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if search.BestScore.Score(objective) < search.Baseline.Score(objective) || search.Best.Validate() != nil {
+			t.Errorf("%s: bedste %+v er dårligere end %+v eller ugyldig", objective, search.BestScore, search.Baseline)
+		}
+	}
+}
+
+// Tester at en kort soak i simuleret tid går igennem, og at leak detektoren skelner
+// en måling der vokser fra en der svinger omkring et niveau
+func TestSoak(t *testing.T) {
+	config := SoakConfig{Duration: time.Minute, SampleEvery: 5 * time.Second, Virtual: true, Processes: 4, Rate: 50, Seed: 1}
+	report, err := RunSoak(config, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Samples) != 12 || report.Events != 3000 {
+		t.Errorf("Forventede 12 målinger og 3000 events, fik %d og %d", len(report.Samples), report.Events)
+	}
+
+	growing, flat := make([]SoakSample, 20), make([]SoakSample, 20)
+	for i := range growing {
+		growing[i] = SoakSample{Goroutines: 10 + i, HeapBytes: 4 << 20, QueueDepth: 5}
+		flat[i] = SoakSample{Goroutines: 10 + i%3, HeapBytes: uint64(4<<20 + i%2*(512<<10)), QueueDepth: 5 * (i % 4)}
+	}
+	if leaks := findSoakLeaks(growing); len(leaks) != 1 || !strings.HasPrefix(leaks[0], "goroutines") {
+		t.Errorf("Forventede en goroutine leak, fik %v", leaks)
+	}
+	if leaks := findSoakLeaks(flat); len(leaks) != 0 {
+		t.Errorf("Forventede ingen leaks, fik %v", leaks)
+	}
+}
+
+// Tester at et FIFO link holder beskeder tilbage til de tidligere er leveret og dropper
+// dubletter, mens linket i den anden retning leverer ved ankomst
+func TestFIFODelivery(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	sim.EnableFIFOLink(p0, p1)
+
+	for _, message := range []string{"a", "b", "c"} {
+		p0.SendMessage(p1, message)
+	}
+	a, b, c := <-p1.MessageQueue, <-p1.MessageQueue, <-p1.MessageQueue
+	p1.deliver(c)
+	p1.deliver(b)
+	if len(p1.EventLog) != 0 || p1.HeldBack() != 2 {
+		t.Fatalf("Forventede 2 beskeder holdt tilbage, fik %d (og %d events)", p1.HeldBack(), len(p1.EventLog))
+	}
+	p1.deliver(a)
+	p1.deliver(b)
+	if got := strings.Join(p1.EventMessageIDs, " "); got != "P0-1 P0-2 P0-3" || p1.HeldBack() != 0 {
+		t.Errorf("Forventede P0-1 P0-2 P0-3 i rækkefølge, fik %q", got)
+	}
+
+	p1.SendMessage(p0, "x")
+	p1.SendMessage(p0, "y")
+	x, y := <-p0.MessageQueue, <-p0.MessageQueue
+	p0.deliver(y)
+	p0.deliver(x)
+	if got := strings.Join(p0.EventMessageIDs[len(p0.EventMessageIDs)-2:], " "); got != "P1-2 P1-1" {
+		t.Errorf("Forventede levering ved ankomst på P1→P0, fik %q", got)
+	}
+}
+
+// Tester at en alert der venter i causal bufferen løfter den bulk besked den afhænger af
+// forbi uafhængige beskeder i inboxen, men kun med InheritPriority
+func TestPriorityInheritance(t *testing.T) {
+	for _, inherit := range []bool{false, true} {
+		sim := NewSimulation(3, true)
+		sim.Out = io.Discard
+		sim.EnableCausalDelivery()
+		p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+
+		p0.Broadcast("bulk")
+		p1.deliver(<-p1.MessageQueue)
+		bulk := <-p2.MessageQueue
+		p1.Broadcast("alert")
+		alert := <-p2.MessageQueue
+		inbox := make([]Event, 0)
+		for i := 0; i < 3; i++ {
+			p0.Multicast([]*Process{p2}, "filler")
+			inbox = append(inbox, <-p2.MessageQueue)
+		}
+
+		s := NewScheduler(sim, 1)
+		s.ServiceTime = time.Millisecond
+		s.Priority = func(event Event) int {
+			if strings.HasSuffix(event.Message, "|alert") {
+				return 1
+			}
+			return 0
+		}
+		s.InheritPriority = inherit
+		s.inbox[p2.ID] = append(inbox, bulk, alert)
+		s.serveNext(p2)
+		s.Run()
+
+		expected := "P0-2 P0-3 P0-4 P0-1 P1-1"
+		if inherit {
+			expected = "P0-1 P1-1 P0-2 P0-3 P0-4"
+		}
+		if got := strings.Join(p2.EventMessageIDs, " "); got != expected {
+			t.Errorf("InheritPriority %v: forventede %s, fik %s", inherit, expected, got)
+		}
+	}
+}
+
+// Tester at WaitUntilIdle venter på en kæde af beskeder der sendes videre under
+// leveringen, og at den giver op når en proces med beskeder i køen ikke kører
+func TestWaitUntilIdle(t *testing.T) {
+	sim := NewSimulation(4, true)
+	sim.Out = io.Discard
+	for _, p := range sim.Processes {
+		p.ProcessingDelay = FixedDelay(2 * time.Millisecond)
+		p.OnEvent = func(p *Process, event LoggedEvent) {
+			if event.Type == "receive" && p.ID < 3 {
+				p.SendMessage(sim.Processes[p.ID+1], "relay")
+			}
+		}
+	}
+	done := make(chan bool)
+	for _, p := range sim.Processes {
+		p.Run(done)
+	}
+	sim.Processes[0].SendMessage(sim.Processes[1], "start")
+	if err := sim.WaitUntilIdle(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.Processes[3].EventLog) != 1 {
+		t.Errorf("Forventede at P3 havde modtaget relay beskeden, fik %v", sim.Processes[3].EventLog)
+	}
+	close(done)
+
+	defer func(timeout time.Duration) { idleStallTimeout = timeout }(idleStallTimeout)
+	idleStallTimeout = 20 * time.Millisecond
+	stopped := NewSimulation(2, true)
+	stopped.Out = io.Discard
+	stopped.Processes[0].SendMessage(stopped.Processes[1], "never delivered")
+	if err := stopped.WaitUntilIdle(); err == nil || !strings.Contains(err.Error(), "P1: 1") {
+		t.Errorf("Forventede en fejl om P1's kø, fik %v", err)
+	}
+}
+
+// Tester at WatchClock får uret efter hver ændring, tæller opdateringer der ikke er plads
+// til, og ikke får flere efter Stop
+func TestWatchClock(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	updates := make(chan ClockSnapshot, 10)
+	watch := p1.WatchClock(updates)
+
+	p1.HandleLocalEvent("a")
+	p0.SendMessage(p1, "b")
+	sim.deliverPending()
+	p1.SendMessage(p0, "c")
+	sim.deliverPending()
+	got := make([]string, 0)
+	for len(updates) > 0 {
+		got = append(got, (<-updates).String())
+	}
+	if strings.Join(got, " ") != "[0,1] [1,2] [1,3]" {
+		t.Errorf("Forventede [0,1] [1,2] [1,3], fik %v", got)
+	}
+
+	full := make(chan ClockSnapshot)
+	blocked := p1.WatchClock(full)
+	p1.HandleLocalEvent("d")
+	watch.Stop()
+	p1.HandleLocalEvent("e")
+	if len(updates) != 1 || blocked.Dropped() != 2 {
+		t.Errorf("Forventede 1 opdatering efter Stop og 2 droppede, fik %d og %d", len(updates), blocked.Dropped())
+	}
+}
+
+// Tester at RealTime venter til eventernes tid er nået, og at begge tilstande giver
+// samme interleaving
+func TestTimeModes(t *testing.T) {
+	fingerprints := make([]string, 0)
+	for _, mode := range []TimeMode{VirtualTime, RealTime} {
+		sim := NewSimulation(3, true)
+		sim.Out = io.Discard
+		sim.Seed(1)
+		sim.TimeMode = mode
+		started := time.Now()
+		span := sim.RunScenario()
+		elapsed := time.Since(started)
+		if mode == RealTime && elapsed < span || mode == VirtualTime && elapsed >= span {
+			t.Errorf("%s: scenariet på %v tog %v", mode, span, elapsed)
+		}
+		fingerprints = append(fingerprints, RunFingerprint(sim))
+	}
+	if fingerprints[0] != fingerprints[1] {
+		t.Errorf("Forventede samme fingerprint, fik %v", fingerprints)
+	}
+}
+
+// Tester at step mode stopper på q efter det antal events brugeren bad om, og at c kører
+// scenariet færdigt med de samme logs som RunScenario
+func TestStepThrough(t *testing.T) {
+	sim := NewSimulation(3, true)
+	sim.Out = io.Discard
+	sim.Seed(1)
+	var out bytes.Buffer
+	StepThrough(sim, sim.scheduleScenario(), strings.NewReader("\nn\nq\n"), &out)
+	if !strings.Contains(out.String(), "Step 3 at") || strings.Contains(out.String(), "Step 4 at") {
+		t.Errorf("Forventede 3 steps, fik:\n%s", out.String())
+	}
+
+	stepped := NewSimulation(3, true)
+	stepped.Out = io.Discard
+	stepped.Seed(1)
+	out.Reset()
+	StepThrough(stepped, stepped.scheduleScenario(), strings.NewReader("c\n"), &out)
+	whole := NewSimulation(3, true)
+	whole.Out = io.Discard
+	whole.Seed(1)
+	whole.RunScenario()
+	if !strings.Contains(out.String(), "Done after 16 events") || RunFingerprint(stepped) != RunFingerprint(whole) {
+		t.Errorf("Forventede samme kørsel som RunScenario, fik:\n%s", out.String())
+	}
+}
+
+// Tester middelværdi, varians og histogram, og at fordelingen over kørsler er
+// reproducerbar og aldrig har en Lamport tid over vector summen
+func TestClockDistribution(t *testing.T) {
+	d := NewClockDistribution([]float64{1, 2, 3, 4})
+	if d.Mean != 2.5 || math.Abs(d.Variance-5.0/3) > 1e-9 || fmt.Sprint(d.Histogram(2)) != "[2 2]" {
+		t.Errorf("Forkert fordeling: %+v, histogram %v", d, d.Histogram(2))
+	}
+
+	lamport, vectorSum, err := MeasureClockDistribution(3, 50, 0.5, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _, _ := MeasureClockDistribution(3, 50, 0.5, 10, 1)
+	if fmt.Sprint(lamport.Values) != fmt.Sprint(again.Values) {
+		t.Errorf("Forventede samme værdier med samme seed, fik %v og %v", lamport.Values, again.Values)
+	}
+	for r := range lamport.Values {
+		if lamport.Values[r] > vectorSum.Values[r] {
+			t.Errorf("Kørsel %d: Lamport tid %.1f over vector sum %.1f", r, lamport.Values[r], vectorSum.Values[r])
+		}
+	}
+}
+
+// Tester at FitComplexity finder konstanten og eksponenten på eksakte data og melder
+// afvigelser fra teorien
+func TestComplexityFit(t *testing.T) {
+	ns := []int{2, 4, 8, 16}
+	flat := FitComplexity("flat", 0, ns, []float64{5, 5, 5, 5})
+	if math.Abs(flat.Constant-5) > 1e-9 || math.Abs(flat.Exponent) > 1e-9 || flat.Verdict() != "matches O(1)" {
+		t.Errorf("Forventede c=5, k=0 og O(1), fik %+v (%s)", flat, flat.Verdict())
+	}
+	linear := FitComplexity("linear", 1, ns, []float64{6, 12, 24, 48})
+	if math.Abs(linear.Constant-3) > 1e-9 || math.Abs(linear.Exponent-1) > 1e-9 || linear.FitError > 1e-9 || linear.ExponentR2 < 0.999 {
+		t.Errorf("Forventede c=3, k=1 uden fejl, fik %+v", linear)
+	}
+	quadratic := FitComplexity("quadratic", 1, ns, []float64{4, 16, 64, 256})
+	if !strings.HasPrefix(quadratic.Verdict(), "faster than O(n)") || complexityName(quadratic.Exponent) != "O(n²)" {
+		t.Errorf("Forventede kvadratisk vækst flaget mod O(n), fik %+v (%s)", quadratic, quadratic.Verdict())
+	}
+}
+
+// Tester at et eksperiment giver samme fordeling uanset parallelitet, og at
+// konfidensintervallet ligger omkring middelværdien
+func TestRunExperiment(t *testing.T) {
+	scenario := workloadExperiment(3, 60, 0.5)
+	serial, err := RunExperiment(scenario, 12, 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := RunExperiment(scenario, 12, 4, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Lamport orderable pairs %", "Concurrent pairs %", "Vector header B/msg"} {
+		a, okA := serial.Metric(name)
+		b, okB := parallel.Metric(name)
+		if !okA || !okB || fmt.Sprint(a.Distribution.Values) != fmt.Sprint(b.Distribution.Values) {
+			t.Errorf("%s: forventede samme værdier serielt og parallelt, fik %v og %v", name, a.Distribution.Values, b.Distribution.Values)
+		}
+		if a.Low > a.Distribution.Mean || a.High < a.Distribution.Mean || len(a.Distribution.Values) != 12 {
+			t.Errorf("%s: forkert konfidensinterval [%.3f, %.3f] om %.3f", name, a.Low, a.High, a.Distribution.Mean)
+		}
+	}
+
+	low, high := confidenceInterval95(NewClockDistribution([]float64{1, 3}))
+	if math.Abs(low-(2-12.706)) > 1e-9 || math.Abs(high-(2+12.706)) > 1e-9 {
+		t.Errorf("Forventede [%.3f, %.3f], fik [%.3f, %.3f]", 2-12.706, 2+12.706, low, high)
+	}
+}
+
+// Tester at hooks ser hvert event med samme clock værdi som loggen, også for processer
+// tilføjet senere og når events ikke gemmes
+func TestEventHooks(t *testing.T) {
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	counts := make(map[string]int)
+	sim.AddEventHook(func(event StampedEvent) {
+		counts[event.Type]++
+		p := event.Process
+		if !p.DiscardEvents && p.loggedEvent(len(p.EventLog)-1).Clock.String() != event.Clock.String() {
+			t.Errorf("%s: hook fik %s, loggen har %s", event.Log(), event.Clock, p.loggedEvent(len(p.EventLog)-1).Clock)
+		}
+	})
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("a")
+	p0.SendMessage(p1, "b")
+	if err := p1.ReceiveMessage(<-p1.MessageQueue); err != nil {
+		t.Fatal(err)
+	}
+	if p1.EventLog[0] != "P1: Receive from P0 (received [2,0], was [0,0] → synchronized to [2,1]): b" {
+		t.Errorf("Uventet log linje: %s", p1.EventLog[0])
+	}
+
+	p2, err := sim.AddProcess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2.DiscardEvents = true
+	p2.HandleLocalEvent("c")
+	if counts["local"] != 2 || counts["send"] != 1 || counts["receive"] != 1 || len(p2.EventLog) != 0 {
+		t.Errorf("Forventede 2 local, 1 send og 1 receive og ingen log for P2, fik %v og %d", counts, len(p2.EventLog))
+	}
+}
+
+// Tester watch: events skrives i den rækkefølge de sker, filtreret på proces
+func TestWatchCommand(t *testing.T) {
+	var out strings.Builder
+	if err := watchCommand([]string{"-process", "P1"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	phase2, received := -1, -1
+	for i, line := range lines {
+		if strings.Contains(line, "Phase 2") {
+			phase2 = i
+		}
+		if strings.Contains(line, "P1: Receive from P0") {
+			received = i
+		}
+		if strings.Contains(line, "P0: ") || strings.Contains(line, "P2: ") {
+			t.Errorf("Kun P1's events skulle vises, fik %q", line)
+		}
+	}
+	if phase2 < 0 || received < phase2 {
+		t.Errorf("P1's receive skulle vises efter Phase 2 markeringen:\n%s", out.String())
+	}
+
+	scenario := filepath.Join(t.TempDir(), "chat.yaml")
+	os.WriteFile(scenario, []byte("processes: 2\nnames: [alice, bob]\nsteps:\n  - alice send bob hej\n  - wait 20ms\n  - say done\n"), 0o644)
+	out.Reset()
+	if err := watchCommand([]string{"-process", "bob", scenario}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "bob: Receive from alice") || strings.Index(got, "Receive") > strings.Index(got, "done") {
+		t.Errorf("Bob's receive skulle vises før say linjen:\n%s", got)
+	}
+	if err := watchCommand([]string{"-process", "carol", scenario}, io.Discard); err == nil {
+		t.Error("Forventede fejl for ukendt proces")
+	}
+}
+
+// Tester delta sessioner: fuld vector først, så kun ændrede entries, og genstart efter et hul
+func TestDeltaSessionMiddleware(t *testing.T) {
+	sim := NewSimulation(8, true)
+	sessions := NewDeltaSessionMiddleware()
+	for _, p := range sim.Processes {
+		p.Middleware = []MessageMiddleware{sessions}
+	}
+	sender, receiver := sim.Processes[0], sim.Processes[1]
+
+	headers := make([]string, 0)
+	send := func(message string) Event {
+		sender.SendMessage(receiver, message)
+		wire := <-receiver.MessageQueue
+		headers = append(headers, splitMessage(wire.Message)[0])
+		return wire
+	}
+	receiver.deliver(send("a"))
+	receiver.deliver(send("b"))
+	send("tabt") // Når aldrig frem
+	receiver.deliver(send("c"))
+	receiver.deliver(send("d"))
+
+	expected := []string{"D0:0[1,0,0,0,0,0,0,0]", "D0:1{0:2}", "D0:2{0:3}", "D0:3{0:4}", "D0:0[5,0,0,0,0,0,0,0]"}
+	if strings.Join(headers, " ") != strings.Join(expected, " ") {
+		t.Errorf("Forventede headers %v, fik %v", expected, headers)
+	}
+	if len(receiver.EventLog) != 3 || !strings.Contains(receiver.EventLog[2], "synchronized to [5,3,0,0,0,0,0,0]") {
+		t.Errorf("Forventede a, b og d modtaget (c afvist efter hullet), fik %v", receiver.EventLog)
+	}
+	if sessions.Breaks.Load() != 1 {
+		t.Errorf("Forventede 1 brudt session, fik %d", sessions.Breaks.Load())
+	}
+
+	// Sessionen sparer bytes på et snakkesaligt link med mange processer
+	full, _ := measureDeltaSession(64, 50, nil, 0, 1)
+	delta, breaks := measureDeltaSession(64, 50, NewDeltaSessionMiddleware(), 0, 1)
+	if delta >= full/2 || breaks != 0 {
+		t.Errorf("Forventede under halvdelen af %.1f bytes per besked uden brud, fik %.1f og %d brud", full, delta, breaks)
+	}
+}
+
+// Tester scenarie skabeloner: parametre, overrides, indlejrede løkker og fejl i skabelonen
+func TestScenarioTemplate(t *testing.T) {
+	data, err := os.ReadFile("scenarios/ring.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := ParseScenarioTemplate(data, map[string]string{"n": "3", "rounds": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring.Processes != 3 || ring.Name != "Token ring with 3 processes" || len(ring.Steps) != 1+2*(1+3*3)+1 {
+		t.Fatalf("Forventede 3 processer og 22 steps, fik %d, %q og %d", ring.Processes, ring.Name, len(ring.Steps))
+	}
+	if want := "P2 send P0 token round 1"; !containsString(ring.Steps, want) {
+		t.Errorf("Forventede steppet %q i %v", want, ring.Steps)
+	}
+	if _, err := ring.Run("", io.Discard); err != nil {
+		t.Error(err)
+	}
+
+	json := `{"params": ["n=2", "seed=5"], "processes": ${n*2}, "seed": ${seed},
+		"steps": ["repeat ${n} i", "P${i} send P${i+n} m${i}", "end"]}`
+	f, err := ParseScenarioTemplate([]byte(json), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Processes != 4 || f.Seed != 5 || strings.Join(f.Steps, ",") != "P0 send P2 m0,P1 send P3 m1" {
+		t.Errorf("Forventede 4 processer, seed 5 og to sends, fik %d, %d og %v", f.Processes, f.Seed, f.Steps)
+	}
+
+	result, err := RunExperiment(scenarioExperiment(ring), 5, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := result.Metric("Messages"); !ok || m.Distribution.Mean != 6 {
+		t.Errorf("Forventede 6 beskeder per kørsel, fik %v", m.Distribution.Values)
+	}
+
+	for _, bad := range []struct {
+		source    string
+		overrides map[string]string
+	}{
+		{"processes: ${n}\n", nil},
+		{"params: [n=2]\nprocesses: 2\n", map[string]string{"m": "3"}},
+		{"params: [n=2]\nprocesses: 2\nsteps:\n  - repeat ${n}\n  - P0 local\n", nil},
+		{"params: [n=2]\nprocesses: 2\nsteps:\n  - end\n", nil},
+		{"params: [n=2]\nprocesses: 2\nsteps:\n  - repeat 2 n\n  - end\n", nil},
+		{"params: [n=2]\nprocesses: 2\nsteps:\n  - P0 send P${n/0}\n", nil},
+		{"params: [n=x]\nprocesses: 2\nsteps:\n  - P0 send P${n+1}\n", nil},
+		{"params: [n=2]\nprocesses: 2\nsteps:\n  - repeat 1000 a\n  - repeat 1000 b\n  - P0 local\n  - end\n  - end\n", nil},
+		{"params: [n=2]\nname: ring ${i}\nprocesses: 2\n", nil},
+	} {
+		if _, err := ParseScenarioTemplate([]byte(bad.source), bad.overrides); err == nil {
+			t.Errorf("Forventede fejl for %q", bad.source)
+		}
+	}
+}
+
+// Tester assertions i scenarie filer: clock entries, leveringsrækkefølge, og at en
+// assertion der ikke holder nævner sit step
+func TestScenarioAssertions(t *testing.T) {
+	run := "processes: 3\nseed: 1\nsteps:\n" +
+		"  - P0 send P2 first\n  - wait 20ms\n  - P1 send P2 second\n  - wait 20ms\n"
+	source := run +
+		"  - assert P2 clock[0] >= 1\n  - assert P2 clock[2] == 2\n" +
+		"  - assert P2 delivered M1 before M2\n  - assert P2 delivered P1-1\n"
+	f, err := ParseScenarioFile([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Run("", io.Discard); err != nil {
+		t.Errorf("Forventede at alle assertions holdt, fik %v", err)
+	}
+	if _, err := f.Run("Lamport", io.Discard); err == nil || !strings.Contains(err.Error(), "step 5") {
+		t.Errorf("Forventede at clock[0] fejler med Lamport clocks, fik %v", err)
+	}
+
+	for _, failing := range []struct{ assertion, step string }{
+		{"assert P2 delivered M2 before M1", "step 5"},
+		{"assert P1 clock[1] > 1", "step 5"},
+		{"assert P0 delivered M1", "step 5"},
+	} {
+		f, err := ParseScenarioFile([]byte(run + "  - " + failing.assertion + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Run("", io.Discard); err == nil || !strings.Contains(err.Error(), failing.step) {
+			t.Errorf("%s: forventede fejl i %s, fik %v", failing.assertion, failing.step, err)
+		}
+	}
+
+	// En besked fra en crashet proces bliver aldrig leveret
+	crashed, err := ParseScenarioFile([]byte("processes: 2\nsteps:\n  - P0 crash\n  - P0 send P1 lost\n  - wait 20ms\n  - assert P1 delivered M1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crashed.Run("", io.Discard); err == nil {
+		t.Error("Forventede at M1 fra den crashede P0 ikke var leveret")
+	}
+
+	for _, bad := range []string{
+		"assert P2 delivered M3",
+		"assert P9 clock[0] >= 1",
+		"assert P2 clock[7] >= 1",
+		"assert P2 clock[0] ~ 1",
+		"assert P2 clock[0] >= x",
+		"assert P2 delivered M1 after M2",
+	} {
+		f, err := ParseScenarioFile([]byte(source + "  - " + bad + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Run("", io.Discard); err == nil || !strings.Contains(err.Error(), "step 9") {
+			t.Errorf("%s: forventede fejl i step 9, fik %v", bad, err)
+		}
+	}
+}
+
+// Tester what-if på en optagelse: en forsinket levering ændrer rækkefølgen på modtageren,
+// og ændringer der ikke kan afspilles afvises
+func TestWhatIf(t *testing.T) {
+	data, err := os.ReadFile("scenarios/crash-recovery.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseScenarioFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, recording, err := f.Record("Vector", io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := recording.Replay("Vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := CausalChanges(original, original); len(changes) != 0 {
+		t.Errorf("Forventede ingen ændringer mod sig selv, fik %v", changes)
+	}
+
+	delayed, err := recording.DelayDelivery("P0-4", 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, err := delayed.Replay("Vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CausalChange{First: "replica-2 receive P0-4", Second: "replica-2 receive P1-1", Was: Before, Now: After}
+	found := false
+	for _, c := range CausalChanges(original, sim) {
+		found = found || c == want
+	}
+	if !found {
+		t.Errorf("Forventede %v blandt %v", want, CausalChanges(original, sim))
+	}
+
+	if _, err := recording.SwapDeliveries("P0-1", "P0-2"); err == nil {
+		t.Error("Forventede fejl for leveringer til forskellige processer")
+	}
+	if _, err := recording.DelayDelivery("P9-1", time.Millisecond); err == nil {
+		t.Error("Forventede fejl for en besked der aldrig leveres")
+	}
+
+	// x=2 nåede ikke frem mens replica-1 var nede; 20ms senere er den oppe igen
+	late, err := recording.DelayDelivery("P0-3", 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim, err := late.Replay("Vector"); err != nil || deliveryIndex(sim.Processes[1], "P0-3") < 0 {
+		t.Errorf("Forventede at replica-1 fik P0-3 efter recover, fik %v", err)
+	}
+	swapped, err := recording.SwapDeliveries("P0-2", "P1-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := swapped.Replay("Vector"); err == nil {
+		t.Error("Forventede fejl når P1-1 leveres før den er sendt")
+	}
+
+	var out bytes.Buffer
+	if err := WhatIf(recording, strings.NewReader("delay P0-4 20ms\nswap P0-2 P1-1\nreset\nq\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"causal relationships changed", "cannot replay the change", "Back to the recorded run"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Forventede %q i output:\n%s", line, out.String())
+		}
+	}
+}
+
+// Tester at en trace med kun fysiske tider afspilles med alle ure, at de logiske ure ordner
+// alle causale par rigtigt, og at de par den fysiske tid vender om bliver talt
+func TestPhysicalTraceReplay(t *testing.T) {
+	sim := NewSimulation(3, true)
+	clocks := NewSimulatedWallClocks(sim, []time.Duration{0, -50 * time.Millisecond, 20 * time.Millisecond})
+	p0, p1, p2 := sim.Processes[0], sim.Processes[1], sim.Processes[2]
+	p0.HandleLocalEvent("a")
+	p0.SendMessage(p1, "b")
+	clocks.Advance(10 * time.Millisecond)
+	sim.deliverPending()
+	p1.SendMessage(p2, "c")
+	p2.HandleLocalEvent("d")
+	clocks.Advance(10 * time.Millisecond)
+	sim.deliverPending()
+	p2.SendMessage(p0, "e")
+	clocks.Advance(10 * time.Millisecond)
+	sim.deliverPending()
+	anomalies, _, err := WallClockAnomalies(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var trace bytes.Buffer
+	if err := ExportPhysicalTrace(&trace, sim); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(trace.String(), "vector_clock") {
+		t.Fatal("Forventede en trace uden clock felter")
+	}
+	events, err := ReadPhysicalTrace(bytes.NewReader(trace.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := make(map[string]*Simulation)
+	for _, clockType := range clockTypes {
+		if replayed[clockType], err = ReplayPhysicalTrace(events, clockType); err != nil {
+			t.Fatalf("%s: %v", clockType, err)
+		}
+	}
+	if RunFingerprint(replayed["Vector"]) != RunFingerprint(sim) {
+		t.Error("Forventede samme vector clocks som den oprindelige kørsel")
+	}
+
+	vector := replayed["Vector"]
+	delete(replayed, "Vector")
+	for _, o := range CompareTraceOrderings(vector, replayed) {
+		switch {
+		case o.Clock == "Physical" && (o.Inverted != len(anomalies) || o.Inverted == 0):
+			t.Errorf("Forventede %d omvendte par med fysisk tid, fik %d", len(anomalies), o.Inverted)
+		case o.Clock != "Physical" && (o.Inverted != 0 || o.Tied != 0):
+			t.Errorf("%s: forventede ingen forkerte causale par, fik %+v", o.Clock, o)
+		case o.Clock == "Vector" && o.Concurrent != 0:
+			t.Errorf("Forventede at vector clocks lod concurrent par være uordnede, fik %d", o.Concurrent)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "trace.log")
+	if err := os.WriteFile(path, trace.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := retimeCommand([]string{path}, &out); err != nil || !strings.Contains(out.String(), "Largest inversions") {
+		t.Errorf("Forventede en rapport med omvendte par, fik %v:\n%s", err, out.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	for _, bad := range []string{
+		strings.Replace(lines[0], "2024-", "-", 1),
+		strings.Replace(lines[0], " local ", " dance ", 1),
+	} {
+		if _, err := ReadPhysicalTrace(strings.NewReader(bad)); err == nil {
+			t.Errorf("Forventede fejl for %s", bad)
+		}
+	}
+	receiveOnly := make([]PhysicalTraceEvent, 0)
+	for _, e := range events {
+		if e.Type != "send" {
+			receiveOnly = append(receiveOnly, e)
+		}
+	}
+	if _, err := ReplayPhysicalTrace(receiveOnly, "Lamport"); err == nil {
+		t.Error("Forventede fejl for receives uden sends")
+	}
+}
+
+// En kørsel hvor P2 kommer til undervejs, så loggede vectors har to længder: P0 sender
+// til P1 før P2 er med, og P2 (med et wall clock en time foran) sender til P0 og P1 bagefter
+func churnedSimulation() *Simulation {
+	sim := NewSimulation(2, true)
+	sim.Out = io.Discard
+	clocks := NewSimulatedWallClocks(sim, []time.Duration{0, 0})
+	p0, p1 := sim.Processes[0], sim.Processes[1]
+	p0.HandleLocalEvent("før")
+	p0.SendMessage(p1, "hej")
+	clocks.Advance(time.Millisecond)
+	sim.deliverPending()
+	p2, _ := sim.AddProcess()
+	p2.WallClock = func() time.Time { return p0.WallClock().Add(time.Hour) }
+	p2.HandleLocalEvent("efter")
+	p2.SendMessage(p0, "til P0")
+	p2.SendMessage(p1, "til P1")
+	clocks.Advance(time.Millisecond)
+	sim.deliverPending()
+	return sim
+}
+
+// Tester at analyserne sammenligner loggede vectors fra før og efter AddProcess i stedet
+// for at panic'e på forskellige længder
+func TestAnalyzersAfterChurn(t *testing.T) {
+	t.Run("CausalityMatrix", func(t *testing.T) {
+		matrix := CausalityMatrix(churnedSimulation())
+		if matrix[0][1] == 0 || matrix[2][0] == 0 || matrix[0][2] != 0 {
+			t.Errorf("Forventede P0 → P1 og P2 → P0, men ikke P0 → P2, fik %v", matrix)
+		}
+	})
+	t.Run("CountConcurrentPairs", func(t *testing.T) {
+		// P2's tre events ∥ P0's to første og P1's første, og P0's receive ∥ P1's to og P2's sidste
+		if got := CountConcurrentPairs(churnedSimulation()); got != 12 {
+			t.Errorf("Forventede 12 concurrent par, fik %d", got)
+		}
+	})
+	t.Run("DeliveryOrderViolations", func(t *testing.T) {
+		if fifo, causal := DeliveryOrderViolations(churnedSimulation()); fifo != 0 || causal != 0 {
+			t.Errorf("Forventede ingen brud, fik %d FIFO og %d causal", fifo, causal)
+		}
+	})
+	t.Run("ConcurrencyDetector", func(t *testing.T) {
+		d := NewConcurrencyDetector(100)
+		for _, event := range ConsolidatedEvents(churnedSimulation()) {
+			d.Observe(event)
+		}
+		if got := len(d.Found()); got != 12 {
+			t.Errorf("Forventede de samme 12 concurrent par som CountConcurrentPairs, fik %d", got)
+		}
+	})
+	t.Run("WallClockAnomalies", func(t *testing.T) {
+		anomalies, causal, err := WallClockAnomalies(churnedSimulation())
+		if err != nil || causal != 16 || len(anomalies) != 5 {
+			t.Errorf("Forventede 5 af 16 causale par med omvendt wall clock (P2's events før deres receives), fik %d af %d (%v)", len(anomalies), causal, err)
+		}
+	})
+	t.Run("ClockSnapshot.Compare", func(t *testing.T) {
+		before, after := VectorSnapshot([]int{1, 0}), VectorSnapshot([]int{2, 0, 1})
+		if before.Compare(after) != -1 || after.Compare(before) != 1 || before.Compare(VectorSnapshot([]int{0, 0, 1})) != 0 {
+			t.Error("Forventede at manglende entries tæller som 0")
+		}
+	})
+}
+
+// Tester at contention rapporten efter en kørsel med mange goroutines på samme ur og en
+// blokerende channel send nævner clock mutexen eller channel sendet
+func TestContentionReport(t *testing.T) {
+	disable := EnableContentionProfiling()
+	defer disable()
+
+	clock := NewLamportClock()
+	messages := make(chan int)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				messages <- clock.LocalEvent()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+	received := 0
+	for range messages {
+		received++
+		if received%1000 == 0 {
+			time.Sleep(time.Millisecond) // Modtageren er langsom, så afsenderne blokerer
+		}
+	}
+	if received != 16000 || clock.GetTime().Time() != 16000 {
+		t.Fatalf("Forventede 16000 beskeder og tid 16000, fik %d og %d", received, clock.GetTime().Time())
+	}
+
+	var buf bytes.Buffer
+	PrintContentionReport(&buf, 1000)
+	report := buf.String()
+	if strings.Contains(report, "No contention recorded") {
+		t.Fatal("Forventede contention fra kørslen")
+	}
+	clockMutex := regexp.MustCompile(`\(\*LamportClock\)\.LocalEvent +\| sync\.\(\*Mutex\)`)
+	channelSend := regexp.MustCompile(`TestContentionReport\.func\d+ +\| runtime\.chansend`)
+	if !clockMutex.MatchString(report) && !channelSend.MatchString(report) {
+		t.Errorf("Forventede at rapporten nævner clock mutexen eller channel sendet:\n%s", report)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Åbner en output sink: "file:<sti>", "tcp:<host:port>" eller "unix:<sti>". Sockets er
+// til headless kørsler hvor resultaterne samles centralt (fx med nc -lk).
+func OpenOutputSink(spec string) (io.WriteCloser, error) {
+	kind, target, _ := strings.Cut(spec, ":")
+	if target == "" {
+		return nil, fmt.Errorf("output sink %q has no target", spec)
+	}
+	switch kind {
+	case "file":
+		return os.Create(target)
+	case "tcp", "unix":
+		return net.DialTimeout(kind, target, 5*time.Second)
+	}
+	return nil, fmt.Errorf("unknown output sink %q (use stdout, file:<path>, tcp:<host:port> or unix:<path>)", spec)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockSnapshot er en uforanderlig aflæsning af et Lamport eller Vector ur.
+// Vectoren deles aldrig med uret, så kaldere kan ikke ændre urets tilstand.
+type ClockSnapshot struct {
+	time   int             // Lamport tiden (bruges kun når vector er nil)
+	vector []int           // Kopi af vector clock (nil for Lamport snapshots)
+	hybrid bool            // HLC: time er fysisk tid i ms << hlcLogicalBits | logisk tæller
+	stamps []ClockSnapshot // Multi-clock: de andre ures aflæsning af det samme event
+}
+
+// Opretter et Lamport snapshot
+func LamportSnapshot(time int) ClockSnapshot {
+	return ClockSnapshot{time: time}
+}
+
+// Opretter et Vector snapshot ud fra en kopi af v
+func VectorSnapshot(v []int) ClockSnapshot {
+	return ClockSnapshot{vector: copyVector(v)}
+}
+
+// Opretter et HLC snapshot. Tiden pakkes i én int, så HLC værdier kan sammenlignes,
+// sendes og gemmes præcis som Lamport tider.
+func HLCSnapshot(physical int64, logical int) ClockSnapshot {
+	return ClockSnapshot{time: int(physical<<hlcLogicalBits | int64(logical)), hybrid: true}
+}
+
+// Er det et HLC snapshot?
+func (s ClockSnapshot) IsHybrid() bool {
+	return s.hybrid
+}
+
+// HLC: den fysiske del i ms siden epoch
+func (s ClockSnapshot) Physical() int64 {
+	return int64(s.time) >> hlcLogicalBits
+}
+
+// HLC: den logiske tæller
+func (s ClockSnapshot) Logical() int {
+	return s.time & (1<<hlcLogicalBits - 1)
+}
+
+// Multi-clock: de andre ures aflæsning af det samme event (nil for et enkelt ur)
+func (s ClockSnapshot) Stamps() []ClockSnapshot {
+	return s.stamps
+}
+
+// Er det et vector snapshot?
+func (s ClockSnapshot) IsVector() bool {
+	return s.vector != nil
+}
+
+// Retuner Lamport tiden (0 for vector snapshots)
+func (s ClockSnapshot) Time() int {
+	return s.time
+}
+
+// Retuner en kopi af vectoren (nil for Lamport snapshots)
+func (s ClockSnapshot) Vector() []int {
+	return copyVector(s.vector)
+}
+
+// Retuner entry i uden at kopiere hele vectoren
+func (s ClockSnapshot) At(i int) int {
+	return s.vector[i]
+}
+
+// Antal entries i vectoren (0 for Lamport snapshots)
+func (s ClockSnapshot) Len() int {
+	return len(s.vector)
+}
+
+// Sammenlign to snapshots: -1 hvis s er før other, 1 hvis efter, 0 hvis lig eller concurrent.
+// En vector fra før en proces kom til (AddProcess) har 0 i de nye entries.
+func (s ClockSnapshot) Compare(other ClockSnapshot) int {
+	if s.IsVector() != other.IsVector() {
+		panic("Kan ikke sammenligne Lamport og vector snapshots!")
+	}
+
+	if s.IsVector() {
+		n := max(len(s.vector), len(other.vector))
+		return CompareVectors(padVector(s.vector, n), padVector(other.vector, n))
+	}
+
+	if s.time < other.time {
+		return -1
+	}
+	if s.time > other.time {
+		return 1
+	}
+	return 0
+}
+
+// Happened s før other? For Lamport betyder det kun at s *kan* være sket før other,
+// da C(a) < C(b) ikke medfører a → b.
+func (s ClockSnapshot) HappensBefore(other ClockSnapshot) bool {
+	return s.Compare(other) == -1
+}
+
+// Print funktion: T5 for Lamport, [1,2,3] for vector, H12:00:00.250+2 for HLC og
+// [1,2,3] / T5 for flere ure
+func (s ClockSnapshot) String() string {
+	if len(s.stamps) > 0 {
+		parts := []string{ClockSnapshot{time: s.time, vector: s.vector, hybrid: s.hybrid}.String()}
+		for _, stamp := range s.stamps {
+			parts = append(parts, stamp.String())
+		}
+		return strings.Join(parts, " / ")
+	}
+	if s.IsVector() {
+		return FormatVector(s.vector)
+	}
+	if s.hybrid {
+		physical := time.UnixMilli(s.Physical()).UTC().Format("15:04:05.000")
+		return "H" + physical + "+" + strconv.Itoa(s.Logical())
+	}
+	return "T" + strconv.Itoa(s.time)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// Indstillinger for en soak test
+type SoakConfig struct {
+	Duration    time.Duration // Hvor længe der køres (simuleret tid med Virtual, ellers rigtig tid)
+	SampleEvery time.Duration // Tid mellem målinger
+	Virtual     bool          // Kør på en Scheduler i simuleret tid i stedet for med goroutines
+	Processes   int
+	Rate        int // Events per sekund i hele systemet
+	Seed        int64
+}
+
+// Én måling under en soak test
+type SoakSample struct {
+	At         time.Duration
+	Goroutines int
+	HeapBytes  uint64 // HeapAlloc efter en GC
+	QueueDepth int    // Beskeder i processernes køer, i causal buffere og på schedulerens netværk
+}
+
+// Resultatet af en soak test
+type SoakReport struct {
+	Samples []SoakSample
+	Events  int
+	Leaks   []string // Målinger der voksede uden at flade ud
+}
+
+// Hvor meget en måling må vokse fra midten til slutningen af kørslen uden at tælle som
+// en leak (støj fra GC, runtime goroutines og beskeder der tilfældigvis er undervejs)
+var soakSlack = map[string]float64{"goroutines": 2, "heap": 1 << 20, "queue depth": 20}
+
+// Finder målinger der vokser uden grænse: efter opvarmningen (første fjerdedel) deles
+// resten i tre, og en måling vokser hvis dens mindste værdi i sidste tredjedel er over
+// dens største værdi i første tredjedel plus soakSlack. En måling der svinger omkring et
+// niveau, eller som voksede under opvarmningen og så stoppede, overlapper og er i orden.
+func findSoakLeaks(samples []SoakSample) []string {
+	leaks := make([]string, 0)
+	steady := samples[len(samples)/4:]
+	third := len(steady) / 3
+	if third == 0 {
+		return leaks
+	}
+	metrics := []struct {
+		name  string
+		value func(SoakSample) float64
+	}{
+		{"goroutines", func(s SoakSample) float64 { return float64(s.Goroutines) }},
+		{"heap", func(s SoakSample) float64 { return float64(s.HeapBytes) }},
+		{"queue depth", func(s SoakSample) float64 { return float64(s.QueueDepth) }},
+	}
+	for _, metric := range metrics {
+		earlyMax, lateMin := metric.value(steady[0]), metric.value(steady[len(steady)-1])
+		for _, s := range steady[:third] {
+			earlyMax = max(earlyMax, metric.value(s))
+		}
+		for _, s := range steady[len(steady)-third:] {
+			lateMin = min(lateMin, metric.value(s))
+		}
+		if lateMin > earlyMax+soakSlack[metric.name] {
+			leaks = append(leaks, fmt.Sprintf("%s grew from at most %.0f to at least %.0f (first %v → %v)",
+				metric.name, earlyMax, lateMin, metric.value(samples[0]), metric.value(samples[len(samples)-1])))
+		}
+	}
+	return leaks
+}
+
+// Måler goroutines, heap og kødybde
+func sampleSoak(at time.Duration, sim *Simulation, scheduled int) SoakSample {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	depth := scheduled
+	for _, p := range sim.Processes {
+		depth += len(p.MessageQueue) + p.HeldBack()
+	}
+	return SoakSample{At: at, Goroutines: runtime.NumGoroutine(), HeapBytes: mem.HeapAlloc, QueueDepth: depth}
+}
+
+// Kører en soak test: processerne (vector clocks, events gemmes ikke) laver lokale events,
+// sends og broadcasts i Rate events per sekund, og hver SampleEvery måles goroutines, heap
+// og kødybde. Virtual kører på en Scheduler med causal delivery, omrokering og duplikater;
+// ellers kører processerne i deres egne goroutines i rigtig tid. Returnerer en fejl med
+// diagnostics hvis noget vokser uden grænse.
+func RunSoak(config SoakConfig, out io.Writer) (*SoakReport, error) {
+	if config.Processes < 2 || config.Rate < 1 || config.SampleEvery <= 0 || config.Duration < 4*config.SampleEvery {
+		return nil, fmt.Errorf("soak needs at least 2 processes, a positive rate and at least 4 samples")
+	}
+	sim := NewSimulation(config.Processes, true)
+	sim.Out = io.Discard
+	sim.Seed(config.Seed)
+	for _, p := range sim.Processes {
+		p.DiscardEvents = true
+	}
+	report := &SoakReport{}
+
+	// Ét tilfældigt event fra workloaden
+	step := func(send func(from, to *Process)) {
+		p := sim.Processes[sim.Rand.Intn(len(sim.Processes))]
+		switch r := sim.Rand.Intn(10); {
+		case r < 4:
+			p.HandleLocalEvent("work")
+		case r < 9:
+			send(p, sim.Processes[(p.ID+1+sim.Rand.Intn(len(sim.Processes)-1))%len(sim.Processes)])
+		default:
+			p.Broadcast("announce")
+		}
+		report.Events++
+	}
+	interval := time.Second / time.Duration(config.Rate)
+
+	fmt.Fprintf(out, "%-10s | %-10s | %-10s | %s\n", "Time", "Goroutines", "Heap (KB)", "Queue depth")
+	fmt.Fprintln(out, "-----------|------------|------------|------------")
+	record := func(sample SoakSample) {
+		report.Samples = append(report.Samples, sample)
+		fmt.Fprintf(out, "%-10v | %-10d | %-10d | %d\n", sample.At.Round(config.SampleEvery), sample.Goroutines, sample.HeapBytes/1024, sample.QueueDepth)
+	}
+
+	if config.Virtual {
+		sim.EnableCausalDelivery()
+		s := NewScheduler(sim, config.Seed)
+		s.Faults = TransportFaults{Reorder: 0.1, ReorderDelay: 50 * time.Millisecond, Duplicate: 0.05}
+		for at := config.SampleEvery; at <= config.Duration; at += config.SampleEvery {
+			for t := at - config.SampleEvery; t < at; t += interval {
+				s.At(t, func() {
+					step(func(from, to *Process) { from.SendMessage(to, "update") })
+					s.forwardQueued()
+				})
+			}
+			s.RunUntil(at)
+			record(sampleSoak(at, sim, s.Pending()))
+		}
+	} else {
+		done := make(chan bool)
+		for _, p := range sim.Processes {
+			p.Run(done)
+		}
+		start, nextSample := time.Now(), config.SampleEvery
+		ticker := time.NewTicker(interval)
+		for elapsed := time.Duration(0); elapsed < config.Duration; elapsed = time.Since(start) {
+			<-ticker.C
+			step(func(from, to *Process) { from.SendMessage(to, "update") })
+			if elapsed >= nextSample {
+				record(sampleSoak(elapsed, sim, 0))
+				nextSample += config.SampleEvery
+			}
+		}
+		ticker.Stop()
+		close(done)
+	}
+
+	report.Leaks = findSoakLeaks(report.Samples)
+	if len(report.Leaks) == 0 {
+		return report, nil
+	}
+	var diagnostics strings.Builder
+	fmt.Fprintf(&diagnostics, "soak failed after %d events: %s", report.Events, strings.Join(report.Leaks, "; "))
+	diagnostics.WriteString("\n\ngoroutines:\n")
+	pprof.Lookup("goroutine").WriteTo(&diagnostics, 1)
+	for _, p := range sim.Processes {
+		fmt.Fprintf(&diagnostics, "%s: queue %d/%d, held back %d\n", p.Label(), len(p.MessageQueue), cap(p.MessageQueue), p.HeldBack())
+	}
+	return report, fmt.Errorf("%s", diagnostics.String())
+}
+
+// "soak [-duration d] [-sample d] [-virtual] [-processes n] [-rate n] [-seed s]"
+func soakCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("soak", flag.ContinueOnError)
+	flags.SetOutput(out)
+	config := SoakConfig{}
+	flags.DurationVar(&config.Duration, "duration", time.Hour, "how long to run (simulated time with -virtual)")
+	flags.DurationVar(&config.SampleEvery, "sample", time.Minute, "time between samples")
+	flags.BoolVar(&config.Virtual, "virtual", false, "run on the deterministic scheduler in simulated time")
+	flags.IntVar(&config.Processes, "processes", 8, "number of processes")
+	flags.IntVar(&config.Rate, "rate", 200, "events per second across all processes")
+	flags.Int64Var(&config.Seed, "seed", 1, "seed for the workload and the network")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	report, err := RunSoak(config, out)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nNo unbounded growth in %d samples over %d events\n", len(report.Samples), report.Events)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Staleness for alle processer på et givent tidspunkt
+type StalenessSample struct {
+	Elapsed   time.Duration
+	Staleness [][]int // [i][j] = Pj's egen counter minus hvad Pi ved om Pj
+}
+
+// Måler hvor forældet hver proces' viden om de andre er lige nu (kræver vector clocks).
+// Urene læses ét ad gangen mens processerne kører, så samplet er ikke et konsistent cut:
+// Pj kan læses før den sender en besked som Pi har modtaget når Pi læses. Pi ser så ud
+// til at vide mere om Pj end Pj selv, og den negative staleness rundes op til 0.
+func (sim *Simulation) SampleStaleness() [][]int {
+	n := len(sim.Processes)
+	vectors := make([]ClockSnapshot, n)
+	for i, p := range sim.Processes {
+		vectors[i] = p.Clock.Now()
+	}
+
+	staleness := make([][]int, n)
+	for i := 0; i < n; i++ {
+		staleness[i] = make([]int, n)
+		for j := 0; j < n; j++ {
+			staleness[i][j] = max(0, vectors[j].At(j)-vectors[i].At(j))
+		}
+	}
+	return staleness
+}
+
+// Gennemsnitlig staleness for hver proces over dens peers
+func MeanStaleness(staleness [][]int) []float64 {
+	means := make([]float64, len(staleness))
+	for i, row := range staleness {
+		if len(row) <= 1 {
+			continue
+		}
+		total := 0
+		for j, s := range row {
+			if i != j {
+				total += s
+			}
+		}
+		means[i] = float64(total) / float64(len(row)-1)
+	}
+	return means
+}
+
+// Sampler staleness med et fast interval i baggrunden
+type StalenessRecorder struct {
+	samples []StalenessSample
+	stop    chan bool
+	wg      sync.WaitGroup
+}
+
+// Starter en recorder der sampler simulationen hvert interval
+func (sim *Simulation) StartStalenessRecorder(interval time.Duration) *StalenessRecorder {
+	recorder := &StalenessRecorder{stop: make(chan bool)}
+	start := time.Now()
+
+	recorder.wg.Add(1)
+	go func() {
+		defer recorder.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				recorder.samples = append(recorder.samples, StalenessSample{
+					Elapsed:   time.Since(start),
+					Staleness: sim.SampleStaleness(),
+				})
+			case <-recorder.stop:
+				return
+			}
+		}
+	}()
+
+	return recorder
+}
+
+// Stopper recorderen og returnerer tidsserien
+func (r *StalenessRecorder) Stop() []StalenessSample {
+	close(r.stop)
+	r.wg.Wait()
+	return r.samples
+}
+
+// En navngiven staleness tidsserie (fx én per topologi)
+type StalenessSeries struct {
+	Name    string
+	Samples []StalenessSample
+}
+
+// Skriver tidsserierne som CSV: series,elapsed_ms,observer,peer,staleness
+func WriteStalenessCSV(w io.Writer, series []StalenessSeries) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"series", "elapsed_ms", "observer", "peer", "staleness"})
+	for _, s := range series {
+		for _, sample := range s.Samples {
+			elapsed := strconv.FormatFloat(float64(sample.Elapsed.Microseconds())/1000, 'f', 3, 64)
+			for i, row := range sample.Staleness {
+				for j, staleness := range row {
+					if i == j {
+						continue
+					}
+					writer.Write([]string{s.Name, elapsed, strconv.Itoa(i), strconv.Itoa(j), strconv.Itoa(staleness)})
+				}
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Vælger modtageren for proces i i en given runde ud fra topologien
+func topologyTarget(rng *rand.Rand, topology string, i int, round int, numProcesses int) int {
+	if numProcesses < 2 {
+		return i
+	}
+	switch topology {
+	case "ring":
+		return (i + 1) % numProcesses
+	case "star":
+		// Blade sender til P0, P0 sender round-robin til bladene
+		if i != 0 {
+			return 0
+		}
+		return 1 + round%(numProcesses-1)
+	default:
+		return rng.Intn(numProcesses)
+	}
+}
+
+// Kører samme mængde trafik i en given topologi og returnerer staleness tidsserien
+func runStalenessWorkload(out io.Writer, topology string, numProcesses int, rounds int) []StalenessSample {
+	sim := NewSimulation(numProcesses, true)
+	done := make(chan bool)
+	for _, p := range sim.Processes {
+		p.Run(done)
+	}
+
+	recorder := sim.StartStalenessRecorder(2 * time.Millisecond)
+	for r := 0; r < rounds; r++ {
+		for _, p := range sim.Processes {
+			p.HandleLocalEvent(fmt.Sprintf("Work %d", r))
+			if target := topologyTarget(sim.Rand, topology, p.ID, r, numProcesses); target != p.ID {
+				p.SendMessage(sim.Processes[target], fmt.Sprintf("Msg %d", r))
+			}
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+	if err := sim.WaitUntilIdle(); err != nil {
+		fmt.Fprintln(out, "Messages still in flight:", err)
+	}
+	samples := recorder.Stop()
+	close(done)
+
+	return samples
+}
+
+// Sammenligner informations-forsinkelsen (staleness) for forskellige topologier
+func MeasureStalenessByTopology(out io.Writer, numProcesses int, csvOut io.Writer) {
+	fmt.Fprintln(out, "\n=== VECTOR ENTRY STALENESS BY TOPOLOGY ===")
+	fmt.Fprintf(out, "Processes: %d (staleness = peer's own counter - local knowledge of it)\n\n", numProcesses)
+	fmt.Fprintf(out, "%-10s | %-10s | %-15s | %-15s\n", "Topology", "Samples", "Mean staleness", "Worst process")
+	fmt.Fprintln(out, "-----------|------------|-----------------|----------------")
+
+	series := make([]StalenessSeries, 0)
+	for _, topology := range []string{"ring", "star", "random"} {
+		samples := runStalenessWorkload(out, topology, numProcesses, 30)
+
+		perProcess := make([]float64, numProcesses)
+		for _, sample := range samples {
+			for i, mean := range MeanStaleness(sample.Staleness) {
+				perProcess[i] += mean / float64(len(samples))
+			}
+		}
+
+		overall, worst := 0.0, 0
+		for i, mean := range perProcess {
+			overall += mean / float64(numProcesses)
+			if mean > perProcess[worst] {
+				worst = i
+			}
+		}
+		fmt.Fprintf(out, "%-10s | %-10d | %-15.2f | P%d (%.2f)\n",
+			topology, len(samples), overall, worst, perProcess[worst])
+
+		series = append(series, StalenessSeries{Name: topology, Samples: samples})
+	}
+
+	if csvOut != nil {
+		if err := WriteStalenessCSV(csvOut, series); err != nil {
+			fmt.Fprintln(out, "Could not write staleness CSV:", err)
+		}
+	}
+}
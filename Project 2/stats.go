@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Tællere for en process' beskeder (opdateres atomisk fra flere goroutines)
+type MessageCounters struct {
+	sent         atomic.Int64
+	received     atomic.Int64
+	headerBytes  atomic.Int64
+	payloadBytes atomic.Int64
+	rejected     atomic.Int64
+}
+
+// Tæl en afsendt besked med clock header og payload
+func (c *MessageCounters) recordSend(headerBytes int, payloadBytes int) {
+	c.sent.Add(1)
+	c.headerBytes.Add(int64(headerBytes))
+	c.payloadBytes.Add(int64(payloadBytes))
+}
+
+// Tæl en modtaget besked
+func (c *MessageCounters) recordReceive() {
+	c.received.Add(1)
+}
+
+// Tæl en modtaget besked der blev afvist fordi clock headeren var ugyldig
+func (c *MessageCounters) recordRejected() {
+	c.rejected.Add(1)
+}
+
+// Retuner et øjebliksbillede af tællerne
+func (c *MessageCounters) Snapshot() MessageStats {
+	return MessageStats{
+		Sent:         c.sent.Load(),
+		Received:     c.received.Load(),
+		HeaderBytes:  c.headerBytes.Load(),
+		PayloadBytes: c.payloadBytes.Load(),
+		Rejected:     c.rejected.Load(),
+	}
+}
+
+// Målt besked kompleksitet
+type MessageStats struct {
+	Sent         int64 // Antal afsendte beskeder
+	Received     int64 // Antal modtagne beskeder
+	HeaderBytes  int64 // Bytes brugt på clock headers
+	PayloadBytes int64 // Bytes brugt på selve indholdet
+	Rejected     int64 // Modtagne beskeder afvist pga. en ugyldig clock header
+}
+
+// Lægger to sæt stats sammen
+func (s MessageStats) Add(other MessageStats) MessageStats {
+	return MessageStats{
+		Sent:         s.Sent + other.Sent,
+		Received:     s.Received + other.Received,
+		HeaderBytes:  s.HeaderBytes + other.HeaderBytes,
+		PayloadBytes: s.PayloadBytes + other.PayloadBytes,
+		Rejected:     s.Rejected + other.Rejected,
+	}
+}
+
+// Beskeder og bytes per high-level operation (fx per critical section eller per transaktion)
+func (s MessageStats) PerOperation(operations int) (float64, float64) {
+	if operations <= 0 {
+		return 0, 0
+	}
+	ops := float64(operations)
+	return float64(s.Sent) / ops, float64(s.HeaderBytes+s.PayloadBytes) / ops
+}
+
+// Gennemsnitlig clock header størrelse per besked
+func (s MessageStats) HeaderBytesPerMessage() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.HeaderBytes) / float64(s.Sent)
+}
+
+// Summerer tællerne for alle processer i simulationen
+func (sim *Simulation) MessageStats() MessageStats {
+	total := MessageStats{}
+	for _, p := range sim.Processes {
+		total = total.Add(p.Counters.Snapshot())
+	}
+	return total
+}
+
+// Antal broadcasts som alle de andre processer har modtaget
+func DeliveredBroadcasts(sim *Simulation) int {
+	receivers := make(map[string]int)
+	for _, edge := range MessageEdges(sim) {
+		receivers[edge.ID]++
+	}
+	delivered := 0
+	for _, n := range receivers {
+		if n == len(sim.Processes)-1 {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Print funktion: operation navngiver operationerne, fx "delivered multicast"
+func PrintMessageStats(out io.Writer, label string, stats MessageStats, operations int, operation string) {
+	msgsPerOp, bytesPerOp := stats.PerOperation(operations)
+	fmt.Fprintf(out, "%s: %d messages, %d header bytes, %d payload bytes\n",
+		label, stats.Sent, stats.HeaderBytes, stats.PayloadBytes)
+	fmt.Fprintf(out, "  per %s: %.2f messages, %.1f bytes (%d operations)\n",
+		operation, msgsPerOp, bytesPerOp, operations)
+}
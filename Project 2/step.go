@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kører s ét event ad gangen. Efter hvert event printes det nye event og alle processers
+// ure (* ved dem der ændrede sig). Enter (eller "n") tager næste event, "c" kører resten
+// uden at spørge, og "q" stopper. Schedulerede linjer uden events (fx fase overskrifter)
+// printes undervejs uden at tælle som et step.
+func StepThrough(sim *Simulation, s *Scheduler, in io.Reader, out io.Writer) {
+	input := bufio.NewScanner(in)
+	interactive := true
+	fmt.Fprintln(out, "Enter/n: next event, c: run to the end, q: quit")
+	for step := 1; ; step++ {
+		before := make([]int, len(sim.Processes))
+		clocks := make([]string, len(sim.Processes))
+		for i, p := range sim.Processes {
+			before[i], clocks[i] = len(p.EventLog), p.Clock.Now().String()
+		}
+		advanced := false
+		for !advanced {
+			if !s.Step() {
+				fmt.Fprintf(out, "\nDone after %d events at %v\n", step-1, s.Now)
+				return
+			}
+			for i, p := range sim.Processes {
+				advanced = advanced || len(p.EventLog) > before[i]
+			}
+		}
+
+		fmt.Fprintf(out, "\nStep %d at %v:\n", step, s.Now)
+		for i, p := range sim.Processes {
+			for _, log := range p.EventLog[before[i]:] {
+				fmt.Fprintln(out, "  "+log)
+			}
+		}
+		for i, p := range sim.Processes {
+			marker := ""
+			if now := p.Clock.Now().String(); now != clocks[i] {
+				marker = " *"
+			}
+			fmt.Fprintf(out, "    %-4s %s%s\n", p.Label(), p.Clock.Now(), marker)
+		}
+		fmt.Fprintf(out, "    Messages in flight: %d\n", sim.inFlight())
+
+		if !interactive {
+			continue
+		}
+		fmt.Fprint(out, "> ")
+		if !input.Scan() {
+			return
+		}
+		switch strings.TrimSpace(input.Text()) {
+		case "q":
+			return
+		case "c":
+			interactive = false
+		}
+	}
+}
+
+// "step [-clock Lamport|Vector|HLC] [-seed s]": går demo scenariet igennem ét event ad gangen
+func stepCommand(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("step", flag.ContinueOnError)
+	flags.SetOutput(out)
+	clockType := flags.String("clock", "Vector", "clock type: Lamport, Vector or HLC")
+	seed := flags.Int64("seed", 1, "seed for the scenario's network and tie-breaks")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !containsString(clockTypes, *clockType) {
+		return fmt.Errorf("unknown clock type %q (want Lamport, Vector or HLC)", *clockType)
+	}
+	sim := newSimulationOfType(3, *clockType)
+	sim.Out = out
+	sim.Seed(*seed)
+	StepThrough(sim, sim.scheduleScenario(), in, out)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Hook der skriver hvert event til w i det øjeblik det sker, med uret det blev stemplet
+// med. Er process ikke tom vises kun events fra processen med den label (fx "P1" eller
+// et navn). Events fra flere goroutines skrives hele, én linje ad gangen.
+func TailEvents(w io.Writer, process string) EventHook {
+	var mutex sync.Mutex
+	return func(event StampedEvent) {
+		if process != "" && event.Process.Label() != process {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		fmt.Fprintln(w, "  "+event.Log())
+	}
+}
+
+// Fejl hvis process hverken er tom eller en af labels
+func checkTailProcess(process string, labels []string) error {
+	if process == "" || containsString(labels, process) {
+		return nil
+	}
+	return fmt.Errorf("no process %q (have %v)", process, labels)
+}
+
+// "watch [-process label] [-lamport] [-realtime] [scenarie fil]": kører demo scenariet
+// (eller en scenarie fil) og skriver events med ure mens de sker, i stedet for loggen
+// per proces bagefter
+func watchCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(out)
+	process := flags.String("process", "", "only show events from the process with this label (e.g. P1)")
+	lamport := flags.Bool("lamport", false, "run the demo scenario with Lamport clocks instead of vector clocks")
+	realTime := flags.Bool("realtime", false, "run the demo scenario in real time, so events arrive as they would live")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: watch [-process label] [-lamport] [-realtime] [scenario file]")
+	}
+
+	if flags.NArg() == 1 {
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		f, err := ParseScenarioFile(data)
+		if err != nil {
+			return err
+		}
+		labels := make([]string, f.Processes)
+		for i := range labels {
+			labels[i] = fmt.Sprintf("P%d", i)
+			if i < len(f.Names) && f.Names[i] != "" {
+				labels[i] = f.Names[i]
+			}
+		}
+		if err := checkTailProcess(*process, labels); err != nil {
+			return err
+		}
+		f.Hooks = append(f.Hooks, TailEvents(out, *process))
+		_, err = f.Run("", out)
+		return err
+	}
+
+	sim := NewSimulation(3, !*lamport)
+	sim.Out = out
+	if *realTime {
+		sim.TimeMode = RealTime
+	}
+	labels := make([]string, len(sim.Processes))
+	for i, p := range sim.Processes {
+		labels[i] = p.Label()
+	}
+	if err := checkTailProcess(*process, labels); err != nil {
+		return err
+	}
+	sim.AddEventHook(TailEvents(out, *process))
+	sim.scheduleScenario().Run()
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hvor langt den målte eksponent må ligge fra teoriens før det flages som en afvigelse
+const complexityTolerance = 0.35
+
+// En måling fittet til teoriens model c·n^k
+type ComplexityFit struct {
+	Quantity   string  // Hvad der er målt, fx "Vector µs/event"
+	Theory     float64 // Teoriens eksponent k (0 = O(1), 1 = O(n))
+	Constant   float64 // c fittet med mindste kvadrater for teoriens k
+	FitError   float64 // RMS afvigelse fra c·n^k relativt til gennemsnittet af målingerne
+	Exponent   float64 // Den målte eksponent: hældningen af log(værdi) mod log(n)
+	ExponentR2 float64 // Hvor godt en ret linje i log-log passer (1 = perfekt)
+}
+
+// Navnet på en vækstklasse, fx O(1), O(n) eller O(n^1.7)
+func complexityName(k float64) string {
+	switch {
+	case math.Abs(k) < complexityTolerance:
+		return "O(1)"
+	case math.Abs(k-1) < complexityTolerance:
+		return "O(n)"
+	case math.Abs(k-2) < complexityTolerance:
+		return "O(n²)"
+	}
+	return fmt.Sprintf("O(n^%.1f)", k)
+}
+
+// Fitter values målt ved ns til teoriens c·n^theory, og måler den faktiske eksponent
+func FitComplexity(quantity string, theory float64, ns []int, values []float64) ComplexityFit {
+	fit := ComplexityFit{Quantity: quantity, Theory: theory}
+
+	// c = Σ y·f / Σ f² med f = n^k
+	var yf, ff, mean float64
+	for i, n := range ns {
+		f := math.Pow(float64(n), theory)
+		yf += values[i] * f
+		ff += f * f
+		mean += values[i] / float64(len(values))
+	}
+	fit.Constant = yf / ff
+	var residuals float64
+	for i, n := range ns {
+		r := values[i] - fit.Constant*math.Pow(float64(n), theory)
+		residuals += r * r
+	}
+	if mean > 0 {
+		fit.FitError = math.Sqrt(residuals/float64(len(values))) / mean
+	}
+
+	// Lineær regression af log(y) mod log(n)
+	var sx, sy, sxx, sxy, syy float64
+	points := 0
+	for i, n := range ns {
+		if values[i] <= 0 {
+			continue
+		}
+		x, y := math.Log(float64(n)), math.Log(values[i])
+		sx, sy, sxx, sxy, syy = sx+x, sy+y, sxx+x*x, sxy+x*y, syy+y*y
+		points++
+	}
+	if points < 2 {
+		return fit
+	}
+	p := float64(points)
+	fit.Exponent = (p*sxy - sx*sy) / (p*sxx - sx*sx)
+	if varY := p*syy - sy*sy; varY > 0 {
+		r := (p*sxy - sx*sy) / math.Sqrt((p*sxx-sx*sx)*varY)
+		fit.ExponentR2 = r * r
+	} else {
+		fit.ExponentR2 = 1 // Helt konstante målinger passer perfekt på en vandret linje
+	}
+	return fit
+}
+
+// Matcher målingen teorien, eller vokser den hurtigere eller langsommere?
+func (f ComplexityFit) Verdict() string {
+	switch {
+	case f.Exponent > f.Theory+complexityTolerance:
+		return fmt.Sprintf("faster than %s: super-linear effects such as contention or cache misses", complexityName(f.Theory))
+	case f.Exponent < f.Theory-complexityTolerance:
+		return fmt.Sprintf("slower than %s: fixed costs dominate at these n", complexityName(f.Theory))
+	}
+	return "matches " + complexityName(f.Theory)
+}
+
+// Fitter scalability målingerne (gennemsnitlig tid og besked stats per clock type og
+// antal processer) til teorien: tid per event og header bytes per besked er O(1) for
+// Lamport og HLC og O(n) for vector clocks
+func scalingFits(processCounts []int, eventsPerProcess int, times map[string][]time.Duration, stats map[string][]MessageStats) []ComplexityFit {
+	fits := make([]ComplexityFit, 0)
+	for _, clockType := range clockTypes {
+		theory := 0.0
+		if clockType == "Vector" {
+			theory = 1
+		}
+		perEvent, header := make([]float64, len(processCounts)), make([]float64, len(processCounts))
+		for i, n := range processCounts {
+			perEvent[i] = float64(times[clockType][i].Nanoseconds()) / 1000 / float64(n*eventsPerProcess)
+			header[i] = stats[clockType][i].HeaderBytesPerMessage()
+		}
+		fits = append(fits,
+			FitComplexity(clockType+" µs/event", theory, processCounts, perEvent),
+			FitComplexity(clockType+" header B/msg", theory, processCounts, header))
+	}
+	return fits
+}
+
+// Printer teori mod måling for hver fit
+func PrintTheoryReport(w io.Writer, fits []ComplexityFit) {
+	fmt.Fprintf(w, "%-20s | %-6s | %-11s | %-9s | %-9s | %-6s | %s\n",
+		"Quantity", "Theory", "Constant c", "Fit error", "Measured", "R²", "Verdict")
+	fmt.Fprintln(w, "---------------------|--------|-------------|-----------|-----------|--------|--------------------------")
+	for _, f := range fits {
+		fmt.Fprintf(w, "%-20s | %-6s | %-11.4f | %-8.1f%% | %-9s | %-6.2f | %s\n",
+			f.Quantity, complexityName(f.Theory), f.Constant, f.FitError*100,
+			fmt.Sprintf("n^%.2f", f.Exponent), f.ExponentR2, f.Verdict())
+	}
+}
+
+// "report [-processes 2,4,8,16,32] [-events n] [-iterations n]": kører scalability
+// cellerne og printer teori mod måling
+func reportCommand(args []string, out io.Writer, opts BenchmarkOptions) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(out)
+	counts := flags.String("processes", "2,4,8,16,32", "comma-separated process counts")
+	eventsPerProcess := flags.Int("events", 20, "events per process in each run")
+	iterations := flags.Int("iterations", 30, "runs per cell (the mean is fitted)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	processCounts := make([]int, 0)
+	for _, field := range strings.Split(*counts, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 2 {
+			return fmt.Errorf("invalid process count %q", field)
+		}
+		processCounts = append(processCounts, n)
+	}
+	if len(processCounts) < 2 {
+		return fmt.Errorf("need at least two process counts to fit a model")
+	}
+
+	times, stats := make(map[string][]time.Duration), make(map[string][]MessageStats)
+	for _, n := range processCounts {
+		for _, clockType := range clockTypes {
+			t, _, s := runScalabilityCell(n, *eventsPerProcess, *iterations, clockType, opts)
+			times[clockType] = append(times[clockType], t)
+			stats[clockType] = append(stats[clockType], s)
+		}
+	}
+	fmt.Fprintf(out, "Processes %v, %d events per process, %d iterations per cell\n\n", processCounts, *eventsPerProcess, *iterations)
+	PrintTheoryReport(out, scalingFits(processCounts, *eventsPerProcess, times, stats))
+	return nil
+}
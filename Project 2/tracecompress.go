@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Laver clock feltet for hver linje i en trace fil. Med compress skrives en vector kun
+// som de entries der har ændret sig siden processens forrige linje i filen, fx
+// vector_delta={2:4} i stedet for vector_clock=[3,0,4,1,0,0,0,0].
+type clockEncoder struct {
+	compress bool
+	previous map[string][]int // Sidste vector skrevet for hver proces label
+}
+
+// Opretter en encoder (én per fil, da deltaer er i forhold til filens forrige linje)
+func newClockEncoder(compress bool) *clockEncoder {
+	return &clockEncoder{compress: compress, previous: make(map[string][]int)}
+}
+
+// Navn og værdi for clock feltet: lamport_time=5, hlc_time=<pakket HLC>, vector_clock=[1,2,0]
+// eller vector_delta={1:2}
+func (c *clockEncoder) field(e LoggedEvent) (string, string) {
+	if e.Clock.IsHybrid() {
+		return "hlc_time", fmt.Sprintf("%d", e.Clock.Time())
+	}
+	if !e.Clock.IsVector() {
+		return "lamport_time", fmt.Sprintf("%d", e.Clock.Time())
+	}
+	if !c.compress {
+		return "vector_clock", e.Clock.String()
+	}
+
+	vector := e.Clock.Vector()
+	previous, ok := c.previous[e.Label]
+	c.previous[e.Label] = vector
+	if !ok || len(previous) != len(vector) {
+		return "vector_clock", e.Clock.String()
+	}
+	return "vector_delta", EncodeVectorDelta(previous, vector)
+}
+
+// Zero-suppressed delta: kun de entries der er forskellige fra previous, som {index:værdi}
+func EncodeVectorDelta(previous []int, vector []int) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, v := range vector {
+		if v == previous[i] {
+			continue
+		}
+		if sb.Len() > 1 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.Itoa(i))
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(v))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// Genskaber vectoren ud fra previous og en delta fra EncodeVectorDelta
+func DecodeVectorDelta(previous []int, delta string) ([]int, error) {
+	if len(delta) < 2 || delta[0] != '{' || delta[len(delta)-1] != '}' {
+		return nil, fmt.Errorf("malformed vector delta %q", delta)
+	}
+	vector := copyVector(previous)
+	body := delta[1 : len(delta)-1]
+	if body == "" {
+		return vector, nil
+	}
+	for _, entry := range strings.Split(body, ",") {
+		index, value, found := strings.Cut(entry, ":")
+		i, err := strconv.Atoi(index)
+		if !found || err != nil || i < 0 || i >= len(vector) {
+			return nil, fmt.Errorf("malformed vector delta entry %q", entry)
+		}
+		if vector[i], err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("malformed vector delta entry %q", entry)
+		}
+	}
+	return vector, nil
+}
+
+// Et event læst fra en trace fil
+type TraceEvent struct {
+	Label string
+	Index int
+	Clock ClockSnapshot
+}
+
+var (
+	syslogParamPattern = regexp.MustCompile(`(\w+)="((?:[^"\\]|\\.)*)"`)
+	cefKeyPattern      = regexp.MustCompile(`(?:^| )(\w+)=`)
+)
+
+// Læser clock værdierne fra en syslog eller CEF trace fil skrevet af ExportLogsToFile.
+// Komprimerede vectors pakkes ud automatisk.
+func ReadTraceClocks(r io.Reader) ([]TraceEvent, error) {
+	events := make([]TraceEvent, 0)
+	previous := make(map[string][]int)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var fields map[string]string
+		var label, index string
+		switch text := scanner.Text(); {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "<"):
+			fields = syslogFields(text)
+			label, index = fields["process"], fields["event"]
+		case strings.HasPrefix(text, "CEF:"):
+			fields = cefFields(text)
+			label, index = fields["dvchost"], fields["cnt"]
+			if name := fields["cs1Label"]; name != "" {
+				fields[name] = fields["cs1"]
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown trace format", line)
+		}
+
+		event := TraceEvent{Label: label}
+		var err error
+		if event.Index, err = strconv.Atoi(index); err != nil {
+			return nil, fmt.Errorf("line %d: missing event index", line)
+		}
+
+		if value, ok := fields["vector_clock"]; ok {
+			clock, err := parseClockHeaderChecked(value)
+			if err != nil || !clock.IsVector() || len(clock.stamps) > 0 {
+				return nil, fmt.Errorf("line %d: malformed vector clock %q", line, value)
+			}
+			previous[label] = clock.vector
+			event.Clock = clock
+		} else if value, ok := fields["vector_delta"]; ok {
+			base, known := previous[label]
+			if !known {
+				return nil, fmt.Errorf("line %d: vector delta for %s without a full vector before it", line, label)
+			}
+			vector, err := DecodeVectorDelta(base, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			previous[label] = vector
+			event.Clock = VectorSnapshot(vector)
+		} else if value, ok := fields["hlc_time"]; ok {
+			packed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: malformed hlc time %q", line, value)
+			}
+			event.Clock = ClockSnapshot{time: int(packed), hybrid: true}
+		} else if value, ok := fields["lamport_time"]; ok {
+			time, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: malformed lamport time %q", line, value)
+			}
+			event.Clock = LamportSnapshot(time)
+		} else {
+			return nil, fmt.Errorf("line %d: no clock field", line)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// PARAM-NAME="PARAM-VALUE" par fra syslog linjens structured data
+func syslogFields(line string) map[string]string {
+	fields := make(map[string]string)
+	start := strings.Index(line, "[dissy@")
+	if start < 0 {
+		return fields
+	}
+	data := line[start:]
+	for i := 1; i < len(data); i++ {
+		if data[i] == '\\' {
+			i++
+		} else if data[i] == ']' {
+			data = data[:i]
+			break
+		}
+	}
+	for _, match := range syslogParamPattern.FindAllStringSubmatch(data, -1) {
+		fields[match[1]] = unescapeTraceValue(match[2])
+	}
+	return fields
+}
+
+// Nøgle=værdi par fra CEF extension. Et '=' i en værdi er escaped, så hvert
+// " nøgle=" starter et nyt felt.
+func cefFields(line string) map[string]string {
+	fields := make(map[string]string)
+	start := strings.Index(line, "|dvchost=")
+	if start < 0 {
+		return fields
+	}
+	extension := line[start+1:]
+	keys := cefKeyPattern.FindAllStringSubmatchIndex(extension, -1)
+	for k, key := range keys {
+		end := len(extension)
+		if k+1 < len(keys) {
+			end = keys[k+1][0]
+		}
+		fields[extension[key[2]:key[3]]] = unescapeTraceValue(extension[key[1]:end])
+	}
+	return fields
+}
+
+// Fjerner backslash escapes fra syslog og CEF værdier
+func unescapeTraceValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			if value[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+// Tæller bytes skrevet til den
+type byteCounter int
+
+func (c *byteCounter) Write(b []byte) (int, error) {
+	*c += byteCounter(len(b))
+	return len(b), nil
+}
+
+// Måler hvor meget mindre trace filerne bliver med komprimerede vectors
+func MeasureTraceCompression(out io.Writer) {
+	fmt.Fprintln(out, "\n=== TRACE SIZE WITH COMPRESSED VECTORS ===")
+	fmt.Fprintf(out, "%-10s | %-8s | %-12s | %-12s | %-8s\n", "Processes", "Format", "Plain", "Compressed", "Saved")
+	fmt.Fprintln(out, "-----------|----------|--------------|--------------|---------")
+
+	for _, numProcesses := range []int{4, 16, 64} {
+		sim := NewSimulation(numProcesses, true)
+		sim.Seed(int64(numProcesses))
+		for round := 0; round < 10; round++ {
+			for _, p := range sim.Processes {
+				if target := sim.Rand.Intn(numProcesses); target != p.ID {
+					p.SendMessage(sim.Processes[target], fmt.Sprintf("Msg %d", round))
+				} else {
+					p.HandleLocalEvent(fmt.Sprintf("Event %d", round))
+				}
+			}
+			sim.deliverPending()
+		}
+
+		for _, format := range []string{"syslog", "cef"} {
+			var plain, compressed byteCounter
+			exportLogs(&plain, format, false, sim)
+			exportLogs(&compressed, format, true, sim)
+			saved := 100 * (1 - float64(compressed)/float64(plain))
+			fmt.Fprintf(out, "%-10d | %-8s | %-12d | %-12d | %6.1f%%\n",
+				numProcesses, format, plain, compressed, saved)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• A full vector costs O(n) per line, but each event only changes a few entries")
+	fmt.Fprintln(out, "• The savings grow with n, since most entries are unchanged between a process' events")
+	fmt.Fprintln(out, "• Use -export-compress to write compressed traces; ReadTraceClocks decompresses them")
+}
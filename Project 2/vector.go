@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"strings"
+	"strconv"
 	"sync"
 )
 
@@ -45,15 +45,72 @@ func (vc *VectorClock) ReceiveEvent(receivedVector []int) []int {
 	vc.mutex.Lock()
 	defer vc.mutex.Unlock()
 
-	// Merge: tag maximum af hver position
+	vc.merge(receivedVector)
+	vc.vector[vc.processID]++
+	return vc.getCopy()
+}
+
+// Som ReceiveEvent, men afviser en vector med forkert længde i stedet for at panic'e.
+// Uret er uændret hvis der returneres en fejl.
+func (vc *VectorClock) ReceiveEventChecked(receivedVector []int) ([]int, error) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if err := checkVectorLengths(vc.vector, receivedVector); err != nil {
+		return nil, err
+	}
+	vc.merge(receivedVector)
+	vc.vector[vc.processID]++
+	return vc.getCopy(), nil
+}
+
+// Merge: tag maximum af hver position (kræver at mutex er låst og at other har samme længde)
+func (vc *VectorClock) merge(other []int) {
 	for i := 0; i < len(vc.vector); i++ {
-		if receivedVector[i] > vc.vector[i] {
-			vc.vector[i] = receivedVector[i]
+		if other[i] > vc.vector[i] {
+			vc.vector[i] = other[i]
 		}
 	}
+}
 
-	vc.vector[vc.processID]++
-	return vc.getCopy()
+// Merger et andet ur ind i dette uden at tælle et event. Afviser et ur med en anden
+// længde i stedet for at panic'e; uret er uændret hvis der returneres en fejl.
+func (vc *VectorClock) Merge(other *VectorClock) error {
+	// Tag snapshot først så vi aldrig holder begge låse (og så Merge(vc) ikke deadlocker)
+	otherVector := other.GetVector()
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	if err := checkVectorLengths(vc.vector, otherVector.vector); err != nil {
+		return err
+	}
+	vc.merge(otherVector.vector)
+	return nil
+}
+
+// Returnerer en uafhængig kopi af uret med samme proces ID
+func (vc *VectorClock) Clone() *VectorClock {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	return &VectorClock{
+		vector:    vc.getCopy(),
+		processID: vc.processID,
+	}
+}
+
+// Er de to ure ens entry for entry?
+func (vc *VectorClock) Equals(other *VectorClock) bool {
+	v1 := vc.GetVector()
+	v2 := other.GetVector()
+	if v1.Len() != v2.Len() {
+		return false
+	}
+	for i := 0; i < v1.Len(); i++ {
+		if v1.At(i) != v2.At(i) {
+			return false
+		}
+	}
+	return true
 }
 
 // Returnerer en kopi
@@ -65,15 +122,40 @@ func (vc *VectorClock) getCopy() []int {
 	return copy
 }
 
-// Retuner aktuel vector
-func (vc *VectorClock) GetVector() []int {
+// Retuner et snapshot af aktuel vector
+func (vc *VectorClock) GetVector() ClockSnapshot {
 	vc.mutex.Lock()
 	defer vc.mutex.Unlock()
-	return vc.getCopy()
+	return ClockSnapshot{vector: vc.getCopy()}
 }
 
-// Sammenlign vectors og find relation
-func CompareVectors(v1, v2 []int) int {
+// Relationen mellem to vector clocks
+type Relation int
+
+const (
+	Concurrent Relation = iota // Ingen af dem happened before den anden
+	Before                     // v1 happened before v2
+	After                      // v2 happened before v1
+	Equal                      // Samme vector (samme event, eller events uden ny causal historie)
+)
+
+// Print funktion
+func (r Relation) String() string {
+	switch r {
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Equal:
+		return "equal"
+	}
+	return "concurrent"
+}
+
+// Sammenlign vectors og find relationen. Til forskel fra CompareVectors skelnes lige
+// vectors fra concurrent, så kalderen ikke selv skal scanne dem igen. Panic'er hvis
+// længderne er forskellige; brug CompareChecked for data udefra.
+func Compare(v1, v2 []int) Relation {
 	if len(v1) != len(v2) {
 		panic("Vector clocks skal have samme længde!")
 	}
@@ -90,21 +172,44 @@ func CompareVectors(v1, v2 []int) int {
 		}
 	}
 
-	// Hvis v1 <= v2 og mindst ét element er mindre
-	if lessOrEqual && !greaterOrEqual {
-		return -1 // v1 happened before v2
+	switch {
+	case lessOrEqual && greaterOrEqual:
+		return Equal
+	case lessOrEqual:
+		return Before
+	case greaterOrEqual:
+		return After
 	}
+	return Concurrent
+}
 
-	// Hvis v1 >= v2 og mindst ét element er større
-	if greaterOrEqual && !lessOrEqual {
-		return 1 // v2 happened before v1
+// Sammenlign vectors som Compare, men returner en fejl i stedet for at panic'e når
+// længderne ikke passer
+func CompareChecked(v1, v2 []int) (Relation, error) {
+	if err := checkVectorLengths(v1, v2); err != nil {
+		return Concurrent, err
 	}
+	return Compare(v1, v2), nil
+}
 
-	// Hvis v1 == v2
-	if lessOrEqual && greaterOrEqual {
-		return 0 // De er identiske (samme event eller concurrent)
+// Fejl hvis to vectors ikke kan sammenlignes eller merges
+func checkVectorLengths(v1, v2 []int) error {
+	if len(v1) != len(v2) {
+		return fmt.Errorf("vector clocks have different lengths: %s has %d entries, %s has %d",
+			FormatVector(v1), len(v1), FormatVector(v2), len(v2))
 	}
+	return nil
+}
 
+// Sammenlign vectors og find relation: -1 hvis v1 happened before v2, 1 hvis v2
+// happened before v1, og 0 hvis de er ens eller concurrent. Brug Compare for at skelne.
+func CompareVectors(v1, v2 []int) int {
+	switch Compare(v1, v2) {
+	case Before:
+		return -1
+	case After:
+		return 1
+	}
 	return 0
 }
 
@@ -117,24 +222,30 @@ type VectorMessage struct {
 
 // Print funktion
 func (msg VectorMessage) String() string {
-	// Konverter vector til string format: [1,2,3]
-	vectorStr := "["
-	for i, v := range msg.Timestamp {
+	buf := make([]byte, 0, 32)
+	buf = append(buf, 'P')
+	buf = strconv.AppendInt(buf, int64(msg.ProcessID), 10)
+	buf = append(buf, '@')
+	buf = AppendFormat(buf, msg.Timestamp)
+	buf = append(buf, ": "...)
+	buf = append(buf, msg.Content...)
+	return string(buf)
+}
+
+// Skriver vectoren som [1,2,3] i slutningen af dst uden ekstra allokeringer
+func AppendFormat(dst []byte, v []int) []byte {
+	dst = append(dst, '[')
+	for i, val := range v {
 		if i > 0 {
-			vectorStr += ","
+			dst = append(dst, ',')
 		}
-		vectorStr += fmt.Sprintf("%d", v)
+		dst = strconv.AppendInt(dst, int64(val), 10)
 	}
-	vectorStr += "]"
-
-	return fmt.Sprintf("P%d@%s: %s", msg.ProcessID, vectorStr, msg.Content)
+	return append(dst, ']')
 }
 
 // Print funktion 
 func FormatVector(v []int) string {
-	parts := make([]string, len(v))
-	for i, val := range v {
-		parts[i] = fmt.Sprintf("%d", val)
-	}
-	return "[" + strings.Join(parts, ",") + "]"
+	// Ca. 4 bytes per entry for små tal
+	return string(AppendFormat(make([]byte, 0, 2+4*len(v)), v))
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// En egenskab en demo kørsel er tjekket for: safety (der sker aldrig noget dårligt) eller
+// liveness (der sker til sidst noget godt). Detail er modeksemplet når den ikke holdt.
+type Verdict struct {
+	Kind     string // "safety" eller "liveness"
+	Property string
+	Held     bool
+	Detail   string
+}
+
+// Safety verdict: holdt hvis violation er tom, ellers er violation modeksemplet
+func safetyVerdict(property string, violation string) Verdict {
+	return Verdict{Kind: "safety", Property: property, Held: violation == "", Detail: violation}
+}
+
+// Liveness verdict: holdt hvis missing er tom, ellers er missing det der aldrig skete
+func livenessVerdict(property string, missing string) Verdict {
+	return Verdict{Kind: "liveness", Property: property, Held: missing == "", Detail: missing}
+}
+
+// Retuner "held"/"violated" for safety og "yes"/"no" for liveness
+func (v Verdict) Result() string {
+	switch {
+	case v.Kind == "safety" && v.Held:
+		return "held"
+	case v.Kind == "safety":
+		return "violated"
+	case v.Held:
+		return "yes"
+	}
+	return "no"
+}
+
+// Print funktion
+func PrintVerdicts(w io.Writer, verdicts []Verdict) {
+	fmt.Fprintf(w, "\n%-8s | %-47s | %s\n", "Kind", "Property", "Verdict")
+	fmt.Fprintln(w, "---------|-------------------------------------------------|--------")
+	for _, v := range verdicts {
+		result := v.Result()
+		if v.Detail != "" {
+			result += " (" + v.Detail + ")"
+		}
+		fmt.Fprintf(w, "%-8s | %-47s | %s\n", v.Kind, v.Property, result)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version vector hvor actors identificeres med en streng (UUID, hostname, ...) i stedet
+// for et index 0..n-1. Manglende actors tæller som 0, så vectors fra forskellige
+// medlemsskaber kan sammenlignes og merges uden at blive lige lange først.
+type VersionVector map[string]uint64
+
+// Opretter en tom version vector
+func NewVersionVector() VersionVector {
+	return make(VersionVector)
+}
+
+// Laver en version vector ud fra en index-baseret vector og actor navnene for hvert index
+func VersionVectorFromSlice(v []int, actors []string) VersionVector {
+	vv := NewVersionVector()
+	for i, entry := range v {
+		if entry > 0 {
+			vv[actors[i]] = uint64(entry)
+		}
+	}
+	return vv
+}
+
+// Tæller et event for actor og returnerer den nye værdi
+func (vv VersionVector) Increment(actor string) uint64 {
+	vv[actor]++
+	return vv[actor]
+}
+
+// Merger other ind i vv (entry-vis maximum)
+func (vv VersionVector) Merge(other VersionVector) {
+	for actor, counter := range other {
+		if counter > vv[actor] {
+			vv[actor] = counter
+		}
+	}
+}
+
+// Returnerer en uafhængig kopi
+func (vv VersionVector) Copy() VersionVector {
+	copy := make(VersionVector, len(vv))
+	for actor, counter := range vv {
+		copy[actor] = counter
+	}
+	return copy
+}
+
+// Sammenlign som CompareVectors: -1 hvis vv er før other, 1 hvis efter, 0 hvis lig eller concurrent
+func (vv VersionVector) Compare(other VersionVector) int {
+	less, greater := vv.relation(other)
+	if less && !greater {
+		return -1
+	}
+	if greater && !less {
+		return 1
+	}
+	return 0
+}
+
+// Er de to vectors ens? (en entry på 0 er det samme som en manglende entry)
+func (vv VersionVector) Equal(other VersionVector) bool {
+	less, greater := vv.relation(other)
+	return !less && !greater
+}
+
+// Har vv en entry der er mindre end others, og en der er større?
+func (vv VersionVector) relation(other VersionVector) (bool, bool) {
+	less, greater := false, false
+	for actor, counter := range vv {
+		if counter > other[actor] {
+			greater = true
+		} else if counter < other[actor] {
+			less = true
+		}
+	}
+	for actor, counter := range other {
+		if _, ok := vv[actor]; !ok && counter > 0 {
+			less = true
+		}
+	}
+	return less, greater
+}
+
+// Actors sorteret, så String altid giver samme tekst
+func (vv VersionVector) actors() []string {
+	actors := make([]string, 0, len(vv))
+	for actor := range vv {
+		actors = append(actors, actor)
+	}
+	sort.Strings(actors)
+	return actors
+}
+
+// Print funktion: {db-1:3,frontend:1}, sorteret efter actor
+func (vv VersionVector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, actor := range vv.actors() {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(actor)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.FormatUint(vv[actor], 10))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// Parser en version vector skrevet med String. Actor ID'er må ikke indeholde ',' (men gerne
+// ':', fx host:port).
+func ParseVersionVector(s string) (VersionVector, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("malformed version vector %q", s)
+	}
+	vv := NewVersionVector()
+	body := s[1 : len(s)-1]
+	if body == "" {
+		return vv, nil
+	}
+	for _, entry := range strings.Split(body, ",") {
+		colon := strings.LastIndexByte(entry, ':')
+		if colon <= 0 {
+			return nil, fmt.Errorf("malformed version vector entry %q", entry)
+		}
+		counter, err := strconv.ParseUint(entry[colon+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed version vector entry %q", entry)
+		}
+		vv[entry[:colon]] = counter
+	}
+	return vv, nil
+}
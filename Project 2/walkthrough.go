@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Et navngivet event i en walkthrough (processen og index i dens EventLog)
+type walkthroughEvent struct {
+	process int
+	index   int
+}
+
+// Et trin i en walkthrough. Hvis a og b er sat, er trinnet et checkpoint hvor brugeren
+// skal gætte relationen mellem de to events før svaret vises.
+type walkthroughStep struct {
+	title     string
+	narration string
+	act       func(wt *Walkthrough)
+	a, b      string
+}
+
+// En fortalt gennemgang af et fejlscenarie der kan afbrydes og genoptages. Fremskridtet
+// gemmes i en fil efter hvert checkpoint; ved genoptagelse afspilles de tidligere trin
+// igen i stilhed, da simulationen er deterministisk.
+type Walkthrough struct {
+	sim       *Simulation
+	events    map[string]walkthroughEvent
+	order     []string // Eventnavne i den rækkefølge de skete (til diagrammet)
+	statePath string
+	in        *bufio.Scanner
+	out       io.Writer
+	correct   int
+	asked     int
+}
+
+// Navngiver processens seneste event
+func (wt *Walkthrough) name(p *Process, name string) {
+	wt.events[name] = walkthroughEvent{process: p.ID, index: len(p.EventLog) - 1}
+	wt.order = append(wt.order, name)
+}
+
+// Lokal skrivning på processen
+func (wt *Walkthrough) write(id int, name string, message string) {
+	p := wt.sim.Processes[id]
+	p.HandleLocalEvent(message)
+	wt.name(p, name)
+}
+
+// Sender en besked og leverer den med det samme
+func (wt *Walkthrough) message(from int, to int, sendName string, receiveName string, message string) {
+	sender, receiver := wt.sim.Processes[from], wt.sim.Processes[to]
+	sender.SendMessage(receiver, message)
+	wt.name(sender, sendName)
+	wt.sim.deliverPending()
+	wt.name(receiver, receiveName)
+}
+
+// Clock værdien for et navngivet event
+func (wt *Walkthrough) clock(name string) ClockSnapshot {
+	e := wt.events[name]
+	return wt.sim.Processes[e.process].clockAt(e.index)
+}
+
+// Relationen mellem to navngivne events: before, after eller concurrent
+func (wt *Walkthrough) relation(a string, b string) string {
+	switch wt.clock(a).Compare(wt.clock(b)) {
+	case -1:
+		return "before"
+	case 1:
+		return "after"
+	}
+	return "concurrent"
+}
+
+// Tegner en tidslinje per proces med de navngivne events og deres vector clocks
+func (wt *Walkthrough) diagram() {
+	for _, p := range wt.sim.Processes {
+		lane := make([]string, 0)
+		for _, name := range wt.order {
+			if e := wt.events[name]; e.process == p.ID {
+				lane = append(lane, fmt.Sprintf("%s%s", name, p.clockAt(e.index)))
+			}
+		}
+		fmt.Fprintf(wt.out, "  %s %s──▶\n", p.Label(), prefixJoin(lane, "── "))
+	}
+}
+
+// Sætter sep foran hvert element
+func prefixJoin(parts []string, sep string) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(sep)
+		sb.WriteString(part)
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// Partition → concurrent writes → heal → merge med tre replicas
+func partitionWalkthroughSteps() []walkthroughStep {
+	return []walkthroughStep{
+		{
+			title:     "Setup",
+			narration: "Three replicas start in sync. P0 writes x=1 (a) and replicates it to P1 (b → c) and P2 (d → e).",
+			act: func(wt *Walkthrough) {
+				wt.write(0, "a", "x=1")
+				wt.message(0, 1, "b", "c", "x=1")
+				wt.message(0, 2, "d", "e", "x=1")
+			},
+			a: "a", b: "e",
+		},
+		{
+			title: "Partition",
+			narration: "The network splits into {P0, P1} and {P2}. P0 writes x=2 (f) and replicates it to P1 (g → h).\n" +
+				"P2 cannot reach anyone and writes x=3 (i) locally.",
+			act: func(wt *Walkthrough) {
+				wt.write(0, "f", "x=2")
+				wt.message(0, 1, "g", "h", "x=2")
+				wt.write(2, "i", "x=3")
+			},
+			a: "f", b: "i",
+		},
+		{
+			title:     "Partition (continued)",
+			narration: "P1 has now seen x=2 through the partition's majority side.",
+			a:         "h", b: "i",
+		},
+		{
+			title:     "Heal",
+			narration: "The partition heals. P2 sends its write to P0 (j → k), and P0 sends its state to P2 (l → m).",
+			act: func(wt *Walkthrough) {
+				wt.message(2, 0, "j", "k", "x=3")
+				wt.message(0, 2, "l", "m", "x=2")
+			},
+			a: "i", b: "k",
+		},
+		{
+			title: "Merge",
+			narration: "P0 holds x=2 and x=3 from concurrent writes, so neither may overwrite the other. It writes the\n" +
+				"merged value x={2,3} (n) and replicates it to P1 (o → p) and P2 (q → r).",
+			act: func(wt *Walkthrough) {
+				wt.write(0, "n", "x={2,3}")
+				wt.message(0, 1, "o", "p", "x={2,3}")
+				wt.message(0, 2, "q", "r", "x={2,3}")
+			},
+			a: "n", b: "i",
+		},
+		{
+			title:     "Converged",
+			narration: "All replicas now hold x={2,3}. One last check: how does P1's receive of the merge relate to P2's partitioned write?",
+			a:         "p", b: "i",
+		},
+	}
+}
+
+// Læser gemt fremskridt: trin, rigtige svar og antal spørgsmål
+func loadWalkthroughState(path string) (int, int, int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var step, correct, asked int
+	if _, err := fmt.Sscanf(string(data), "%d %d %d", &step, &correct, &asked); err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed walkthrough state in %s", path)
+	}
+	return step, correct, asked, nil
+}
+
+// Gemmer fremskridtet så walkthroughen kan genoptages fra trin step
+func (wt *Walkthrough) save(step int) error {
+	return os.WriteFile(wt.statePath, []byte(fmt.Sprintf("%d %d %d\n", step, wt.correct, wt.asked)), 0o644)
+}
+
+// Spørger brugeren om relationen mellem a og b. Returnerer false hvis brugeren vil stoppe.
+func (wt *Walkthrough) ask(a string, b string) bool {
+	answers := map[string]string{"b": "before", "a": "after", "c": "concurrent"}
+	for {
+		fmt.Fprintf(wt.out, "\n  Predict: did %s happen before, after, or concurrently with %s? [b/a/c, q to save and quit] ", a, b)
+		if !wt.in.Scan() {
+			return false
+		}
+		input := strings.ToLower(strings.TrimSpace(wt.in.Text()))
+		if input == "q" {
+			return false
+		}
+		guess, ok := answers[input]
+		if !ok {
+			continue
+		}
+
+		actual := wt.relation(a, b)
+		wt.asked++
+		verdict := "Not quite"
+		if guess == actual {
+			wt.correct++
+			verdict = "Correct"
+		}
+		phrases := map[string]string{"before": "happened before", "after": "happened after", "concurrent": "is concurrent with"}
+		fmt.Fprintf(wt.out, "  %s: %s %s %s (%s vs %s)\n", verdict, a, phrases[actual], b, wt.clock(a), wt.clock(b))
+		return true
+	}
+}
+
+// Kører (eller genoptager) partition walkthroughen. Fremskridt gemmes i statePath efter
+// hvert checkpoint og slettes når walkthroughen er færdig.
+func RunWalkthrough(in io.Reader, out io.Writer, statePath string) error {
+	start, correct, asked, err := loadWalkthroughState(statePath)
+	if err != nil {
+		return err
+	}
+	wt := &Walkthrough{
+		sim:       NewSimulation(3, true),
+		events:    make(map[string]walkthroughEvent),
+		statePath: statePath,
+		in:        bufio.NewScanner(in),
+		out:       out,
+		correct:   correct,
+		asked:     asked,
+	}
+
+	steps := partitionWalkthroughSteps()
+	if start > 0 && start < len(steps) {
+		fmt.Fprintf(out, "Resuming at step %d of %d (%d/%d correct so far)\n", start+1, len(steps), correct, asked)
+	}
+	for i, step := range steps {
+		if step.act != nil {
+			step.act(wt)
+		}
+		if i < start {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n--- Step %d/%d: %s ---\n%s\n\n", i+1, len(steps), step.title, step.narration)
+		wt.diagram()
+		if step.a != "" && !wt.ask(step.a, step.b) {
+			if err := wt.save(i); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\nProgress saved to %s; run the walkthrough again to resume.\n", statePath)
+			return nil
+		}
+		if err := wt.save(i + 1); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "\nWalkthrough complete: %d/%d predictions correct\n", wt.correct, wt.asked)
+	fmt.Fprintln(out, "\n=== Analysis ===")
+	fmt.Fprintln(out, "• Writes on both sides of a partition are concurrent, no matter which happened first in real time")
+	fmt.Fprintln(out, "• Healing does not order them; it only lets each side learn about the other")
+	fmt.Fprintln(out, "• A merge written after seeing both versions is after both, so it can safely replace them")
+	return os.Remove(statePath)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Et abonnement på en process' ur, oprettet med WatchClock
+type ClockWatch struct {
+	p       *Process
+	ch      chan<- ClockSnapshot
+	dropped atomic.Int64
+}
+
+// Abonnenterne på en process' ur
+type clockWatchers struct {
+	mutex   sync.Mutex
+	watches []*ClockWatch
+}
+
+// Sender processens ur til ch hver gang det ændres: ved lokale events, sends, receives,
+// recover og migration til vector clocks, også når events ikke gemmes (DiscardEvents).
+// Sendingen blokerer ikke, så en langsom abonnent aldrig bremser simulationen; er ch
+// fuld springes opdateringen over og tælles i Dropped. Giv ch en buffer efter behov.
+func (p *Process) WatchClock(ch chan<- ClockSnapshot) *ClockWatch {
+	w := &ClockWatch{p: p, ch: ch}
+	p.watchers.mutex.Lock()
+	defer p.watchers.mutex.Unlock()
+	p.watchers.watches = append(p.watchers.watches, w)
+	return w
+}
+
+// Stopper abonnementet (ch lukkes ikke, da abonnenten ejer den)
+func (w *ClockWatch) Stop() {
+	watchers := &w.p.watchers
+	watchers.mutex.Lock()
+	defer watchers.mutex.Unlock()
+	for i, other := range watchers.watches {
+		if other == w {
+			watchers.watches = append(watchers.watches[:i:i], watchers.watches[i+1:]...)
+			return
+		}
+	}
+}
+
+// Antal opdateringer der er sprunget over fordi ch var fuld
+func (w *ClockWatch) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Sender clock til alle abonnenter på p's ur
+func (p *Process) clockChanged(clock ClockSnapshot) {
+	p.watchers.mutex.Lock()
+	watches := p.watchers.watches
+	p.watchers.mutex.Unlock()
+	for _, w := range watches {
+		select {
+		case w.ch <- clock:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+}
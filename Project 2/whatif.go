@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Indeks i Events for den første levering af beskeden id, eller -1
+func (t *Recording) deliveryEvent(id string) int {
+	for i, e := range t.Events {
+		if e.Kind == "deliver" && e.MessageID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Kopi af optagelsen hvor den første levering af id sker by senere. Events på samme tid
+// som den nye leveringstid sker før den.
+func (t *Recording) DelayDelivery(id string, by time.Duration) (*Recording, error) {
+	i := t.deliveryEvent(id)
+	if i < 0 {
+		return nil, fmt.Errorf("message %s is never delivered", id)
+	}
+	if by <= 0 {
+		return nil, fmt.Errorf("delay must be positive, got %v", by)
+	}
+	delayed := t.Events[i]
+	delayed.AtNs += int64(by)
+
+	c := *t
+	c.Events = make([]RecordedEvent, 0, len(t.Events))
+	c.Events = append(c.Events, t.Events[:i]...)
+	c.Events = append(c.Events, t.Events[i+1:]...)
+	at := sort.Search(len(c.Events), func(j int) bool { return c.Events[j].AtNs > delayed.AtNs })
+	c.Events = append(c.Events[:at], append([]RecordedEvent{delayed}, c.Events[at:]...)...)
+	return &c, nil
+}
+
+// Kopi af optagelsen hvor to beskeder til samme proces leveres i omvendt rækkefølge: de
+// bytter plads og tid
+func (t *Recording) SwapDeliveries(a string, b string) (*Recording, error) {
+	i, j := t.deliveryEvent(a), t.deliveryEvent(b)
+	if i < 0 || j < 0 {
+		return nil, fmt.Errorf("messages %s and %s must both be delivered", a, b)
+	}
+	if t.Events[i].Process != t.Events[j].Process || i == j {
+		return nil, fmt.Errorf("%s and %s are not two deliveries to the same process", a, b)
+	}
+	c := *t
+	c.Events = append([]RecordedEvent(nil), t.Events...)
+	c.Events[i].MessageID, c.Events[j].MessageID = b, a
+	return &c, nil
+}
+
+// En relation mellem to events der er anderledes efter en ændring af en kørsel
+type CausalChange struct {
+	First, Second string // Eventene som de er nævnt i EventKeys
+	Was, Now      Relation
+}
+
+// Navngiver hvert event uden clock værdier, så det samme event kan findes i to kørsler af
+// samme optagelse: "P0 send P0-1", "P2 receive P0-1" og "P1 local #2" (processens anden
+// lokale operation). En dublet levering får "#2" på.
+func EventKeys(sim *Simulation) map[string]ClockSnapshot {
+	keys := make(map[string]ClockSnapshot)
+	for _, p := range sim.Processes {
+		seen := make(map[string]int)
+		for i := range p.EventLog {
+			e := p.loggedEvent(i)
+			key := e.Label + " " + e.Type
+			if e.MessageID != "" {
+				key += " " + e.MessageID
+			}
+			seen[key]++
+			if e.Type == "local" || seen[key] > 1 {
+				key += fmt.Sprintf(" #%d", seen[key])
+			}
+			keys[key] = e.Clock
+		}
+	}
+	return keys
+}
+
+// Event par hvis happened-before relation er en anden i after end i before (begge kørt
+// med vector clocks). Events der kun findes i den ene kørsel er ikke med.
+func CausalChanges(before *Simulation, after *Simulation) []CausalChange {
+	was, now := EventKeys(before), EventKeys(after)
+	keys := make([]string, 0, len(was))
+	for key := range was {
+		if _, ok := now[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]CausalChange, 0)
+	for i, first := range keys {
+		for _, second := range keys[i+1:] {
+			r1 := Compare(was[first].Vector(), was[second].Vector())
+			r2 := Compare(now[first].Vector(), now[second].Vector())
+			if r1 != r2 {
+				changes = append(changes, CausalChange{First: first, Second: second, Was: r1, Now: r2})
+			}
+		}
+	}
+	return changes
+}
+
+// Højeste antal ændringer whatif viser efter en ændring
+const maxShownChanges = 20
+
+// Print funktion
+func printCausalChanges(out io.Writer, changes []CausalChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No causal relationships changed")
+		return
+	}
+	fmt.Fprintf(out, "%d causal relationships changed:\n", len(changes))
+	fmt.Fprintf(out, "  %-24s | %-24s | %-10s | %s\n", "Event", "Event", "Was", "Now")
+	fmt.Fprintln(out, "  -------------------------|--------------------------|------------|-----------")
+	for _, c := range changes[:min(len(changes), maxShownChanges)] {
+		fmt.Fprintf(out, "  %-24s | %-24s | %-10s | %s\n", c.First, c.Second, c.Was, c.Now)
+	}
+	if len(changes) > maxShownChanges {
+		fmt.Fprintf(out, "  ... and %d more\n", len(changes)-maxShownChanges)
+	}
+}
+
+// Print funktion: beskederne i kørslen med afsender, modtager og leveringstid
+func printDeliveries(out io.Writer, t *Recording, sim *Simulation) {
+	fmt.Fprintf(out, "%-10s | %-10s | %-10s | %-10s | %s\n", "Message", "From", "To", "Delivered", "Payload")
+	fmt.Fprintln(out, "-----------|------------|------------|------------|--------")
+	for _, edge := range MessageEdges(sim) {
+		at := time.Duration(0)
+		if i := t.deliveryEvent(edge.ID); i >= 0 {
+			at = time.Duration(t.Events[i].AtNs)
+		}
+		_, payload, _ := strings.Cut(edge.Send.Log, edge.Send.Clock.String()+": ")
+		fmt.Fprintf(out, "%-10s | %-10s | %-10s | %-10v | %s\n", edge.ID, edge.Send.Label, edge.Receive.Label, at, payload)
+	}
+}
+
+// Kører en interaktiv what-if session på optagelsen: leveringer kan forsinkes eller byttes
+// om, og efter hver ændring afspilles kørslen med vector clocks og de causal relationer
+// der er anderledes end i den optagne kørsel vises. Ændringer lægges oven på hinanden
+// indtil "reset".
+func WhatIf(t *Recording, in io.Reader, out io.Writer) error {
+	original, err := t.Replay("Vector")
+	if err != nil {
+		return err
+	}
+	current := t
+	input := bufio.NewScanner(in)
+	fmt.Fprintln(out, "list | delay <message> <duration> | swap <message> <message> | logs | reset | q")
+	for {
+		fmt.Fprint(out, "> ")
+		if !input.Scan() {
+			return input.Err()
+		}
+		fields := strings.Fields(input.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var changed *Recording
+		switch {
+		case fields[0] == "q":
+			return nil
+		case fields[0] == "list" || fields[0] == "logs":
+			sim, err := current.Replay("Vector")
+			if err != nil {
+				return err
+			}
+			if fields[0] == "list" {
+				printDeliveries(out, current, sim)
+			} else {
+				sim.Out = out
+				sim.PrintLogs()
+			}
+			continue
+		case fields[0] == "reset":
+			current = t
+			fmt.Fprintln(out, "Back to the recorded run")
+			continue
+		case fields[0] == "delay" && len(fields) == 3:
+			by, parseErr := time.ParseDuration(fields[2])
+			if parseErr != nil {
+				fmt.Fprintln(out, parseErr)
+				continue
+			}
+			changed, err = current.DelayDelivery(fields[1], by)
+		case fields[0] == "swap" && len(fields) == 3:
+			changed, err = current.SwapDeliveries(fields[1], fields[2])
+		default:
+			fmt.Fprintf(out, "unknown command %q\n", input.Text())
+			continue
+		}
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+
+		sim, err := changed.Replay("Vector")
+		if err != nil {
+			fmt.Fprintf(out, "cannot replay the change: %v\n", err)
+			continue
+		}
+		current = changed
+		printCausalChanges(out, CausalChanges(original, sim))
+	}
+}
+
+// "whatif <optagelse>": interaktiv what-if på en optagelse lavet med scenario run -record
+func whatifCommand(args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: whatif <recording file>")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	t, err := ReadRecording(file)
+	if err != nil {
+		return err
+	}
+	return WhatIf(t, in, out)
+}
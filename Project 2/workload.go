@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Versionen af workload formatet. Filer med en anden version afvises i stedet for at
+// blive kørt forkert.
+const WorkloadFormat = "dissy-workload/1"
+
+// Simuleret starttid for workloads, så også HLC værdier er ens fra maskine til maskine
+var workloadEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Et abstrakt event i en workload: hvad der sker, ikke hvilken clock værdi det får
+type WorkloadEvent struct {
+	AtMs    int64  `json:"at_ms"`        // Simuleret tid i ms fra start
+	Process int    `json:"process"`      // Processen eventet sker på
+	Kind    string `json:"kind"`         // "local" eller "send"
+	To      *int   `json:"to,omitempty"` // Modtageren for send events
+	Message string `json:"message,omitempty"`
+}
+
+// En workload der kan deles som fil: et seed og en tidsplan af events der ikke afhænger
+// af urtypen. Seedet styrer netværkets latency og rækkefølgen af events på samme tid, så
+// samme fil giver præcis samme interleaving med alle ure. Expect er fingerprints fra
+// forfatterens kørsel per urtype, så andre kan se at de har genskabt den.
+type Workload struct {
+	Format      string            `json:"format"`
+	Description string            `json:"description,omitempty"`
+	Processes   int               `json:"processes"`
+	Seed        int64             `json:"seed"`
+	Events      []WorkloadEvent   `json:"events"`
+	Expect      map[string]string `json:"expect,omitempty"`
+}
+
+// Laver en tilfældig workload: events fordelt over processerne med 0-4 ms imellem, hvor
+// en andel sendRatio er sends til en anden tilfældig proces
+func GenerateWorkload(processes int, events int, sendRatio float64, seed int64) *Workload {
+	rng := rand.New(rand.NewSource(seed))
+	w := &Workload{Format: WorkloadFormat, Processes: processes, Seed: seed, Events: make([]WorkloadEvent, 0, events)}
+	at := int64(0)
+	for i := 0; i < events; i++ {
+		at += int64(rng.Intn(5))
+		event := WorkloadEvent{AtMs: at, Process: rng.Intn(processes), Kind: "local", Message: fmt.Sprintf("e%d", i)}
+		if processes > 1 && rng.Float64() < sendRatio {
+			to := (event.Process + 1 + rng.Intn(processes-1)) % processes
+			event.Kind, event.To = "send", &to
+		}
+		w.Events = append(w.Events, event)
+	}
+	return w
+}
+
+// Tjekker at workloaden kan køres
+func (w *Workload) Validate() error {
+	if w.Format != WorkloadFormat {
+		return fmt.Errorf("unsupported workload format %q (expected %s)", w.Format, WorkloadFormat)
+	}
+	if w.Processes < 1 {
+		return fmt.Errorf("workload needs at least one process, has %d", w.Processes)
+	}
+	for i, e := range w.Events {
+		if e.Process < 0 || e.Process >= w.Processes {
+			return fmt.Errorf("event %d: process %d out of range", i, e.Process)
+		}
+		if e.AtMs < 0 {
+			return fmt.Errorf("event %d: negative time %d ms", i, e.AtMs)
+		}
+		switch e.Kind {
+		case "local":
+		case "send":
+			if e.To == nil || *e.To < 0 || *e.To >= w.Processes || *e.To == e.Process {
+				return fmt.Errorf("event %d: send needs a target other than the sender", i)
+			}
+		default:
+			return fmt.Errorf("event %d: unknown kind %q (use local or send)", i, e.Kind)
+		}
+	}
+	return nil
+}
+
+// Kører workloaden med den valgte urtype (en af clockTypes) på en scheduler med
+// workloadens seed, og returnerer simulationen med logs
+func (w *Workload) Run(clockType string) (*Simulation, error) {
+	return w.run(clockType, nil)
+}
+
+func (w *Workload) run(clockType string, recording *Recording) (*Simulation, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	sim := newSimulationOfType(w.Processes, clockType)
+	sim.Out = io.Discard
+	s := NewScheduler(sim, w.Seed)
+	s.start = workloadEpoch
+	s.Recording = recording
+	for _, e := range w.Events {
+		at, p := time.Duration(e.AtMs)*time.Millisecond, sim.Processes[e.Process]
+		if e.Kind == "send" {
+			s.Send(at, p, sim.Processes[*e.To], e.Message)
+		} else {
+			s.Local(at, p, e.Message)
+		}
+	}
+	s.Run()
+	return sim, nil
+}
+
+// Et kort fingerprint af en kørsels logs (clock værdier, typer og beskeder per proces).
+// To kørsler med samme fingerprint har haft præcis samme interleaving.
+func RunFingerprint(sim *Simulation) string {
+	hash := sha256.New()
+	for _, p := range sim.Processes {
+		for i := range p.EventLog {
+			e := p.loggedEvent(i)
+			fmt.Fprintf(hash, "%s|%d|%s|%s|%s\n", e.Label, e.Index, e.Type, e.Clock, e.MessageID)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Skriver workloaden som JSON
+func WriteWorkload(w io.Writer, workload *Workload) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(workload)
+}
+
+// Læser en workload fra JSON. Ukendte felter er en fejl, så en stavefejl ikke
+// stille ændrer eksperimentet.
+func ReadWorkload(r io.Reader) (*Workload, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var w Workload
+	if err := decoder.Decode(&w); err != nil {
+		return nil, fmt.Errorf("read workload: %w", err)
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Udfylder Expect med fingerprints for alle ure og skriver workloaden til path
+func writeWorkloadFile(path string, w *Workload, out io.Writer) error {
+	w.Expect = make(map[string]string)
+	for _, clockType := range clockTypes {
+		sim, err := w.Run(clockType)
+		if err != nil {
+			return err
+		}
+		w.Expect[clockType] = RunFingerprint(sim)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := WriteWorkload(file, w); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %d events for %d processes to %s\n", len(w.Events), w.Processes, path)
+	return file.Close()
+}
+
+// "workload generate [flags] <file>", "workload fuzz [flags] <file>" og "workload run <file>"
+func workloadCommand(args []string, out io.Writer) error {
+	usage := fmt.Errorf("usage: workload generate [-processes n] [-events n] [-send-ratio r] [-seed s] <file> | " +
+		"workload fuzz [-processes n] [-events n] [-objective o] [-iterations n] [-seed s] <file> | workload run <file>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "generate":
+		flags := flag.NewFlagSet("workload generate", flag.ContinueOnError)
+		flags.SetOutput(out)
+		processes := flags.Int("processes", 3, "number of processes")
+		events := flags.Int("events", 50, "number of events")
+		sendRatio := flags.Float64("send-ratio", 0.5, "fraction of events that are sends")
+		seed := flags.Int64("seed", 1, "seed for the workload and the network")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return usage
+		}
+		return writeWorkloadFile(flags.Arg(0), GenerateWorkload(*processes, *events, *sendRatio, *seed), out)
+
+	case "fuzz":
+		flags := flag.NewFlagSet("workload fuzz", flag.ContinueOnError)
+		flags.SetOutput(out)
+		processes := flags.Int("processes", 4, "number of processes")
+		events := flags.Int("events", 40, "approximate number of events")
+		objective := flags.String("objective", "concurrency", "what to maximize: concurrency, depth or backlog")
+		iterations := flags.Int("iterations", 1000, "number of mutations to try")
+		seed := flags.Int64("seed", 1, "seed for the search and the network")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return usage
+		}
+		if !containsString(fuzzObjectives, *objective) {
+			return fmt.Errorf("unknown objective %q (use concurrency, depth or backlog)", *objective)
+		}
+		search, err := GenerateStressWorkload(*processes, *events, *objective, *iterations, *seed)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s: %d (random workload: %d)\n", *objective, search.BestScore.Score(*objective), search.Baseline.Score(*objective))
+		return writeWorkloadFile(flags.Arg(0), search.Best, out)
+
+	case "run":
+		if len(args) != 2 {
+			return usage
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w, err := ReadWorkload(file)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%-8s | %-8s | %-16s | %s\n", "Clock", "Events", "Fingerprint", "Reproduced")
+		fmt.Fprintln(out, "---------|----------|------------------|-----------")
+		for _, clockType := range clockTypes {
+			sim, err := w.Run(clockType)
+			if err != nil {
+				return err
+			}
+			events := 0
+			for _, p := range sim.Processes {
+				events += len(p.EventLog)
+			}
+			fingerprint, reproduced := RunFingerprint(sim), "-"
+			if expected, ok := w.Expect[clockType]; ok {
+				reproduced = fmt.Sprint(expected == fingerprint)
+			}
+			fmt.Fprintf(out, "%-8s | %-8d | %-16s | %s\n", clockType, events, fingerprint, reproduced)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown workload command %q (use generate, fuzz or run)", args[0])
+}